@@ -0,0 +1,75 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+// NewBytesReader returns a new Reader that reads from data, which is
+// already in memory, such as a memory-mapped file or an embedded asset.
+// The fields returned by Read and ReadAll share the memory of data
+// instead of being copied, whenever a field appears in data exactly as
+// it is returned: fields that hold doubled quotes, escapes or line
+// breaks normalized from \r\n, or that are otherwise changed by the
+// options, are copied one by one. Reading large inputs therefore
+// allocates little beyond the slices of fields.
+//
+// Since the fields alias data, data must not be modified while any of
+// them is in use; copy the fields, for example with strings.Clone, to
+// keep them past a change. Reset makes the Reader copy its fields again,
+// like any other Reader. With InternStrings or Decoder set, the fields
+// are never aliased.
+func NewBytesReader(data []byte) *Reader {
+	r := NewReader(bytes.NewReader(data))
+	r.data = data
+	return r
+}
+
+// aliasFields sets dst to the fields of the record just parsed, sharing
+// the memory of data where the field is found there.
+func (r *Reader) aliasFields(dst []string) {
+	var preIdx int
+	for i, idx := range r.fieldIndexes {
+		field := r.recordBuffer[preIdx:idx]
+		if s, ok := r.inputString(i, field); ok {
+			dst[i] = s
+		} else {
+			dst[i] = string(field)
+		}
+		preIdx = idx
+	}
+}
+
+// inputString returns field, the i-th field of the record just parsed,
+// as a string sharing the memory of data, if data holds it where the
+// field starts.
+func (r *Reader) inputString(i int, field []byte) (string, bool) {
+	if len(field) == 0 {
+		return "", true
+	}
+	if i >= len(r.fieldPositions) {
+		return "", false
+	}
+	pos := r.fieldPositions[i]
+	line := pos.line - r.resume.numLine // The record's first line is 0
+	if line < 0 || line >= len(r.lineStarts) {
+		return "", false
+	}
+	off := r.lineStarts[line] + int64(pos.col-1)
+	if r.fieldQuoted[i] {
+		off += int64(len(r.quote))
+	}
+	if off < 0 || off > int64(len(r.data)-len(field)) {
+		return "", false
+	}
+	in := r.data[off : off+int64(len(field))]
+	if !bytes.Equal(in, field) {
+		return "", false
+	}
+	return unsafe.String(&in[0], len(in)), true
+}
@@ -0,0 +1,73 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestNewBytesReader(t *testing.T) {
+	data := []byte("a,\"b,c\",\"d\"\"e\"\r\n\"f\ng\",,h\r\n")
+	r := NewBytesReader(data)
+	got, err := r.ReadAll()
+	want := [][]string{{"a", "b,c", `d"e`}, {"f\ng", "", "h"}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadAll() = %q, %v; want %q", got, err, want)
+	}
+	inData := func(s string) bool {
+		p := uintptr(unsafe.Pointer(unsafe.StringData(s)))
+		start := uintptr(unsafe.Pointer(&data[0]))
+		return p >= start && p+uintptr(len(s)) <= start+uintptr(len(data))
+	}
+	for i, field := range []string{got[0][0], got[0][1], got[1][0], got[1][2]} {
+		if !inData(field) {
+			t.Errorf("field %d %q was copied, want it to share the input", i, field)
+		}
+	}
+	if inData(got[0][2]) {
+		t.Errorf("field %q with a doubled quote shares the input", got[0][2])
+	}
+
+	// Reset makes the Reader copy its fields again.
+	r.Reset(bytes.NewReader(data))
+	if got, err := r.Read(); err != nil || inData(got[0]) {
+		t.Errorf("Read() after Reset = %q, %v; want a copied field", got, err)
+	}
+}
+
+func BenchmarkNewBytesReader(b *testing.B) {
+	var buf bytes.Buffer
+	for i := 0; i < 10_000; i++ {
+		fmt.Fprintf(&buf, "%d,widget %d,\"a, b\",%d.%02d\n", i, i%977, i%10000, i%100)
+	}
+	data := buf.Bytes()
+	read := func(b *testing.B, newReader func() *Reader) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r := newReader()
+			r.ReuseRecord = true
+			for {
+				if _, err := r.Read(); err == io.EOF {
+					break
+				} else if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+	b.Run("NewReader", func(b *testing.B) {
+		read(b, func() *Reader { return NewReader(bytes.NewReader(data)) })
+	})
+	b.Run("NewBytesReader", func(b *testing.B) {
+		read(b, func() *Reader { return NewBytesReader(data) })
+	})
+}
@@ -0,0 +1,253 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sort"
+	"unicode/utf8"
+)
+
+// A Transformer converts text between character encodings. Its method
+// set matches golang.org/x/text/transform.Transformer, so the decoders
+// and encoders of golang.org/x/text/encoding, such as
+// charmap.ISO8859_1.NewDecoder(), can be used as Reader.Decoder and
+// Writer.Encoder directly.
+type Transformer interface {
+	// Transform writes to dst the transformed bytes read from src, and
+	// returns the number of dst bytes written and src bytes read. atEOF
+	// reports whether src holds the last bytes of the input.
+	Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error)
+
+	// Reset resets the state and allows a Transformer to be reused.
+	Reset()
+}
+
+// maxUnit is the longest input sequence a Transformer is expected to
+// need before producing output or consuming input. A Transformer that
+// makes no progress on that many bytes is reporting an error.
+const maxUnit = 16
+
+// errNoProgress is returned when a Transformer neither consumes nor
+// produces bytes and gives no error of its own.
+var errNoProgress = errors.New("csv: transformer made no progress")
+
+// A span maps the decoded bytes starting at dec to the input bytes
+// starting at src. A linear span maps byte for byte; any other span
+// holds the output of one input sequence and maps it as a whole to the
+// start of that sequence.
+type span struct {
+	dec, src int64
+	linear   bool
+}
+
+// decodeReader applies a Transformer to an io.Reader, recording where
+// each decoded byte came from so that offsets can be reported in terms
+// of the original input.
+type decodeReader struct {
+	r   io.Reader
+	t   Transformer
+	err error // Sticky read error of r
+
+	in  []byte // Input read from r but not yet transformed
+	out []byte // Decoded bytes not yet returned by Read
+	buf [4096]byte
+	dst [64]byte
+
+	spans    []span
+	dec, src int64 // Decoded and input bytes transformed so far
+	flushed  bool  // The final Transform call at EOF has been made
+}
+
+func newDecodeReader(r io.Reader, t Transformer, offset int64) *decodeReader {
+	t.Reset()
+	return &decodeReader{r: r, t: t, dec: offset, src: offset}
+}
+
+func (d *decodeReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil && len(d.in) == 0 && d.flushed {
+			return 0, d.err
+		}
+		if err := d.decode(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// decode fills d.out with the decoded form of the next block of input.
+// Each input sequence is transformed on its own so that its position in
+// the input is known.
+func (d *decodeReader) decode() error {
+	if len(d.in) == 0 || d.err == nil && len(d.in) < maxUnit {
+		n := copy(d.buf[:], d.in)
+		m, err := d.r.Read(d.buf[n:])
+		d.in = d.buf[:n+m]
+		if err != nil {
+			d.err = err
+		}
+	}
+	eof := d.err != nil
+	d.out = d.out[:0]
+	for len(d.in) > 0 {
+		k := 1
+		var nDst, nSrc int
+		var err error
+		for {
+			atEOF := eof && k == len(d.in)
+			nDst, nSrc, err = d.t.Transform(d.dst[:], d.in[:k], atEOF)
+			if nDst > 0 || nSrc > 0 {
+				break
+			}
+			if k == len(d.in) || k == maxUnit {
+				if atEOF || k == maxUnit {
+					if err == nil {
+						err = errNoProgress
+					}
+					return err
+				}
+				// The sequence continues past the input read so far.
+				return nil
+			}
+			k++
+		}
+		d.record(nDst, nSrc)
+		d.out = append(d.out, d.dst[:nDst]...)
+		d.in = d.in[nSrc:]
+		if len(d.out) >= len(d.buf) {
+			return nil
+		}
+	}
+	if eof && !d.flushed {
+		// Let the Transformer write out any state it still holds.
+		d.flushed = true
+		nDst, _, err := d.t.Transform(d.dst[:], nil, true)
+		d.record(nDst, 0)
+		d.out = append(d.out, d.dst[:nDst]...)
+		return err
+	}
+	return nil
+}
+
+// record adds the transformation of nSrc input bytes into nDst decoded
+// bytes to the span list.
+func (d *decodeReader) record(nDst, nSrc int) {
+	linear := nDst == nSrc
+	if n := len(d.spans); !linear || n == 0 || !d.spans[n-1].linear {
+		d.spans = append(d.spans, span{dec: d.dec, src: d.src, linear: linear})
+	}
+	d.dec += int64(nDst)
+	d.src += int64(nSrc)
+}
+
+// srcOffset returns the input offset of the decoded offset off.
+func (d *decodeReader) srcOffset(off int64) int64 {
+	if off >= d.dec {
+		return d.src
+	}
+	i := sort.Search(len(d.spans), func(i int) bool { return d.spans[i].dec > off }) - 1
+	if i < 0 {
+		return off
+	}
+	s := d.spans[i]
+	if s.linear {
+		return s.src + off - s.dec
+	}
+	return s.src
+}
+
+// discard drops the spans that end before the decoded offset off, which
+// is no longer asked for.
+func (d *decodeReader) discard(off int64) {
+	i := sort.Search(len(d.spans), func(i int) bool { return d.spans[i].dec > off }) - 1
+	if i > 0 {
+		d.spans = append(d.spans[:0], d.spans[i:]...)
+	}
+}
+
+// useDecoder inserts Decoder between the input and the parser. It is
+// applied once, before the first read.
+func (r *Reader) useDecoder() {
+	if r.Decoder == nil || r.dec != nil {
+		return
+	}
+	r.dec = newDecodeReader(r.r, r.Decoder, r.offset)
+	r.r = bufio.NewReaderSize(r.dec, r.r.Size())
+}
+
+// inputColumn converts a 1-based column of the given line of the current
+// record from decoded bytes to input bytes.
+func (r *Reader) inputColumn(line, col, recLine int) int {
+	i := line - recLine
+	if i < 0 || i >= len(r.lineStarts) || col < 1 {
+		return col
+	}
+	start := r.lineStarts[i]
+	return int(r.dec.srcOffset(start+int64(col-1))-r.dec.srcOffset(start)) + 1
+}
+
+// encodeWriter applies a Transformer to the bytes written to w.
+type encodeWriter struct {
+	w       io.Writer
+	t       Transformer
+	pending []byte // Incomplete input sequence held for the next Write
+	dst     [4096]byte
+}
+
+func newEncodeWriter(w io.Writer, t Transformer) *encodeWriter {
+	t.Reset()
+	return &encodeWriter{w: w, t: t}
+}
+
+func (e *encodeWriter) Write(p []byte) (int, error) {
+	src := p
+	if len(e.pending) > 0 {
+		e.pending = append(e.pending, p...)
+		src = e.pending
+	}
+	if err := e.encode(src, false); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// encode transforms src and writes the result. Unless atEOF is set, an
+// incomplete sequence at the end of src is kept for the next call.
+func (e *encodeWriter) encode(src []byte, atEOF bool) error {
+	for {
+		nDst, nSrc, err := e.t.Transform(e.dst[:], src, atEOF)
+		if nDst > 0 {
+			if _, werr := e.w.Write(e.dst[:nDst]); werr != nil {
+				return werr
+			}
+		}
+		src = src[nSrc:]
+		if len(src) == 0 {
+			e.pending = e.pending[:0]
+			return nil
+		}
+		if nDst == 0 && nSrc == 0 {
+			if !atEOF && !utf8.FullRune(src) {
+				e.pending = append(e.pending[:0], src...)
+				return nil
+			}
+			if err == nil {
+				err = errNoProgress
+			}
+			return err
+		}
+	}
+}
+
+// close writes out any output the Transformer still holds.
+func (e *encodeWriter) close() error {
+	return e.encode(e.pending, true)
+}
@@ -0,0 +1,147 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+)
+
+var (
+	errShortDst      = errors.New("short destination buffer")
+	errShortSrc      = errors.New("short source buffer")
+	errNotLatin1     = errors.New("character not in Latin-1")
+	latin1Input      = "name,city\n\"Zo\xeb, Jos\xe9\",K\xf6ln\n\xe9t\xe9,\xfc\"x\n"
+	latin1Equivalent = "name,city\n\"Zoe, Jose\",Koln\nete,u\"x\n"
+)
+
+// latin1Decoder converts Latin-1 to UTF-8.
+type latin1Decoder struct{}
+
+func (latin1Decoder) Reset() {}
+
+func (latin1Decoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for _, c := range src {
+		if nDst+utf8.RuneLen(rune(c)) > len(dst) {
+			return nDst, nSrc, errShortDst
+		}
+		nDst += utf8.EncodeRune(dst[nDst:], rune(c))
+		nSrc++
+	}
+	return nDst, nSrc, nil
+}
+
+// latin1Encoder converts UTF-8 to Latin-1.
+type latin1Encoder struct{}
+
+func (latin1Encoder) Reset() {}
+
+func (latin1Encoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		if !atEOF && !utf8.FullRune(src[nSrc:]) {
+			return nDst, nSrc, errShortSrc
+		}
+		c, size := utf8.DecodeRune(src[nSrc:])
+		if c > 0xff {
+			return nDst, nSrc, errNotLatin1
+		}
+		if nDst == len(dst) {
+			return nDst, nSrc, errShortDst
+		}
+		dst[nDst] = byte(c)
+		nDst++
+		nSrc += size
+	}
+	return nDst, nSrc, nil
+}
+
+func TestReadDecoder(t *testing.T) {
+	for _, oneByte := range []bool{false, true} {
+		var in io.Reader = strings.NewReader(latin1Input)
+		if oneByte {
+			in = iotest.OneByteReader(in)
+		}
+		r := NewReader(in)
+		r.Decoder = latin1Decoder{}
+		if _, err := r.Read(); err != nil {
+			t.Fatalf("Read header: %v", err)
+		}
+		rec, err := r.Read()
+		if want := []string{"Zoë, José", "Köln"}; err != nil || !reflect.DeepEqual(rec, want) {
+			t.Fatalf("Read = %q, %v; want %q", rec, err, want)
+		}
+		if line, col := r.FieldPos(1); line != 2 || col != 13 {
+			t.Errorf("FieldPos(1) = %d, %d; want 2, 13", line, col)
+		}
+		if got, want := r.InputOffset(), int64(strings.Index(latin1Input, "\xe9t")); got != want {
+			t.Errorf("InputOffset = %d, want %d", got, want)
+		}
+		_, err = r.Read()
+
+		// The error must be where it is in an input with the same byte
+		// positions that needs no decoding, not at the decoded column 8.
+		_, want := NewReader(strings.NewReader(latin1Equivalent)).ReadAll()
+		var pe *ParseError
+		if !errors.As(want, &pe) || pe.Column != 6 {
+			t.Fatalf("reference error = %v, want column 6", want)
+		}
+		if !reflect.DeepEqual(err, want) {
+			t.Errorf("Read error = %v, want %v", err, want)
+		}
+	}
+}
+
+func TestReadDecoderError(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\n\xe9\n"))
+	r.Decoder = latin1Encoder{} // Rejects \xe9, which is not UTF-8
+	if _, err := r.ReadAll(); err != errNotLatin1 {
+		t.Errorf("ReadAll error = %v, want the Decoder's error", err)
+	}
+}
+
+func TestWriteEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Encoder = latin1Encoder{}
+	w.Write([]string{"name", "city"})
+	w.Write([]string{"Zoë, José", "Köln"})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		t.Fatalf("Error = %v", err)
+	}
+	if got, want := buf.String(), "name,city\n\"Zo\xeb, Jos\xe9\",K\xf6ln\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if got, want := w.BytesWritten(), int64(len("name,city\n\"Zoë, José\",Köln\n")); got != want {
+		t.Errorf("BytesWritten = %d, want %d", got, want)
+	}
+
+	// A value split across buffer flushes is encoded whole.
+	buf.Reset()
+	w = NewWriter(&buf)
+	w.Encoder = latin1Encoder{}
+	long := strings.Repeat("é", 5000)
+	w.Write([]string{long})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	if got, want := buf.String(), strings.Repeat("\xe9", 5000)+"\n"; got != want {
+		t.Errorf("output has %d bytes, want %d", len(got), len(want))
+	}
+
+	w = NewWriter(&buf)
+	w.Encoder = latin1Encoder{}
+	w.Write([]string{"5 €"})
+	if err := w.Close(); err != errNotLatin1 {
+		t.Errorf("Close = %v, want %v", err, errNotLatin1)
+	}
+}
@@ -0,0 +1,60 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"io"
+	"unsafe"
+)
+
+const (
+	arenaChunk = 64 << 10 // Bytes of field data per arena chunk
+	arenaSlab  = 1 << 10  // Fields per block of string headers
+)
+
+// ReadAllCompact is like ReadAll but allocates far less for inputs with
+// many small records: the field data of the records is copied into
+// shared chunks of about 64KB, of which the fields are substrings, and
+// the records are cut from shared blocks of string headers. The records
+// returned are equal to those ReadAll would return.
+//
+// Since the records share memory, a single record or field that is kept
+// keeps its whole chunk and block reachable, which can hold up to about
+// 64KB of unrelated data; copy the records that outlive the others, for
+// example with strings.Clone and slices.Clone. Appending to a returned
+// record reallocates it rather than overwriting its neighbour.
+func (r *Reader) ReadAllCompact() (records [][]string, err error) {
+	var chunk []byte     // Free space of the current data chunk
+	var headers []string // Free space of the current header block
+	for {
+		ok, err := r.nextRecord()
+		if !ok && err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(r.recordBuffer) > cap(chunk) {
+			chunk = make([]byte, 0, max(arenaChunk, len(r.recordBuffer)))
+		}
+		n := len(r.fieldIndexes)
+		if n > len(headers) {
+			headers = make([]string, max(arenaSlab, n))
+		}
+		data := append(chunk, r.recordBuffer...)
+		chunk = data[len(data):]
+		record := headers[:n:n]
+		headers = headers[n:]
+		var preIdx int
+		for i, idx := range r.fieldIndexes {
+			if idx > preIdx {
+				record[i] = unsafe.String(&data[preIdx], idx-preIdx)
+			}
+			preIdx = idx
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,67 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadAllCompact(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, "%d,\"x \"\"%d\"\"\",,%s\n", i, i%7, strings.Repeat("y", i%50))
+	}
+	b.WriteString("big," + strings.Repeat("z", 100_000) + ",,\n")
+	want, err := NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewReader(strings.NewReader(b.String())).ReadAllCompact()
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadAllCompact() = %d records, %v; want the %d of ReadAll", len(got), err, len(want))
+	}
+	got[0] = append(got[0], "extra")
+	if got[1][0] != "1" {
+		t.Errorf("appending to a record changed the next one: %q", got[1])
+	}
+
+	r := NewReader(strings.NewReader("a,b\nc\n"))
+	if got, err := r.ReadAllCompact(); got != nil || err == nil {
+		t.Errorf("ReadAllCompact() with a short record = %q, %v; want an error", got, err)
+	}
+	if got, err := NewReader(strings.NewReader("")).ReadAllCompact(); got != nil || err != nil {
+		t.Errorf("ReadAllCompact() of no input = %q, %v", got, err)
+	}
+}
+
+func BenchmarkReadAllCompact(b *testing.B) {
+	var buf bytes.Buffer
+	for i := 0; i < 100_000; i++ {
+		fmt.Fprintf(&buf, "%d,widget %d,%d.%02d\n", i, i%977, i%10000, i%100)
+	}
+	data := buf.Bytes()
+	for _, bm := range []struct {
+		name string
+		read func(*Reader) ([][]string, error)
+	}{
+		{"ReadAll", (*Reader).ReadAll},
+		{"ReadAllCompact", (*Reader).ReadAllCompact},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := bm.read(NewReader(bytes.NewReader(data))); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
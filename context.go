@@ -0,0 +1,59 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// contextCheckInterval is the number of records ReadContext and
+// ReadAllContext read between checks of their context.
+const contextCheckInterval = 1024
+
+// ReadContext is like Read but fails once ctx is done. The context is
+// checked on the first call and then once every 1024 calls, so reading
+// stops within 1024 records of the cancellation; a single record is never
+// interrupted. The error wraps ctx.Err() and gives the line and input
+// offset reached.
+func (r *Reader) ReadContext(ctx context.Context) (record []string, err error) {
+	if err := r.checkContext(ctx); err != nil {
+		return nil, err
+	}
+	return r.Read()
+}
+
+// ReadAllContext is like ReadAll but fails once ctx is done, checking it
+// as ReadContext does.
+func (r *Reader) ReadAllContext(ctx context.Context) (records [][]string, err error) {
+	for {
+		if err := r.checkContext(ctx); err != nil {
+			return nil, err
+		}
+		record, err := r.readRecord(nil)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// checkContext returns the error of ctx, once every contextCheckInterval
+// calls.
+func (r *Reader) checkContext(ctx context.Context) error {
+	r.numChecks++
+	if r.numChecks%contextCheckInterval != 1 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("csv: stopped at line %d, input offset %d: %w", r.numLine, r.InputOffset(), err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// endlessReader produces the record "a,b" forever and calls cancel once
+// it has produced after records.
+type endlessReader struct {
+	n      int
+	after  int
+	cancel func()
+}
+
+func (e *endlessReader) Read(p []byte) (int, error) {
+	n := 0
+	for len(p)-n >= 4 {
+		n += copy(p[n:], "a,b\n")
+		e.n++
+		if e.n == e.after {
+			e.cancel()
+		}
+	}
+	return n, nil
+}
+
+func TestReadAllContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := &endlessReader{after: 1000, cancel: cancel}
+	r := NewReader(in)
+	records, err := r.ReadAllContext(ctx)
+	if !errors.Is(err, context.Canceled) || records != nil {
+		t.Fatalf("ReadAllContext = %d records, %v; want context.Canceled", len(records), err)
+	}
+	if line, _ := r.FieldPos(0); line > 1000+contextCheckInterval+1 {
+		t.Errorf("stopped at line %d, want within %d records of 1000", line, contextCheckInterval)
+	}
+}
+
+func TestReadContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewReader(&endlessReader{})
+	if _, err := r.ReadContext(ctx); err != nil {
+		t.Fatalf("ReadContext: %v", err)
+	}
+	cancel()
+	var err error
+	n := 0
+	for err == nil {
+		_, err = r.ReadContext(ctx)
+		n++
+	}
+	if !errors.Is(err, context.Canceled) || n > contextCheckInterval {
+		t.Errorf("ReadContext failed after %d records with %v, want context.Canceled within %d", n, err, contextCheckInterval)
+	}
+	if got, want := err.Error(), "csv: stopped at line 1024, input offset 4096: context canceled"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,50 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import "io"
+
+// Convert reads the records of src in the format described by in and
+// writes them to dst in the format described by out, one record at a
+// time, so that files of any size can be converted, and returns the
+// number of records written. The output is configured from out as by
+// WriterPool, with RequireCRLF also setting UseCRLF, and each field is
+// quoted as out needs it, not as it was quoted in src. NULL fields, such
+// as \N with DecodeBackslashEscapes or those matching in.Null, are
+// written as NULL in the output format.
+//
+// Convert flushes the output but does not close dst. It stops at the
+// first error from reading or writing, even one like ErrFieldCount that
+// comes with a record, and returns it along with the number of records
+// written before it.
+func Convert(src io.Reader, in Dialect, dst io.Writer, out Dialect) (int64, error) {
+	r := NewReader(src)
+	in.configure(r)
+	w := NewWriter(dst)
+	out.configureWriter(w)
+	w.UseCRLF = out.RequireCRLF
+	var n int64
+	var err error
+	for {
+		var record [][]byte
+		if record, err = r.ReadBytes(); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			break
+		}
+		if err = write(w, record, nil, r.fieldNull); err != nil {
+			break
+		}
+		n++
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ferr := w.flush(); err == nil {
+		err = ferr
+	}
+	return n, err
+}
@@ -0,0 +1,63 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		in, out Dialect
+		want    string
+		records int64
+		err     error
+	}{{
+		name:    "SemicolonCRLFToComma",
+		input:   "id;name;note\r\n1;\"Lee; Ann\";a,b\r\n2;Bob;\"say \"\"hi\"\"\"\r\n",
+		in:      Dialect{Comma: ';', RequireCRLF: true},
+		want:    "id,name,note\n1,Lee; Ann,\"a,b\"\n2,Bob,\"say \"\"hi\"\"\"\n",
+		records: 3,
+	}, {
+		name:    "CommaToTabCRLF",
+		input:   "a,\"b\tc\",\"d,e\"\n",
+		out:     Dialect{Comma: '\t', RequireCRLF: true},
+		want:    "a\t\"b\tc\"\td,e\r\n",
+		records: 1,
+	}, {
+		name:    "Null",
+		input:   "a\t\\N\tNA\n",
+		in:      Dialect{Comma: '\t', DecodeBackslashEscapes: true},
+		out:     Dialect{Null: "NA"},
+		want:    "a,NA,\"NA\"\n",
+		records: 1,
+	}, {
+		name:    "Empty",
+		records: 0,
+	}, {
+		name:    "FieldCount",
+		input:   "a,b\nc\nd,e\n",
+		want:    "a,b\n",
+		records: 1,
+		err:     ErrFieldCount,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			n, err := Convert(strings.NewReader(tt.input), tt.in, &b, tt.out)
+			if !errors.Is(err, tt.err) || n != tt.records {
+				t.Errorf("Convert = %d, %v, want %d, %v", n, err, tt.records, tt.err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("output = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
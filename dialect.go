@@ -0,0 +1,132 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+// A Dialect describes the format of a CSV file, for functions that create
+// their own Readers. Each field has the meaning of the Reader field of the
+// same name. The zero Dialect describes the format expected by a Reader as
+// returned by NewReader.
+type Dialect struct {
+	Comma                      rune // Field delimiter (',' if 0)
+	Quote                      rune // Quote character ('"' if 0)
+	QuoteOpen                  rune
+	QuoteClose                 rune
+	Separator                  string
+	CollapseDelimiters         bool
+	AllowTrailingComma         bool
+	Whitespace                 bool
+	DoubledDelimiterEscape     bool
+	Comment                    rune
+	InlineComments             bool
+	KeepCommentSpace           bool
+	FieldsPerRecord            int
+	OnShortRow                 ShortRowMode
+	OnLongRow                  LongRowMode
+	LazyQuotes                 bool
+	AllowBareQuotes            bool
+	AllowTextAfterClosingQuote bool
+	AllowUnterminatedQuote     bool
+	RelaxedOpenQuote           bool
+	RepairQuotes               bool
+	Strict                     bool
+	RequireCRLF                bool
+	Escape                     rune
+	QuoteEscape                rune
+	StrictQuoteEscape          bool
+	DecodeEscapes              bool
+	UnescapeNewlines           bool
+	DecodeBackslashEscapes     bool
+	RejectUnknownEscapes       bool
+	AcceptCR                   bool
+	Terminator                 string
+	BareCR                     BareCRMode
+	NormalizeLineEndings       bool
+	RejectNUL                  bool
+	MaxQuotedLines             int
+	InvalidUTF8                InvalidUTF8Mode
+	BlankLineMode              BlankLineMode
+	TrimLeadingSpace           bool
+	TrimSpace                  bool
+	NullIfUnquotedEmpty        bool
+	Null                       string
+}
+
+// configure copies the dialect into the options of r.
+func (d *Dialect) configure(r *Reader) {
+	if d.Comma != 0 {
+		r.Comma = d.Comma
+	}
+	if d.Quote != 0 {
+		r.Quote = d.Quote
+	}
+	r.QuoteOpen = d.QuoteOpen
+	r.QuoteClose = d.QuoteClose
+	r.Separator = d.Separator
+	r.CollapseDelimiters = d.CollapseDelimiters
+	r.AllowTrailingComma = d.AllowTrailingComma
+	r.Whitespace = d.Whitespace
+	r.DoubledDelimiterEscape = d.DoubledDelimiterEscape
+	r.Comment = d.Comment
+	r.InlineComments = d.InlineComments
+	r.KeepCommentSpace = d.KeepCommentSpace
+	r.FieldsPerRecord = d.FieldsPerRecord
+	r.OnShortRow = d.OnShortRow
+	r.OnLongRow = d.OnLongRow
+	r.LazyQuotes = d.LazyQuotes
+	r.AllowBareQuotes = d.AllowBareQuotes
+	r.AllowTextAfterClosingQuote = d.AllowTextAfterClosingQuote
+	r.AllowUnterminatedQuote = d.AllowUnterminatedQuote
+	r.RelaxedOpenQuote = d.RelaxedOpenQuote
+	r.RepairQuotes = d.RepairQuotes
+	r.Strict = d.Strict
+	r.RequireCRLF = d.RequireCRLF
+	r.Escape = d.Escape
+	r.QuoteEscape = d.QuoteEscape
+	r.StrictQuoteEscape = d.StrictQuoteEscape
+	r.DecodeEscapes = d.DecodeEscapes
+	r.UnescapeNewlines = d.UnescapeNewlines
+	r.DecodeBackslashEscapes = d.DecodeBackslashEscapes
+	r.RejectUnknownEscapes = d.RejectUnknownEscapes
+	r.AcceptCR = d.AcceptCR
+	r.Terminator = d.Terminator
+	r.BareCR = d.BareCR
+	r.NormalizeLineEndings = d.NormalizeLineEndings
+	r.RejectNUL = d.RejectNUL
+	r.MaxQuotedLines = d.MaxQuotedLines
+	r.InvalidUTF8 = d.InvalidUTF8
+	r.BlankLineMode = d.BlankLineMode
+	r.TrimLeadingSpace = d.TrimLeadingSpace
+	r.TrimSpace = d.TrimSpace
+	r.NullIfUnquotedEmpty = d.NullIfUnquotedEmpty
+	r.Null = d.Null
+}
+
+// configureWriter copies the parts of the dialect that describe the
+// output format into the options of w, as told by WriterPool.Dialect.
+func (d *Dialect) configureWriter(w *Writer) {
+	if d.Comma != 0 {
+		w.Comma = d.Comma
+	}
+	if d.Quote != 0 {
+		w.Quote = d.Quote
+	}
+	w.QuoteOpen = d.QuoteOpen
+	w.QuoteClose = d.QuoteClose
+	w.Comment = d.Comment
+	w.Null = d.Null
+	w.Escape = d.QuoteEscape
+	if w.Escape == 0 {
+		w.Escape = d.Escape
+	}
+	w.EscapeNewlines = d.UnescapeNewlines
+	w.EncodeBackslashEscapes = d.DecodeBackslashEscapes
+	w.Terminator = d.Terminator
+	w.DoubledDelimiterEscape = d.DoubledDelimiterEscape
+	w.Strict = d.Strict
+	if d.FieldsPerRecord > 0 {
+		w.FieldsPerRecord = d.FieldsPerRecord
+	}
+}
@@ -0,0 +1,245 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A WriteError reports that a record could not be written because one
+// of its values could not be formatted. Nothing of the record is written.
+type WriteError struct {
+	Record int   // 1-based number of the record among those written
+	Column int   // 0-based index of the value in the record
+	Err    error // The formatter's error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("csv: record %d, column %d: %v", e.Record, e.Column, e.Err)
+}
+
+func (e *WriteError) Unwrap() error { return e.Err }
+
+// SetFormatter sets the function WriteTyped uses to format the values in
+// column col, counted from 0. A nil f restores the default formatting.
+func (w *Writer) SetFormatter(col int, f func(any) (string, error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for len(w.formatters) <= col {
+		w.formatters = append(w.formatters, nil)
+	}
+	w.formatters[col] = f
+}
+
+// WriteTyped writes a record whose fields are vals, each formatted by the
+// formatter set with SetFormatter for its column or, failing that, by the
+// default formatting: time.Time values use TimeLayout, floating-point
+// values use FloatPrecision, booleans use TrueText and FalseText, nil is
+// the empty field, and other values are formatted as by fmt.Sprint.
+// If a formatter fails, WriteTyped writes nothing and returns a
+// WriteError. Otherwise the record is written as by Write.
+func (w *Writer) WriteTyped(vals ...any) error {
+	w.mu.Lock()
+	record := w.typedRecord[:0]
+	for i, v := range vals {
+		var s string
+		var err error
+		if i < len(w.formatters) && w.formatters[i] != nil {
+			s, err = w.formatters[i](v)
+		} else {
+			s, err = w.format(v)
+		}
+		if err != nil {
+			w.mu.Unlock()
+			return &WriteError{Record: w.numRecords + 1, Column: i, Err: err}
+		}
+		record = append(record, s)
+	}
+	w.typedRecord = record
+	w.mu.Unlock()
+	return w.Write(record)
+}
+
+// WriteNumber sets field col, counted from 0, of the record being built
+// by WriteField to value, formatted with FloatPrecision and
+// DecimalSeparator and without digit grouping, so that 1234.5 is written
+// as 1234.5 or 1234,5 but never 1,234.5. Empty fields are added before
+// it if the record has fewer than col fields, and a field already added
+// in column col is replaced. EndRecord writes the record. WriteNumber
+// returns an error if col is negative or the Writer has been closed.
+func (w *Writer) WriteNumber(col int, value float64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errClosed
+	}
+	if col < 0 {
+		return fmt.Errorf("csv: negative column %d", col)
+	}
+	for len(w.fields) <= col {
+		w.fields = append(w.fields, "")
+	}
+	w.fields[col] = w.formatFloat(value, 64)
+	return nil
+}
+
+// formatFloat formats v, of the given bit size, with FloatPrecision and
+// DecimalSeparator.
+func (w *Writer) formatFloat(v float64, bitSize int) string {
+	s := strconv.FormatFloat(v, 'f', w.FloatPrecision, bitSize)
+	if w.DecimalSeparator == 0 || w.DecimalSeparator == '.' {
+		return s
+	}
+	return strings.Replace(s, ".", string(w.DecimalSeparator), 1)
+}
+
+// format formats v with the default formatting of WriteTyped.
+func (w *Writer) format(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case time.Time:
+		return v.Format(w.TimeLayout), nil
+	case float64:
+		return w.formatFloat(v, 64), nil
+	case float32:
+		return w.formatFloat(float64(v), 32), nil
+	case bool:
+		if v {
+			return w.TrueText, nil
+		}
+		return w.FalseText, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case error:
+		return v.Error(), nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// ErrInvalidBool is the error ConvertBool returns, wrapped in a
+// ConvertError by ReadTyped, for a value in neither of its sets.
+var ErrInvalidBool = errors.New("invalid boolean value")
+
+// BoolText sets TrueText and FalseText, the text WriteTyped writes for
+// true and false, as for a Reader with the same values set by
+// BoolValues.
+func (w *Writer) BoolText(trueText, falseText string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.TrueText, w.FalseText = trueText, falseText
+}
+
+// A ConvertError reports that a field read by ReadTyped could not be
+// converted.
+type ConvertError struct {
+	Line   int    // Line where the field starts
+	Column int    // 0-based index of the field in the record
+	Value  string // The field as read
+	Err    error  // The converter's error
+}
+
+func (e *ConvertError) Error() string {
+	return fmt.Sprintf("csv: line %d, column %d: converting %q: %v", e.Line, e.Column, e.Value, e.Err)
+}
+
+func (e *ConvertError) Unwrap() error { return e.Err }
+
+// SetConverter sets the function ReadTyped uses to convert the fields in
+// column col, counted from 0. A nil f restores the default, which keeps
+// the field as a string.
+func (r *Reader) SetConverter(col int, f func(string) (any, error)) {
+	for len(r.converters) <= col {
+		r.converters = append(r.converters, nil)
+	}
+	r.converters[col] = f
+}
+
+// BoolValues sets the values ConvertBool converts to true and to false,
+// such as "Y" and "N" or "1" and "0", compared as set by
+// BoolCaseInsensitive. If both are empty, ConvertBool accepts the values
+// of strconv.ParseBool, which is the default.
+func (r *Reader) BoolValues(trues, falses []string) {
+	r.trueValues = slices.Clone(trues)
+	r.falseValues = slices.Clone(falses)
+}
+
+// ConvertBool converts s to a bool using the values set by BoolValues.
+// It returns ErrInvalidBool if s is not one of them. ConvertBool is a
+// converter for SetConverter, as in
+//
+//	r.SetConverter(2, r.ConvertBool)
+//
+// so that ReadTyped reports an invalid value in a ConvertError with its
+// column and text.
+func (r *Reader) ConvertBool(s string) (any, error) {
+	if len(r.trueValues) == 0 && len(r.falseValues) == 0 {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, ErrInvalidBool
+		}
+		return b, nil
+	}
+	switch {
+	case r.boolValue(r.trueValues, s):
+		return true, nil
+	case r.boolValue(r.falseValues, s):
+		return false, nil
+	}
+	return nil, ErrInvalidBool
+}
+
+// boolValue reports whether s is one of values, compared as set by
+// BoolCaseInsensitive.
+func (r *Reader) boolValue(values []string, s string) bool {
+	for _, v := range values {
+		if s == v || r.BoolCaseInsensitive && strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadTyped reads one record as Read does and returns its fields
+// converted by the converters set with SetConverter. Fields in columns
+// without a converter are returned as strings. If a converter fails,
+// ReadTyped returns a ConvertError; the rest of the record is not
+// converted. A record returned by Read along with an error is returned
+// converted along with the error.
+func (r *Reader) ReadTyped() ([]any, error) {
+	record, err := r.readRecord(r.lastRecord)
+	r.lastRecord = record
+	if record == nil {
+		return nil, err
+	}
+	vals := make([]any, len(record))
+	for i, s := range record {
+		if i >= len(r.converters) || r.converters[i] == nil {
+			vals[i] = s
+			continue
+		}
+		v, cerr := r.converters[i](s)
+		if cerr != nil {
+			line, _ := r.FieldPos(i)
+			return nil, &ConvertError{Line: line, Column: i, Value: s, Err: cerr}
+		}
+		vals[i] = v
+	}
+	return vals, err
+}
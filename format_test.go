@@ -0,0 +1,152 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTyped(t *testing.T) {
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	ts := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	w.WriteTyped("a,b", 42, 1.5, true, ts, nil, []byte("raw"), uint8(7), float32(0.1), errors.New("e"))
+	w.TimeLayout = "2006-01-02"
+	w.FloatPrecision = 2
+	w.TrueText, w.FalseText = "Y", "N"
+	w.WriteTyped(ts, 3.14159, false, int64(-1))
+	w.Flush()
+	want := `"a,b",42,1.5,true,2024-03-01T12:30:00Z,,raw,7,0.1,e` + "\n" +
+		"2024-03-01,3.14,N,-1\n"
+	if err := w.Error(); err != nil || b.String() != want {
+		t.Errorf("output = %q, %v; want %q", b.String(), err, want)
+	}
+}
+
+func TestSetFormatter(t *testing.T) {
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	money := func(v any) (string, error) {
+		cents, ok := v.(int)
+		if !ok {
+			return "", errors.New("not an amount")
+		}
+		return fmt.Sprintf("$%d.%02d", cents/100, cents%100), nil
+	}
+	w.SetFormatter(1, money)
+	if err := w.WriteTyped("widget", 1999, true); err != nil {
+		t.Fatal(err)
+	}
+	err := w.WriteTyped("gadget", "free", false)
+	want := &WriteError{Record: 2, Column: 1, Err: errors.New("not an amount")}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("WriteTyped() error = %#v, want %#v", err, want)
+	}
+	if got := err.Error(); got != "csv: record 2, column 1: not an amount" {
+		t.Errorf("Error() = %q", got)
+	}
+	w.SetFormatter(1, nil)
+	if err := w.WriteTyped("gadget", "free", false); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	if got, want := b.String(), "widget,$19.99,true\ngadget,free,false\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestWriteNumber(t *testing.T) {
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.FloatPrecision = 2
+	w.WriteField("widget")
+	w.WriteNumber(2, 1234.5)
+	w.WriteNumber(1, -0.125)
+	w.EndRecord()
+	w.DecimalSeparator = ','
+	w.WriteTyped("gadget", 1e6, float32(0.5))
+	w.WriteNumber(0, 3)
+	w.EndRecord()
+	w.Comma = ';'
+	w.WriteNumber(1, 2.25)
+	w.EndRecord()
+	w.Flush()
+	want := "widget,-0.12,1234.50\n" +
+		"gadget,\"1000000,00\",\"0,50\"\n" +
+		"\"3,00\"\n" +
+		";2,25\n"
+	if err := w.Error(); err != nil || b.String() != want {
+		t.Errorf("output = %q, %v; want %q", b.String(), err, want)
+	}
+	if err := w.WriteNumber(-1, 1); err == nil {
+		t.Error("WriteNumber(-1, 1) succeeded")
+	}
+}
+
+func TestReadTyped(t *testing.T) {
+	r := NewReader(strings.NewReader("widget,3,1.5\ngadget,x,2\n"))
+	r.SetConverter(1, func(s string) (any, error) { return strconv.Atoi(s) })
+	r.SetConverter(2, func(s string) (any, error) { return strconv.ParseFloat(s, 64) })
+	vals, err := r.ReadTyped()
+	if want := []any{"widget", 3, 1.5}; err != nil || !reflect.DeepEqual(vals, want) {
+		t.Fatalf("ReadTyped() = %#v, %v; want %#v", vals, err, want)
+	}
+	_, err = r.ReadTyped()
+	var ce *ConvertError
+	if !errors.As(err, &ce) || ce.Line != 2 || ce.Column != 1 || ce.Value != "x" || !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("ReadTyped() error = %#v, want ConvertError at line 2, column 1", err)
+	}
+	if got, want := err.Error(), `csv: line 2, column 1: converting "x": strconv.Atoi: parsing "x": invalid syntax`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if _, err := r.ReadTyped(); err != io.EOF {
+		t.Errorf("ReadTyped() at end = %v, want io.EOF", err)
+	}
+}
+
+func TestBoolValues(t *testing.T) {
+	const input = "a,Y\nb,n\nc,1\nd,maybe\n"
+	r := NewReader(strings.NewReader(input))
+	r.BoolValues([]string{"y", "1", "T"}, []string{"n", "0", "F"})
+	r.BoolCaseInsensitive = true
+	r.SetConverter(1, r.ConvertBool)
+	for _, want := range [][]any{{"a", true}, {"b", false}, {"c", true}} {
+		vals, err := r.ReadTyped()
+		if err != nil || !reflect.DeepEqual(vals, want) {
+			t.Fatalf("ReadTyped() = %#v, %v; want %#v", vals, err, want)
+		}
+	}
+	_, err := r.ReadTyped()
+	var ce *ConvertError
+	if !errors.As(err, &ce) || ce.Line != 4 || ce.Column != 1 || ce.Value != "maybe" || !errors.Is(err, ErrInvalidBool) {
+		t.Fatalf("ReadTyped() error = %#v, want ConvertError at line 4, column 1", err)
+	}
+
+	r.BoolCaseInsensitive = false
+	if _, err := r.ConvertBool("Y"); err != ErrInvalidBool {
+		t.Errorf("ConvertBool(%q) error = %v, want ErrInvalidBool", "Y", err)
+	}
+	r.BoolValues(nil, nil)
+	if v, err := r.ConvertBool("TRUE"); err != nil || v != true {
+		t.Errorf("ConvertBool(%q) = %v, %v; want true", "TRUE", v, err)
+	}
+
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.BoolText("Y", "N")
+	w.WriteTyped("a", true, false)
+	w.Flush()
+	if got, want := b.String(), "a,Y,N\n"; got != want {
+		t.Errorf("WriteTyped output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// NewGzipReader returns a new Reader that reads gzip-compressed CSV from
+// r. It returns an error if r does not start with a valid gzip header.
+// Close closes the gzip stream and then r, if it is an io.Closer.
+// InputOffset counts bytes of the decompressed input.
+func NewGzipReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	cr := NewReader(gz)
+	cr.closer = closers(gz, r)
+	return cr, nil
+}
+
+// NewGzipWriter returns a new Writer that writes gzip-compressed CSV to w.
+// Close flushes the CSV buffer, finishes the gzip stream and then closes
+// w, if it is an io.Closer, returning the first error. Until Close, some
+// of the flushed output may still be held by the compressor.
+func NewGzipWriter(w io.Writer) *Writer {
+	gz := gzip.NewWriter(w)
+	cw := NewWriter(gz)
+	cw.closer = closers(gz, w)
+	return cw
+}
+
+// closerList closes its io.Closers in order.
+type closerList []io.Closer
+
+// closers returns an io.Closer for c and for u if u is an io.Closer.
+func closers(c io.Closer, u any) io.Closer {
+	if uc, ok := u.(io.Closer); ok {
+		return closerList{c, uc}
+	}
+	return c
+}
+
+// Close closes each io.Closer, even after an error, and returns the first
+// error.
+func (l closerList) Close() error {
+	var err error
+	for _, c := range l {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
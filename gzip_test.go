@@ -0,0 +1,68 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// closeRecorder records the Close of a buffer.
+type closeRecorder struct {
+	bytes.Buffer
+	closed int
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	records := [][]string{{"a", "b"}, {"1", "two, three"}}
+	out := &closeRecorder{}
+	w := NewGzipWriter(out)
+	if err := w.WriteAll(records); err != nil {
+		t.Fatalf("WriteAll: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if out.closed != 1 {
+		t.Errorf("underlying writer closed %d times, want 1", out.closed)
+	}
+
+	in := &closeRecorder{Buffer: *bytes.NewBuffer(out.Bytes())}
+	r, err := NewGzipReader(in)
+	if err != nil {
+		t.Fatalf("NewGzipReader: %v", err)
+	}
+	got, err := r.ReadAll()
+	if err != nil || !reflect.DeepEqual(got, records) {
+		t.Errorf("ReadAll = %q, %v; want %q", got, err, records)
+	}
+	if err := r.Close(); err != nil || in.closed != 1 {
+		t.Errorf("Close = %v with %d closes of the source, want nil and 1", err, in.closed)
+	}
+}
+
+func TestGzipWriterCloseError(t *testing.T) {
+	w := NewGzipWriter(errorWriter{})
+	w.Write([]string{"a"})
+	if err := w.Close(); err == nil {
+		t.Error("Close of a failing writer returned nil")
+	}
+}
+
+func TestNewGzipReaderInvalid(t *testing.T) {
+	if _, err := NewGzipReader(strings.NewReader("a,b\nc,d\ne,f\n")); !errors.Is(err, gzip.ErrHeader) {
+		t.Errorf("NewGzipReader error = %v, want gzip.ErrHeader", err)
+	}
+}
@@ -0,0 +1,272 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A HeaderMatchMode controls how column names are matched against the
+// names in a header record.
+type HeaderMatchMode int
+
+const (
+	// MatchExact matches names byte for byte. This is the default.
+	MatchExact HeaderMatchMode = iota
+
+	// MatchCaseInsensitive matches names regardless of case, so that
+	// "UserID" matches "userid".
+	MatchCaseInsensitive
+
+	// MatchNormalized matches names regardless of case, white space and
+	// underscores, so that "user_id" matches "User Id".
+	MatchNormalized
+)
+
+// key returns the form of name that is compared under mode m.
+func (m HeaderMatchMode) key(name string) string {
+	switch m {
+	case MatchCaseInsensitive:
+		return strings.ToLower(name)
+	case MatchNormalized:
+		return strings.Map(func(r rune) rune {
+			if r == '_' || unicode.IsSpace(r) {
+				return -1
+			}
+			return unicode.ToLower(r)
+		}, name)
+	}
+	return name
+}
+
+// NormalizeSnake returns name in lower case with surrounding white space
+// removed and each run of spaces, dashes and underscores inside replaced
+// by one underscore, so that "User ID" and "user-id" both become
+// "user_id". It is meant for Reader.NormalizeHeader.
+func NormalizeSnake(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	sep := false
+	for _, c := range strings.TrimSpace(name) {
+		if c == '-' || c == '_' || unicode.IsSpace(c) {
+			sep = true
+			continue
+		}
+		if sep && b.Len() > 0 {
+			b.WriteByte('_')
+		}
+		sep = false
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
+
+// An AmbiguousHeaderError reports that two different names in a header
+// record match the same key under the Reader's HeaderMatch mode, so that
+// a column cannot be picked by name without guessing. With
+// RejectDuplicateHeaders, it also reports a name that appears twice.
+type AmbiguousHeaderError struct {
+	Key     string // The key both names match
+	Columns [2]int // 0-based indexes of the two columns
+	Names   [2]string
+}
+
+func (e *AmbiguousHeaderError) Error() string {
+	return fmt.Sprintf("csv: ambiguous header: %q (column %d) and %q (column %d) both match %q",
+		e.Names[0], e.Columns[0]+1, e.Names[1], e.Columns[1]+1, e.Key)
+}
+
+// A MissingColumnsError reports the names of SelectNames that match no
+// column of the header.
+type MissingColumnsError struct {
+	Names []string
+}
+
+func (e *MissingColumnsError) Error() string {
+	quoted := make([]string, len(e.Names))
+	for i, name := range e.Names {
+		quoted[i] = strconv.Quote(name)
+	}
+	return "csv: columns not found in header: " + strings.Join(quoted, ", ")
+}
+
+// A headerIndex maps column names to the index of their column.
+type headerIndex struct {
+	mode HeaderMatchMode
+	cols map[string]int
+}
+
+// newHeaderIndex indexes the names in header under mode. A name repeated
+// verbatim refers to its first column unless strict is true; different
+// names matching the same key are reported with an AmbiguousHeaderError.
+func newHeaderIndex(header []string, mode HeaderMatchMode, strict bool) (*headerIndex, error) {
+	h := &headerIndex{mode: mode, cols: make(map[string]int, len(header))}
+	for i, name := range header {
+		k := mode.key(name)
+		if j, ok := h.cols[k]; ok {
+			if strict || header[j] != name {
+				return nil, &AmbiguousHeaderError{Key: k, Columns: [2]int{j, i}, Names: [2]string{header[j], name}}
+			}
+			continue
+		}
+		h.cols[k] = i
+	}
+	return h, nil
+}
+
+// lookup returns the index of the column matching name.
+func (h *headerIndex) lookup(name string) (int, bool) {
+	i, ok := h.cols[h.mode.key(name)]
+	return i, ok
+}
+
+// ReadHeader reads the next record as the header naming the columns of
+// the following records, and remembers it for Field. With SelectNames,
+// the header holds only the selected columns. It should be called
+// before the first call to Read. Comment lines, and blank lines unless
+// BlankLineMode says otherwise, are skipped as by Read.
+//
+// Once a header has been read, ReadHeader returns it again without
+// consuming more input. Names are matched as set by HeaderMatch; if two
+// names in the header are ambiguous, ReadHeader returns the header along
+// with an AmbiguousHeaderError and Field matches no name.
+// The returned slice is never reused, even if ReuseRecord is true.
+func (r *Reader) ReadHeader() ([]string, error) {
+	if r.header != nil {
+		return r.header, r.headerErr
+	}
+	r.noCount = true
+	header, err := r.readRecord(nil)
+	r.noCount = false
+	if err != nil {
+		return nil, err
+	}
+	if r.NormalizeHeader != nil {
+		for i, name := range header {
+			header[i] = r.NormalizeHeader(name)
+		}
+	}
+	if len(r.SelectNames) > 0 {
+		if header, r.selectErr = r.selectNames(header); r.selectErr != nil {
+			r.header = header
+			return r.header, r.selectErr
+		}
+	}
+	r.header = header
+	r.headerIndex, r.headerErr = newHeaderIndex(header, r.HeaderMatch, r.RejectDuplicateHeaders)
+	return r.header, r.headerErr
+}
+
+// selectNames resolves SelectNames against header, setting r.selected, and
+// returns the selected part of header. A name matching two different
+// names in header is reported with an AmbiguousHeaderError; a name
+// repeated verbatim refers to its first column. On error, header is
+// returned unchanged.
+func (r *Reader) selectNames(header []string) ([]string, error) {
+	selected := make([]int, len(r.SelectNames))
+	names := make([]string, len(r.SelectNames))
+	var missing []string
+	for i, name := range r.SelectNames {
+		k := r.HeaderMatch.key(name)
+		selected[i], names[i] = -1, name
+		for j, h := range header {
+			if r.HeaderMatch.key(h) != k {
+				continue
+			}
+			if c := selected[i]; c < 0 {
+				selected[i], names[i] = j, h
+			} else if header[c] != h {
+				return header, &AmbiguousHeaderError{Key: k, Columns: [2]int{c, j}, Names: [2]string{header[c], h}}
+			}
+		}
+		if selected[i] < 0 {
+			missing = append(missing, name)
+			selected[i] = math.MaxInt // Beyond any record, so read as empty
+		}
+	}
+	if len(missing) > 0 && !r.LenientSelect {
+		return header, &MissingColumnsError{Names: missing}
+	}
+	r.selected = selected
+	return names, nil
+}
+
+// Field returns the field of record in the column with the given name
+// in the header read by ReadHeader. It returns the empty string if there
+// is no such column, if record is too short to have it, or if no header
+// has been read.
+func (r *Reader) Field(record []string, name string) string {
+	if r.headerIndex == nil {
+		return ""
+	}
+	if i, ok := r.headerIndex.lookup(name); ok && i < len(record) {
+		return record[i]
+	}
+	return ""
+}
+
+// ReadMap reads one record and returns it as a map from the names in the
+// header to the fields of the record. The header is read by ReadHeader
+// first if it has not been already. A name repeated in the header maps to
+// its first column. A field beyond the end of the header is stored under
+// "_" followed by its 1-based column number, as in "_5", unless
+// RejectExtraFields is true; a column missing from a short record is
+// absent from the map. Whether such records are errors at all is decided
+// by FieldsPerRecord, as for Read, which sets it from the header by
+// default.
+//
+// Each call returns a newly allocated map, even if ReuseRecord is true.
+func (r *Reader) ReadMap() (map[string]string, error) {
+	if _, err := r.ReadHeader(); err != nil {
+		return nil, err
+	}
+	record, err := r.Read()
+	if record == nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(record))
+	for i, field := range record {
+		name := ""
+		if i < len(r.header) {
+			name = r.header[i]
+		} else if r.RejectExtraFields {
+			if err == nil {
+				line, col := r.FieldPos(i)
+				startLine, _ := r.FieldPos(0)
+				err = &ParseError{StartLine: startLine, Line: line, Column: col, Err: ErrFieldCount}
+			}
+			break
+		} else {
+			name = "_" + strconv.Itoa(i+1)
+		}
+		if _, ok := m[name]; !ok {
+			m[name] = field
+		}
+	}
+	return m, err
+}
+
+// ReadAllMap reads all the remaining records with ReadMap.
+// A successful call returns err == nil, not err == io.EOF. Because
+// ReadAllMap is defined to read until EOF, it does not treat end of file
+// as an error to be reported.
+func (r *Reader) ReadAllMap() (records []map[string]string, err error) {
+	for {
+		m, err := r.ReadMap()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, m)
+	}
+}
@@ -0,0 +1,303 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var headerMatchTests = []struct {
+	Name   string
+	Header []string
+	Mode   HeaderMatchMode
+	Strict bool
+	Lookup map[string]int // -1 if the name must not match
+	Error  error
+}{{
+	Name:   "Exact",
+	Header: []string{"UserID", "email", "User Id"},
+	Lookup: map[string]int{"UserID": 0, "email": 1, "User Id": 2, "userid": -1, "Email": -1},
+}, {
+	Name:   "ExactDuplicate",
+	Header: []string{"a", "b", "a"},
+	Lookup: map[string]int{"a": 0, "b": 1},
+}, {
+	Name:   "ExactDuplicateStrict",
+	Header: []string{"a", "b", "a"},
+	Strict: true,
+	Error:  &AmbiguousHeaderError{Key: "a", Columns: [2]int{0, 2}, Names: [2]string{"a", "a"}},
+}, {
+	Name:   "CaseInsensitive",
+	Header: []string{"UserID", "EMAIL", "Straße"},
+	Mode:   MatchCaseInsensitive,
+	Lookup: map[string]int{"userid": 0, "USERID": 0, "Email": 1, "STRASSE": -1, "straße": 2, "user_id": -1},
+}, {
+	Name:   "Normalized",
+	Header: []string{"User Id", "e_mail", " Last\tName "},
+	Mode:   MatchNormalized,
+	Lookup: map[string]int{"user_id": 0, "UserID": 0, "userid": 0, "email": 1, "E Mail": 1, "last_name": 2, "lastname": 2, "User-Id": -1},
+}, {
+	Name:   "CaseInsensitiveAmbiguous",
+	Header: []string{"id", "Email", "email"},
+	Mode:   MatchCaseInsensitive,
+	Error:  &AmbiguousHeaderError{Key: "email", Columns: [2]int{1, 2}, Names: [2]string{"Email", "email"}},
+}, {
+	Name:   "NormalizedAmbiguous",
+	Header: []string{"UserID", "user_id"},
+	Mode:   MatchNormalized,
+	Error:  &AmbiguousHeaderError{Key: "userid", Columns: [2]int{0, 1}, Names: [2]string{"UserID", "user_id"}},
+}, {
+	Name:   "NormalizedDuplicate",
+	Header: []string{"User Id", "x", "User Id"},
+	Mode:   MatchNormalized,
+	Lookup: map[string]int{"user_id": 0, "X": 1},
+}}
+
+func TestHeaderMatch(t *testing.T) {
+	for _, tt := range headerMatchTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			h, err := newHeaderIndex(tt.Header, tt.Mode, tt.Strict)
+			if !reflect.DeepEqual(err, tt.Error) {
+				t.Fatalf("newHeaderIndex() error:\ngot  %v\nwant %v", err, tt.Error)
+			}
+			if err != nil {
+				return
+			}
+			for name, want := range tt.Lookup {
+				got, ok := h.lookup(name)
+				if !ok {
+					got = -1
+				}
+				if got != want {
+					t.Errorf("lookup(%q) = %d, want %d", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAmbiguousHeaderError(t *testing.T) {
+	err := &AmbiguousHeaderError{Key: "userid", Columns: [2]int{0, 3}, Names: [2]string{"UserID", "user_id"}}
+	want := `csv: ambiguous header: "UserID" (column 1) and "user_id" (column 4) both match "userid"`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	r := NewReader(strings.NewReader("# exported\nid,Email,name\n1,a@example.com,Ann\n2,b@example.com\n"))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	r.HeaderMatch = MatchCaseInsensitive
+	header, err := r.ReadHeader()
+	if err != nil || !reflect.DeepEqual(header, []string{"id", "Email", "name"}) {
+		t.Fatalf("ReadHeader() = %q, %v", header, err)
+	}
+	again, err := r.ReadHeader()
+	if err != nil || !reflect.DeepEqual(again, header) {
+		t.Fatalf("second ReadHeader() = %q, %v; want cached header", again, err)
+	}
+	var got []string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r.Field(record, "id"), r.Field(record, "EMAIL"), r.Field(record, "name"), r.Field(record, "missing"))
+	}
+	want := []string{"1", "a@example.com", "Ann", "", "2", "b@example.com", "", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Field values = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(header, []string{"id", "Email", "name"}) {
+		t.Errorf("header changed to %q by later reads", header)
+	}
+}
+
+func TestReadHeaderDuplicate(t *testing.T) {
+	const input = "a,b,a\n1,2,3\n"
+	r := NewReader(strings.NewReader(input))
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatal(err)
+	}
+	record, _ := r.Read()
+	if got := r.Field(record, "a"); got != "1" {
+		t.Errorf("Field(a) = %q, want first column", got)
+	}
+
+	r = NewReader(strings.NewReader(input))
+	r.RejectDuplicateHeaders = true
+	var ambiguous *AmbiguousHeaderError
+	if _, err := r.ReadHeader(); !errors.As(err, &ambiguous) {
+		t.Fatalf("ReadHeader() error = %v, want AmbiguousHeaderError", err)
+	}
+	if _, err := r.ReadHeader(); !errors.As(err, &ambiguous) {
+		t.Errorf("second ReadHeader() error = %v, want AmbiguousHeaderError", err)
+	}
+	record, _ = r.Read()
+	if got := r.Field(record, "b"); got != "" {
+		t.Errorf("Field(b) = %q after ambiguous header, want empty", got)
+	}
+}
+
+func TestReadHeaderEmpty(t *testing.T) {
+	r := NewReader(strings.NewReader(""))
+	if header, err := r.ReadHeader(); header != nil || err != io.EOF {
+		t.Errorf("ReadHeader() = %q, %v; want nil, EOF", header, err)
+	}
+	if got := r.Field([]string{"x"}, "x"); got != "" {
+		t.Errorf("Field without header = %q, want empty", got)
+	}
+}
+
+func TestReadMap(t *testing.T) {
+	r := NewReader(strings.NewReader("id,name,id\n1,Ann,x\n2,Bob,y,extra\n3\n"))
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	got, err := r.ReadAllMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{
+		{"id": "1", "name": "Ann"},
+		{"id": "2", "name": "Bob", "_4": "extra"},
+		{"id": "3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAllMap() = %q, want %q", got, want)
+	}
+}
+
+func TestReadMapFresh(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\n1,2\n3,4\n"))
+	r.ReuseRecord = true
+	m1, err := r.ReadMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m1["a"] = "changed"
+	m2, err := r.ReadMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m2["a"] != "3" || m2["b"] != "4" || m1["b"] != "2" {
+		t.Errorf("maps share state: %q, %q", m1, m2)
+	}
+}
+
+func TestReadMapFieldCount(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\n1,2,3\n"))
+	m, err := r.ReadMap()
+	if !errors.Is(err, ErrFieldCount) || !reflect.DeepEqual(m, map[string]string{"a": "1", "b": "2", "_3": "3"}) {
+		t.Errorf("ReadMap() = %q, %v; want record with ErrFieldCount", m, err)
+	}
+
+	r = NewReader(strings.NewReader("a,b\n1,2\n\"x\",y,z\n"))
+	r.FieldsPerRecord = -1
+	r.RejectExtraFields = true
+	if _, err := r.ReadMap(); err != nil {
+		t.Fatal(err)
+	}
+	m, err = r.ReadMap()
+	wantErr := &ParseError{StartLine: 3, Line: 3, Column: 7, Err: ErrFieldCount}
+	if !reflect.DeepEqual(err, wantErr) || !reflect.DeepEqual(m, map[string]string{"a": "x", "b": "y"}) {
+		t.Errorf("ReadMap() = %q, %v; want named fields and %v", m, err, wantErr)
+	}
+	if _, err := r.ReadMap(); err != io.EOF {
+		t.Errorf("ReadMap() at end = %v, want EOF", err)
+	}
+}
+
+func TestSelectNames(t *testing.T) {
+	const input = "id,Name,score,Email\n1,ann,9,a@x\n2,bob,7,b@x\n"
+	r := NewReader(strings.NewReader(input))
+	r.SelectNames = []string{"email", "ID"}
+	r.HeaderMatch = MatchCaseInsensitive
+	records, err := r.ReadAll()
+	if want := [][]string{{"a@x", "1"}, {"b@x", "2"}}; err != nil || !reflect.DeepEqual(records, want) {
+		t.Fatalf("ReadAll = %q, %v, want %q", records, err, want)
+	}
+	if header, _ := r.ReadHeader(); !reflect.DeepEqual(header, []string{"Email", "id"}) {
+		t.Errorf("ReadHeader = %q, want the selected names", header)
+	}
+
+	r = NewReader(strings.NewReader(input))
+	r.SelectNames = []string{"score", "name", "rank", "Name"}
+	if _, err := r.ReadHeader(); err == nil {
+		t.Fatal("ReadHeader with missing names succeeded")
+	}
+	var me *MissingColumnsError
+	_, err = r.Read()
+	if !errors.As(err, &me) || !reflect.DeepEqual(me.Names, []string{"name", "rank"}) {
+		t.Errorf("Read error = %v, want MissingColumnsError for name and rank", err)
+	}
+	if want := `csv: columns not found in header: "name", "rank"`; err == nil || err.Error() != want {
+		t.Errorf("Error() = %v, want %q", err, want)
+	}
+
+	r = NewReader(strings.NewReader(input))
+	r.SelectNames = []string{"score", "rank", "Name"}
+	r.LenientSelect = true
+	header, err := r.ReadHeader()
+	if want := []string{"score", "rank", "Name"}; err != nil || !reflect.DeepEqual(header, want) {
+		t.Errorf("ReadHeader = %q, %v, want %q", header, err, want)
+	}
+	m, err := r.ReadMap()
+	if want := map[string]string{"score": "9", "rank": "", "Name": "ann"}; err != nil || !reflect.DeepEqual(m, want) {
+		t.Errorf("ReadMap = %v, %v, want %v", m, err, want)
+	}
+
+	r = NewReader(strings.NewReader("a,A\n1,2\n"))
+	r.SelectNames = []string{"a"}
+	r.HeaderMatch = MatchCaseInsensitive
+	var ae *AmbiguousHeaderError
+	if _, err := r.Read(); !errors.As(err, &ae) {
+		t.Errorf("Read error = %v, want AmbiguousHeaderError", err)
+	}
+}
+
+func TestNormalizeHeader(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"User ID", "user_id"},
+		{"  user-id ", "user_id"},
+		{"First -- Name", "first_name"},
+		{"_Total_", "total"},
+		{"ÉTÉ", "été"},
+		{"", ""},
+	} {
+		if got := NormalizeSnake(tt.in); got != tt.want {
+			t.Errorf("NormalizeSnake(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	const input = "User ID,Full-Name\nAB-1,Ann Lee\n"
+	r := NewReader(strings.NewReader(input))
+	r.NormalizeHeader = NormalizeSnake
+	header, err := r.ReadHeader()
+	if want := []string{"user_id", "full_name"}; err != nil || !reflect.DeepEqual(header, want) {
+		t.Fatalf("ReadHeader = %q, %v, want %q", header, err, want)
+	}
+	m, err := r.ReadMap()
+	if want := map[string]string{"user_id": "AB-1", "full_name": "Ann Lee"}; err != nil || !reflect.DeepEqual(m, want) {
+		t.Errorf("ReadMap = %v, %v, want %v", m, err, want)
+	}
+
+	r = NewReader(strings.NewReader(input))
+	r.NormalizeHeader = strings.ToLower
+	r.SelectNames = []string{"full-name"}
+	records, err := r.ReadAll()
+	if want := [][]string{{"Ann Lee"}}; err != nil || !reflect.DeepEqual(records, want) {
+		t.Errorf("ReadAll = %q, %v, want %q", records, err, want)
+	}
+}
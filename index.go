@@ -0,0 +1,128 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+var (
+	errIndexRange   = errors.New("csv: record number out of index range")
+	errIndexInvalid = errors.New("csv: invalid index data")
+)
+
+// indexVersion is the first byte of the binary form of an Index.
+const indexVersion = 1
+
+// An Index holds the input offsets of every Nth record of a CSV file, so
+// that a record can be read without parsing the records before it.
+type Index struct {
+	stride  int
+	records int
+	offsets []int64 // Offsets of records 0, stride, 2*stride, ...
+}
+
+// BuildIndex reads all the remaining records of r and returns an index
+// storing the RecordOffset of every stride-th record, starting with the
+// first; if stride is not positive, every record's offset is stored.
+// Records are numbered from 0 in the order BuildIndex reads them, so a
+// header read before the call is not counted, while a record returned by
+// Peek is. Offsets come from the parser, so they are never inside a
+// quoted field spanning lines.
+//
+// Records skipped by OnError are left out of the index; otherwise
+// BuildIndex stops at the first error other than io.EOF and returns it.
+func BuildIndex(r *Reader, stride int) (*Index, error) {
+	x := &Index{stride: max(stride, 1)}
+	for {
+		ok, err := r.nextRecord()
+		if ok && x.records%x.stride == 0 {
+			x.offsets = append(x.offsets, r.RecordOffset())
+		}
+		if err == io.EOF {
+			return x, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		x.records++
+	}
+}
+
+// Len returns the number of records in the index.
+func (x *Index) Len() int { return x.records }
+
+// Stride returns the number of records between stored offsets.
+func (x *Index) Stride() int { return x.stride }
+
+// Seek returns where to start reading to get the record with the given
+// 0-based number: a Reader with the same options as the one given to
+// BuildIndex, started at offset of the same input, must read and discard
+// skip records before the one asked for. It returns an error if there is
+// no such record.
+func (x *Index) Seek(record int) (offset int64, skip int, err error) {
+	if record < 0 || record >= x.records {
+		return 0, 0, errIndexRange
+	}
+	return x.offsets[record/x.stride], record % x.stride, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, so that an index can
+// be stored next to its file.
+func (x *Index) MarshalBinary() ([]byte, error) {
+	b := []byte{indexVersion}
+	b = binary.AppendUvarint(b, uint64(x.stride))
+	b = binary.AppendUvarint(b, uint64(x.records))
+	var prev int64
+	for _, off := range x.offsets {
+		b = binary.AppendUvarint(b, uint64(off-prev))
+		prev = off
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, reading an index
+// written by MarshalBinary.
+func (x *Index) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != indexVersion {
+		return errIndexInvalid
+	}
+	data = data[1:]
+	var vals [2]uint64
+	for i := range vals {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errIndexInvalid
+		}
+		vals[i], data = v, data[n:]
+	}
+	stride, records := vals[0], vals[1]
+	if stride < 1 || stride > math.MaxInt || records > math.MaxInt {
+		return errIndexInvalid
+	}
+	count := (records + stride - 1) / stride
+	if count > uint64(len(data)) { // Each offset takes at least a byte
+		return errIndexInvalid
+	}
+	offsets := make([]int64, count)
+	var off int64
+	for i := range offsets {
+		d, n := binary.Uvarint(data)
+		if n <= 0 || d > 1<<62 || off+int64(d) < off {
+			return errIndexInvalid
+		}
+		off += int64(d)
+		offsets[i], data = off, data[n:]
+	}
+	if len(data) > 0 {
+		return errIndexInvalid
+	}
+	*x = Index{stride: int(stride), records: int(records), offsets: offsets}
+	return nil
+}
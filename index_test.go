@@ -0,0 +1,107 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	const input = "id,text\n" +
+		"1,\"two\nlines\"\n" +
+		"# comment\n" +
+		"2,\"a \"\"quoted\"\"\n3,not a record\n\"\n" +
+		"\n" +
+		"3,plain\n" +
+		"4,\"\n\n\"\n" +
+		"5,last"
+	newReader := func(s string) *Reader {
+		r := NewReader(strings.NewReader(s))
+		r.Comment = '#'
+		return r
+	}
+	r := newReader(input)
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatal(err)
+	}
+	want, err := newReader(input[r.InputOffset():]).ReadAll()
+	if err != nil || len(want) != 5 {
+		t.Fatalf("ReadAll = %q, %v; want 5 records", want, err)
+	}
+	for _, stride := range []int{0, 1, 2, 3, 10} {
+		r := newReader(input)
+		r.ReadHeader()
+		x, err := BuildIndex(r, stride)
+		if err != nil {
+			t.Fatalf("stride %d: BuildIndex error = %v", stride, err)
+		}
+		if x.Len() != len(want) {
+			t.Fatalf("stride %d: Len = %d, want %d", stride, x.Len(), len(want))
+		}
+
+		// The index reads back from its binary form.
+		b, _ := x.MarshalBinary()
+		var y Index
+		if err := y.UnmarshalBinary(b); err != nil || !reflect.DeepEqual(&y, x) {
+			t.Fatalf("stride %d: UnmarshalBinary = %+v, %v; want %+v", stride, y, err, x)
+		}
+
+		for n := range want {
+			offset, skip, err := y.Seek(n)
+			if err != nil {
+				t.Fatalf("stride %d: Seek(%d) error = %v", stride, n, err)
+			}
+			cr := newReader(input[offset:])
+			cr.FieldsPerRecord = -1
+			for i := 0; i < skip; i++ {
+				cr.Read()
+			}
+			if rec, err := cr.Read(); err != nil || !reflect.DeepEqual(rec, want[n]) {
+				t.Errorf("stride %d: record %d at offset %d, skip %d = %q, %v; want %q", stride, n, offset, skip, rec, err, want[n])
+			}
+		}
+		if _, _, err := y.Seek(len(want)); err != errIndexRange {
+			t.Errorf("stride %d: Seek(%d) error = %v, want %v", stride, len(want), err, errIndexRange)
+		}
+	}
+}
+
+func TestIndexPeekOnError(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\nc,d\ne,f\n"))
+	if _, err := r.Peek(); err != nil {
+		t.Fatal(err)
+	}
+	x, err := BuildIndex(r, 1)
+	if err != nil {
+		t.Fatalf("BuildIndex error = %v", err)
+	}
+	if want := (&Index{stride: 1, records: 3, offsets: []int64{0, 4, 8}}); !reflect.DeepEqual(x, want) {
+		t.Errorf("BuildIndex after Peek = %+v, want %+v", x, want)
+	}
+
+	r = NewReader(strings.NewReader("a,b\nc\ne,f\n"))
+	r.OnError = func(*ParseError, []byte) bool { return true }
+	x, err = BuildIndex(r, 1)
+	if err != nil {
+		t.Fatalf("BuildIndex error = %v", err)
+	}
+	if want := (&Index{stride: 1, records: 2, offsets: []int64{0, 6}}); !reflect.DeepEqual(x, want) {
+		t.Errorf("BuildIndex with OnError = %+v, want %+v", x, want)
+	}
+}
+
+func TestIndexUnmarshalInvalid(t *testing.T) {
+	x, _ := BuildIndex(NewReader(strings.NewReader("a\nb\nc\n")), 2)
+	b, _ := x.MarshalBinary()
+	for _, data := range [][]byte{nil, {0}, b[:len(b)-1], append(b, 0), {indexVersion, 0, 0}, {indexVersion, 1, 0xff, 0xff, 0xff, 0xff, 0x0f}} {
+		var y Index
+		if err := y.UnmarshalBinary(data); err != errIndexInvalid {
+			t.Errorf("UnmarshalBinary(%x) error = %v, want %v", data, err, errIndexInvalid)
+		}
+	}
+}
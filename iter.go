@@ -0,0 +1,89 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+//go:build go1.23
+
+package flexcsv
+
+import (
+	"io"
+	"iter"
+)
+
+// WriteSeq writes the records produced by seq using Write and then calls
+// Flush, returning any error from the Flush. It stops at the first record
+// Write rejects and returns its error, as WriteAll does.
+func (w *Writer) WriteSeq(seq iter.Seq[[]string]) error {
+	for record := range seq {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+// WriteSeq2 is like WriteSeq but for producers that can fail: it stops at
+// the first non-nil error from seq and returns it, without writing the
+// record paired with it or flushing.
+func (w *Writer) WriteSeq2(seq iter.Seq2[[]string, error]) error {
+	for record, err := range seq {
+		if err != nil {
+			return err
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+// All returns an iterator over the remaining records of r, as read by
+// Read. Each record is yielded with a nil error. If Read fails with an
+// error other than io.EOF, All yields that error, along with the record
+// Read returned with it, if any, and stops. Breaking out of the loop
+// leaves the rest of the input unread.
+//
+// If ReuseRecord is true, the yielded slice is overwritten by the next
+// record, so it must be copied to be kept past an iteration.
+func (r *Reader) All() iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		for {
+			record, err := r.Read()
+			if err == io.EOF {
+				return
+			}
+			if !yield(record, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Values is like All but yields only the records. It stops at the first
+// error other than io.EOF, which Err then returns.
+func (r *Reader) Values() iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		r.iterErr = nil
+		for record, err := range r.All() {
+			if err != nil {
+				r.iterErr = err
+				return
+			}
+			if !yield(record) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the error that stopped the last iteration of Values, or nil
+// if it reached the end of the input or was stopped by its caller.
+func (r *Reader) Err() error {
+	return r.iterErr
+}
@@ -0,0 +1,121 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+//go:build go1.23
+
+package flexcsv
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWriteSeq(t *testing.T) {
+	records := [][]string{{"a", "b"}, {"c", "d"}}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	if err := w.WriteSeq(slices.Values(records)); err != nil {
+		t.Fatalf("WriteSeq: %v", err)
+	}
+	if got, want := b.String(), "a,b\nc,d\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	b.Reset()
+	w = NewWriter(b)
+	w.FieldsPerRecord = 2
+	err := w.WriteSeq(slices.Values([][]string{{"a", "b"}, {"c"}, {"d", "e"}}))
+	if want := (&WriteError{Record: 2, Column: 1, Err: ErrFieldCount}); !reflect.DeepEqual(err, want) {
+		t.Errorf("WriteSeq error = %v, want %v", err, want)
+	}
+}
+
+func TestWriteSeq2(t *testing.T) {
+	errProducer := errors.New("producer failed")
+	seq := func(yield func([]string, error) bool) {
+		if !yield([]string{"a"}, nil) {
+			return
+		}
+		if !yield(nil, errProducer) {
+			return
+		}
+		yield([]string{"never"}, nil)
+	}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	if err := w.WriteSeq2(seq); err != errProducer {
+		t.Errorf("WriteSeq2 = %v, want %v", err, errProducer)
+	}
+	w.Flush()
+	if got, want := b.String(), "a\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestReaderAll(t *testing.T) {
+	var got [][]string
+	for rec, err := range NewReader(strings.NewReader("a,b\nc,d\n")).All() {
+		if err != nil {
+			t.Fatalf("All yielded %v", err)
+		}
+		got = append(got, rec)
+	}
+	if want := [][]string{{"a", "b"}, {"c", "d"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("All = %q, want %q", got, want)
+	}
+
+	for rec, err := range NewReader(strings.NewReader("")).All() {
+		t.Errorf("All of empty input yielded %q, %v", rec, err)
+	}
+
+	// An early break leaves the rest of the input to Read.
+	r := NewReader(strings.NewReader("a\nb\nc\n"))
+	for range r.All() {
+		break
+	}
+	if rec, err := r.Read(); err != nil || !reflect.DeepEqual(rec, []string{"b"}) {
+		t.Errorf("Read after break = %q, %v; want [b]", rec, err)
+	}
+
+	// An error is yielded once and ends the iteration.
+	var errs []error
+	n := 0
+	for _, err := range NewReader(strings.NewReader("a\n\"b\nc\n")).All() {
+		n++
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if n != 2 || len(errs) != 1 || !errors.Is(errs[0], ErrQuote) {
+		t.Errorf("All yielded %d times with errors %v, want 2 with one ErrQuote", n, errs)
+	}
+}
+
+func TestReaderValues(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\nc\nd,e\n"))
+	var got [][]string
+	for rec := range r.Values() {
+		got = append(got, rec)
+	}
+	if want := [][]string{{"a", "b"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values = %q, want %q", got, want)
+	}
+	if err := r.Err(); !errors.Is(err, ErrFieldCount) {
+		t.Errorf("Err = %v, want ErrFieldCount", err)
+	}
+
+	r = NewReader(strings.NewReader("a\nb\n"))
+	r.ReuseRecord = true
+	got = nil
+	for rec := range r.Values() {
+		got = append(got, slices.Clone(rec))
+	}
+	if want := [][]string{{"a"}, {"b"}}; !reflect.DeepEqual(got, want) || r.Err() != nil {
+		t.Errorf("Values = %q, %v; want %q", got, r.Err(), want)
+	}
+}
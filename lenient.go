@@ -0,0 +1,129 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// ErrTooManyErrors ends the errors returned by ReadAllLenient when it
+// stops reading because maxLenientErrors records have failed.
+var ErrTooManyErrors = errors.New("csv: too many invalid records")
+
+// maxLenientErrors is the number of failed records after which
+// ReadAllLenient gives up, so that a file that is not CSV at all does not
+// make it collect an error per line.
+const maxLenientErrors = 1000
+
+// ReadAllLenient is like ReadAll but skips the records that fail to
+// parse, or have the wrong number of fields, instead of stopping at the
+// first one. It returns the valid records along with the ParseError of
+// each skipped record, in input order, so that their line numbers can be
+// reported. After 1000 failed records it stops and returns
+// ErrTooManyErrors as the last error. Any error other than a ParseError
+// also ends reading and is returned last, as is the ParseError of a
+// record that OnError, if set, refuses to skip.
+//
+// A failed record ends the line it failed on, and reading resumes at the
+// next line. If the record had continued onto further lines by then, as
+// a field wrongly opened with a quote swallows the lines up to the next
+// quote, the quote is taken for the mistake and reading resumes instead
+// at the line after the first line of the record, so that those lines
+// are read again as records of their own. A record with the wrong
+// number of fields parsed correctly, so all its lines are skipped.
+func (r *Reader) ReadAllLenient() (records [][]string, errs []error) {
+	onError := r.OnError
+	defer func() { r.OnError = onError }()
+	r.OnError = func(err *ParseError, raw []byte) bool {
+		if onError != nil && !onError(err, raw) {
+			return false
+		}
+		errs = append(errs, err)
+		return len(errs) < maxLenientErrors
+	}
+	for {
+		record, err := r.readRecord(nil)
+		if err == nil {
+			records = append(records, record)
+			continue
+		}
+		if err == io.EOF {
+			return records, errs
+		}
+		if len(errs) >= maxLenientErrors {
+			return records, append(errs, ErrTooManyErrors)
+		}
+		return records, append(errs, err)
+	}
+}
+
+// nextRecord is parseRecord, skipping the invalid records OnError lets it
+// skip, or takes the record read by Peek.
+func (r *Reader) nextRecord() (bool, error) {
+	if peeked, ok, err := r.takePeeked(); peeked {
+		return ok, err
+	}
+	for {
+		read, inferring := r.numRecords, r.FieldsPerRecord == 0
+		ok, err := r.parseRecord()
+		pe, isParse := err.(*ParseError)
+		if r.OnError == nil || !isParse || !r.OnError(pe, r.rawRecord) {
+			return ok, err
+		}
+		if inferring {
+			// Infer FieldsPerRecord from the first valid record instead.
+			r.FieldsPerRecord, r.inferredFields = 0, 0
+		}
+		r.skipInvalid(pe, r.numRecords > read)
+	}
+}
+
+// skipInvalid discards the record that failed with pe, counted as read
+// if counted is true, and puts back the input after its first line if
+// the record spans lines and did not fail on its field count.
+func (r *Reader) skipInvalid(pe *ParseError, counted bool) {
+	r.numSkipped++
+	if counted && !r.noCount {
+		r.numRecords--
+	}
+	n := r.resume.offset - r.recordOffset // Bytes of the first line
+	if pe.Err == ErrFieldCount || r.offset <= r.resume.offset || n > int64(len(r.rawRecord)) {
+		return
+	}
+	r.unread(r.rawRecord[n:])
+	r.offset, r.numLine, r.linesRead = r.resume.offset, r.resume.numLine, r.resume.linesRead
+}
+
+// unread puts b back in front of the unread input.
+func (r *Reader) unread(b []byte) {
+	if r.replay == nil {
+		r.replay = &replayReader{data: append([]byte(nil), b...), r: r.r}
+		r.r = bufio.NewReaderSize(r.replay, r.r.Size())
+		return
+	}
+	buffered, _ := r.r.Peek(r.r.Buffered())
+	data := make([]byte, 0, len(b)+len(buffered)+len(r.replay.data))
+	data = append(append(append(data, b...), buffered...), r.replay.data...)
+	r.replay.data = data
+	r.r.Reset(r.replay)
+}
+
+// A replayReader reads data before reading from r.
+type replayReader struct {
+	data []byte
+	r    io.Reader
+}
+
+func (p *replayReader) Read(b []byte) (int, error) {
+	if len(p.data) > 0 {
+		n := copy(b, p.data)
+		p.data = p.data[n:]
+		return n, nil
+	}
+	return p.r.Read(b)
+}
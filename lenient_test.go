@@ -0,0 +1,130 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadAllLenient(t *testing.T) {
+	type lineErr struct {
+		startLine, line int
+		err             error
+	}
+	tests := []struct {
+		name    string
+		input   string
+		records [][]string
+		errs    []lineErr
+	}{{
+		name:    "SingleLine",
+		input:   "a,b\nc,\"d\"x\ne,f\ng\nh,i\n",
+		records: [][]string{{"a", "b"}, {"e", "f"}, {"h", "i"}},
+		errs:    []lineErr{{2, 2, ErrQuote}, {4, 4, ErrFieldCount}},
+	}, {
+		name:    "StrayQuote",
+		input:   "a,b\n\"c,d\ne,f\ng,h\"x\ni,j\n",
+		records: [][]string{{"a", "b"}, {"e", "f"}, {"i", "j"}},
+		errs:    []lineErr{{2, 4, ErrQuote}, {4, 4, ErrBareQuote}},
+	}, {
+		name:    "UnterminatedQuote",
+		input:   "a,b\n\"c,d\ne,f\n",
+		records: [][]string{{"a", "b"}, {"e", "f"}},
+		errs:    []lineErr{{2, 3, ErrQuote}},
+	}, {
+		name:    "MultilineFieldCount",
+		input:   "a,b\n\"c\nd\"\ne,f\n",
+		records: [][]string{{"a", "b"}, {"e", "f"}},
+		errs:    []lineErr{{2, 2, ErrFieldCount}},
+	}, {
+		name:    "BadFirstRecord",
+		input:   "a,\"b\"x,c\nd,e\nf,g\n",
+		records: [][]string{{"d", "e"}, {"f", "g"}},
+		errs:    []lineErr{{1, 1, ErrQuote}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(tt.input))
+			records, errs := r.ReadAllLenient()
+			if !reflect.DeepEqual(records, tt.records) {
+				t.Errorf("records = %q, want %q", records, tt.records)
+			}
+			var got []lineErr
+			for _, err := range errs {
+				var pe *ParseError
+				if !errors.As(err, &pe) {
+					t.Fatalf("error %v is not a ParseError", err)
+				}
+				got = append(got, lineErr{pe.StartLine, pe.Line, pe.Err})
+			}
+			if !reflect.DeepEqual(got, tt.errs) {
+				t.Errorf("errors = %v, want %v", got, tt.errs)
+			}
+			stats := r.Stats()
+			if stats.Records != len(tt.records) || stats.MalformedSkipped != len(tt.errs) ||
+				stats.Lines != strings.Count(tt.input, "\n") || stats.BytesRead != int64(len(tt.input)) {
+				t.Errorf("Stats() = %+v", stats)
+			}
+		})
+	}
+}
+
+func TestReadAllLenientTooManyErrors(t *testing.T) {
+	input := "a,b\n" + strings.Repeat("\"c\"d\n", maxLenientErrors+5)
+	records, errs := NewReader(strings.NewReader(input)).ReadAllLenient()
+	if len(records) != 1 || len(errs) != maxLenientErrors+1 || errs[len(errs)-1] != ErrTooManyErrors {
+		t.Errorf("ReadAllLenient = %d records, %d errors ending in %v, want 1, %d and ErrTooManyErrors",
+			len(records), len(errs), errs[len(errs)-1], maxLenientErrors+1)
+	}
+}
+
+func TestOnError(t *testing.T) {
+	const input = "a,b\nc,\"d\"x\ne,f\ng\n\"h\ni\nj,k\n"
+	var lines []int
+	var raws []string
+	r := NewReader(strings.NewReader(input))
+	r.OnError = func(err *ParseError, raw []byte) bool {
+		lines = append(lines, err.StartLine)
+		raws = append(raws, string(raw))
+		return true
+	}
+	records, err := r.ReadAll()
+	if want := [][]string{{"a", "b"}, {"e", "f"}, {"j", "k"}}; err != nil || !reflect.DeepEqual(records, want) {
+		t.Errorf("ReadAll = %q, %v, want %q", records, err, want)
+	}
+	wantLines := []int{2, 4, 5, 6}
+	wantRaws := []string{"c,\"d\"x\n", "g\n", "\"h\ni\nj,k\n", "i\n"}
+	if !reflect.DeepEqual(lines, wantLines) || !reflect.DeepEqual(raws, wantRaws) {
+		t.Errorf("OnError called for lines %v with %q, want %v with %q", lines, raws, wantLines, wantRaws)
+	}
+
+	// Refusing the second error stops there, with that error.
+	calls := 0
+	r = NewReader(strings.NewReader(input))
+	r.OnError = func(err *ParseError, raw []byte) bool {
+		calls++
+		return calls < 2
+	}
+	records, err = r.ReadAll()
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.StartLine != 4 || !errors.Is(err, ErrFieldCount) || calls != 2 || records != nil {
+		t.Errorf("ReadAll = %q, %v after %d calls, want the field count error of line 4 after 2 calls", records, err, calls)
+	}
+
+	calls = 0
+	r = NewReader(strings.NewReader(input))
+	r.OnError = func(err *ParseError, raw []byte) bool {
+		calls++
+		return false
+	}
+	records, errs := r.ReadAllLenient()
+	if len(records) != 1 || len(errs) != 1 || calls != 1 {
+		t.Errorf("ReadAllLenient = %q, %v after %d calls, want 1 record and 1 error after 1 call", records, errs, calls)
+	}
+}
@@ -0,0 +1,97 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrMultipleRecords is returned by ParseLine if its input holds more than
+// one record.
+var ErrMultipleRecords = errors.New("csv: line holds more than one record")
+
+// lineReader is a Reader over a string, reused by ParseLine.
+type lineReader struct {
+	sr strings.Reader
+	r  *Reader
+}
+
+var lineReaders = sync.Pool{
+	New: func() any {
+		lr := new(lineReader)
+		lr.r = NewReader(&lr.sr)
+		return lr
+	},
+}
+
+// ParseLine parses line, which must hold exactly one record in the format
+// described by dialect, as a Reader would, and returns its fields. A line
+// terminator at the end of line is allowed but not required. ParseLine
+// returns ErrMultipleRecords if another record follows the first one, as
+// it does after a line break outside quoted fields, and nil and io.EOF if
+// line holds no record, such as a blank or comment line. Errors in the
+// record are returned as by Read, with the fields read before them, and a
+// FieldsPerRecord of 0 accepts any field count.
+//
+// ParseLine reuses its Readers, so that only the record is allocated.
+func ParseLine(line string, dialect Dialect) ([]string, error) {
+	lr := lineReaders.Get().(*lineReader)
+	defer lineReaders.Put(lr)
+	lr.sr.Reset(line)
+	r := lr.r
+	r.Reset(&lr.sr)
+	r.Comma, r.Quote = ',', '"' // Not set by dialect if 0
+	dialect.configure(r)
+	record, err := r.Read()
+	if err != nil {
+		return record, err
+	}
+	if _, err := r.parseRecord(); err != io.EOF {
+		return nil, ErrMultipleRecords
+	}
+	return record, nil
+}
+
+// lineWriter is a Writer into a buffer, reused by FormatLine.
+type lineWriter struct {
+	buf bytes.Buffer
+	w   *Writer
+}
+
+var lineWriters = sync.Pool{
+	New: func() any {
+		lw := new(lineWriter)
+		lw.w = NewWriter(&lw.buf)
+		return lw
+	},
+}
+
+// FormatLine returns record encoded as a CSV line in the format described
+// by dialect, quoted exactly as Writer.Write would quote it with the
+// options WriterPool takes from a Dialect, so that ParseLine with the same
+// dialect returns the record. The line ends with dialect.Terminator, which
+// is empty by default. A record of a single empty field is formatted as
+// the empty string, which ParseLine does not read as a record.
+func FormatLine(record []string, dialect Dialect) (string, error) {
+	lw := lineWriters.Get().(*lineWriter)
+	defer lineWriters.Put(lw)
+	lw.buf.Reset()
+	w := lw.w
+	w.init(&lw.buf, w.w)
+	dialect.configureWriter(w)
+	w.OmitFinalNewline = dialect.Terminator == ""
+	if err := w.Write(record); err != nil {
+		return "", err
+	}
+	if err := w.flush(); err != nil {
+		return "", err
+	}
+	return lw.buf.String(), nil
+}
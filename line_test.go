@@ -0,0 +1,76 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		dialect Dialect
+		want    []string
+		err     error
+	}{
+		{line: "a,b,c", want: []string{"a", "b", "c"}},
+		{line: "a,\"b\nc\",d\r\n", want: []string{"a", "b\nc", "d"}},
+		{line: "a;'b;c'", dialect: Dialect{Comma: ';', Quote: '\''}, want: []string{"a", "b;c"}},
+		{line: "a,b\n\n", want: []string{"a", "b"}},
+		{line: "a,b\nc,d", err: ErrMultipleRecords},
+		{line: "a,b\n\"c", err: ErrMultipleRecords},
+		{line: "a,b\n#c", dialect: Dialect{Comment: '#'}, want: []string{"a", "b"}},
+		{line: "", err: io.EOF},
+		{line: "a,\"b", want: []string{"a"}, err: ErrQuote},
+	}
+	for _, tt := range tests {
+		got, err := ParseLine(tt.line, tt.dialect)
+		if !errors.Is(err, tt.err) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLine(%q) = %q, %v, want %q, %v", tt.line, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func BenchmarkParseLine(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseLine(`1,widget 1,"say ""hi""",1.01`, Dialect{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFormatLine(t *testing.T) {
+	tests := []struct {
+		record  []string
+		dialect Dialect
+		want    string
+	}{
+		{record: []string{"a", "b c", ""}, want: "a,b c,"},
+		{record: []string{"a,b", "c\nd", `e"f`, " g"}, want: "\"a,b\",\"c\nd\",\"e\"\"f\",\" g\""},
+		{record: []string{"a;b", "c'd"}, dialect: Dialect{Comma: ';', Quote: '\''}, want: "'a;b';'c''d'"},
+		{record: []string{"#a", "b"}, dialect: Dialect{Comment: '#'}, want: `"#a",b`},
+		{record: []string{"a", "b|c"}, dialect: Dialect{Terminator: "|"}, want: `a,"b|c"|`},
+		{record: []string{"a\tb", "c"}, dialect: Dialect{DecodeBackslashEscapes: true}, want: `a\tb,c`},
+		{record: []string{`a\\b`, "c\nd"}, dialect: Dialect{DecodeBackslashEscapes: true, UnescapeNewlines: true}, want: `a\\\\b,c\nd`},
+	}
+	for _, tt := range tests {
+		got, err := FormatLine(tt.record, tt.dialect)
+		if err != nil || got != tt.want {
+			t.Errorf("FormatLine(%q) = %q, %v, want %q", tt.record, got, err, tt.want)
+			continue
+		}
+		if back, err := ParseLine(got, tt.dialect); err != nil || !reflect.DeepEqual(back, tt.record) {
+			t.Errorf("ParseLine(%q) = %q, %v, want %q", got, back, err, tt.record)
+		}
+	}
+	if _, err := FormatLine([]string{"a"}, Dialect{Comma: '"'}); err == nil {
+		t.Error("FormatLine with an invalid Comma succeeded")
+	}
+}
@@ -0,0 +1,473 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+	"unicode/utf8"
+)
+
+// parallelChunkSize is the amount of input handed to a worker at a time.
+// It is a variable so that tests can exercise many chunk boundaries.
+var parallelChunkSize = 1 << 20
+
+// ReadAllParallel reads all records from r like the ReadAll method of a
+// Reader configured with dialect, but parses the input on up to workers
+// goroutines. If workers is not positive, runtime.GOMAXPROCS(0) is used.
+// The records and any error are the same as ReadAll would return.
+//
+// The input is cut into chunks that end at record boundaries. Starting
+// from a known boundary, a newline is taken to end a record if it is
+// preceded by an even number of quote characters since that boundary,
+// skipping comment lines. Doubled quotes inside quoted fields keep the count
+// even, so this is exact for input that ReadAll accepts; a chunk ending at
+// a wrong boundary can only follow the first malformed record, whose error
+// is reported first anyway. A chunk grows until such a boundary is found,
+// so a record larger than a chunk is never split.
+//
+// Bare quotes (LazyQuotes, the Allow options, RelaxedOpenQuote or
+// RepairQuotes), Escape, DecodeBackslashEscapes, QuoteEscape, AcceptCR,
+// Terminator and quotes in inline comments all break the quote counting,
+// so with any of them ReadAllParallel falls back to reading sequentially.
+// So does BlankLineEmptyRecord with a field count check, since the check
+// must skip blank records, and OnShortRow or OnLongRow other than the
+// default.
+func ReadAllParallel(r io.Reader, dialect Dialect, workers int) ([][]string, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers == 1 || !splittable(&dialect) {
+		cr := NewReader(r)
+		dialect.configure(cr)
+		return cr.ReadAll()
+	}
+	if err := checkDialect(&dialect); err != nil {
+		return nil, err
+	}
+
+	jobs := make(chan *parallelChunk, workers)
+	ordered := make(chan *parallelChunk, workers)
+	stop := make(chan struct{})
+	defer func() {
+		// Wait for splitChunks to stop reading r before returning.
+		close(stop)
+		for range ordered {
+		}
+	}()
+	quote := dialect.Quote
+	if quote == 0 {
+		quote = '"'
+	}
+	go splitChunks(r, quote, dialect.Comment, parallelChunkSize, jobs, ordered, stop)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for c := range jobs {
+				c.parse(&dialect)
+			}
+		}()
+	}
+	return collectChunks(ordered, dialect.FieldsPerRecord)
+}
+
+// splittable reports whether the input of dialect can be cut at record
+// boundaries found by counting quotes, as described by ReadAllParallel.
+func splittable(dialect *Dialect) bool {
+	return !dialect.LazyQuotes && !dialect.AllowBareQuotes && !dialect.AllowTextAfterClosingQuote && !dialect.AllowUnterminatedQuote && !dialect.RepairQuotes && !dialect.RelaxedOpenQuote && dialect.Escape == 0 && !dialect.DecodeBackslashEscapes && dialect.QuoteEscape == 0 && dialect.QuoteOpen == 0 && dialect.QuoteClose == 0 && !dialect.AcceptCR && dialect.Terminator == "" &&
+		!(dialect.InlineComments && dialect.Comment != 0) &&
+		!(dialect.BlankLineMode == BlankLineEmptyRecord && dialect.FieldsPerRecord >= 0) &&
+		dialect.OnShortRow == ShortRowError && dialect.OnLongRow == LongRowError
+}
+
+// checkDialect reports an invalid dialect the way ReadAll would, even for
+// empty input.
+func checkDialect(dialect *Dialect) error {
+	probe := NewReader(bytes.NewReader(nil))
+	dialect.configure(probe)
+	if _, err := probe.Read(); err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// collectChunks concatenates the records of the parsed chunks in order,
+// checking the field counts against want, the FieldsPerRecord of the
+// dialect, as ReadAll would.
+func collectChunks(ordered <-chan *parallelChunk, want int) ([][]string, error) {
+	var records [][]string
+	for c := range ordered {
+		<-c.done
+		for i, rec := range c.records {
+			if want == 0 {
+				want = len(rec)
+			}
+			if want > 0 && len(rec) != want {
+				return nil, &ParseError{StartLine: c.lines[i], Line: c.lines[i], Column: 1, Err: ErrFieldCount}
+			}
+		}
+		if c.err != nil {
+			return nil, c.err
+		}
+		records = append(records, c.records...)
+	}
+	return records, nil
+}
+
+// A parallelChunk is a run of whole records and the result of parsing it.
+type parallelChunk struct {
+	data    []byte
+	line    int // number of lines before the chunk
+	readErr error
+
+	records [][]string
+	lines   []int // start line of each record
+	err     error
+	done    chan struct{}
+}
+
+// parse parses the chunk, reporting line numbers relative to the whole
+// input. Field counts are checked later, in order.
+func (c *parallelChunk) parse(dialect *Dialect) {
+	defer close(c.done)
+	r := NewReader(bytes.NewReader(c.data))
+	dialect.configure(r)
+	r.FieldsPerRecord = -1
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			c.err = c.readErr
+			return
+		}
+		if err != nil {
+			var pe *ParseError
+			if errors.As(err, &pe) {
+				pe.StartLine += c.line
+				pe.Line += c.line
+			}
+			c.err = err
+			return
+		}
+		line, _ := r.FieldPos(0)
+		c.records = append(c.records, rec)
+		c.lines = append(c.lines, c.line+line)
+	}
+}
+
+// splitChunks reads r and sends chunks of whole records both to the
+// workers on jobs and, in input order, to the consumer on ordered.
+func splitChunks(r io.Reader, quote, comment rune, chunkSize int, jobs, ordered chan<- *parallelChunk, stop <-chan struct{}) {
+	defer close(jobs)
+	defer close(ordered)
+	var buf []byte
+	line := 0
+	for {
+		// Read about a chunk past what is left over, doubling the read
+		// until buf holds a record boundary or the input ends.
+		var err error
+		end := -1
+		for end < 0 && err == nil {
+			buf, err = readChunk(r, buf, max(chunkSize, len(buf)))
+			end = lastRecordEnd(buf, quote, comment)
+		}
+		final := err != nil
+		if final {
+			if err == io.EOF {
+				err = nil
+			}
+			end = len(buf)
+		}
+		c := &parallelChunk{data: buf[:end], line: line, readErr: err, done: make(chan struct{})}
+		select {
+		case ordered <- c:
+		case <-stop:
+			return
+		}
+		select {
+		case jobs <- c:
+		case <-stop:
+			return
+		}
+		if final {
+			return
+		}
+		line += bytes.Count(c.data, []byte{'\n'})
+		buf = append([]byte(nil), buf[end:]...)
+	}
+}
+
+// readChunk appends up to n bytes from r to buf. It returns io.EOF if the
+// input ended before n bytes could be read.
+func readChunk(r io.Reader, buf []byte, n int) ([]byte, error) {
+	buf = append(buf, make([]byte, n)...)
+	m, err := io.ReadFull(r, buf[len(buf)-n:])
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return buf[:len(buf)-n+m], err
+}
+
+// lastRecordEnd returns the index just past the last newline in b that
+// ends a record, assuming b starts at a record boundary, or -1.
+func lastRecordEnd(b []byte, quote, comment rune) int {
+	special := string(quote) + "\n"
+	end := -1
+	inQuotes := false
+	lineStart := true
+	for i := 0; i < len(b); {
+		if lineStart && !inQuotes && comment != 0 {
+			if c, _ := utf8.DecodeRune(b[i:]); c == comment {
+				j := bytes.IndexByte(b[i:], '\n')
+				if j < 0 {
+					break
+				}
+				i += j + 1
+				end = i
+				continue
+			}
+		}
+		j := bytes.IndexAny(b[i:], special)
+		if j < 0 {
+			break
+		}
+		i += j
+		lineStart = false
+		if b[i] != '\n' {
+			inQuotes = !inQuotes
+			i += utf8.RuneLen(quote)
+			continue
+		}
+		if !inQuotes {
+			end = i + 1
+			lineStart = true
+		}
+		i++
+	}
+	return end
+}
+
+// ReadAllParallelAt is like ReadAllParallel but reads the size bytes of r
+// concurrently as well, so that neither reading nor parsing is limited to
+// one goroutine. The records and any error are the same as ReadAll would
+// return for the input.
+//
+// The input is cut into segments of about a chunk, each starting just
+// after a newline. A segment may start either outside quotes or inside a
+// quoted field, so each worker counts the quotes of its segment for both
+// cases, as described by ReadAllParallel. Going through the segments in
+// order then tells which case holds for each one, and so where its first
+// record starts, without having scanned the input from the beginning.
+func ReadAllParallelAt(r io.ReaderAt, size int64, dialect Dialect, workers int) ([][]string, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers == 1 || !splittable(&dialect) {
+		cr := NewReader(io.NewSectionReader(r, 0, size))
+		dialect.configure(cr)
+		return cr.ReadAll()
+	}
+	if err := checkDialect(&dialect); err != nil {
+		return nil, err
+	}
+	quote := dialect.Quote
+	if quote == 0 {
+		quote = '"'
+	}
+	n := int(max(1, (size+int64(parallelChunkSize)-1)/int64(parallelChunkSize)))
+	segments := make([]segment, n)
+	errs := make([]error, n)
+	forEach(n, workers, func(i int) {
+		errs[i] = segments[i].scan(r, size, int64(i)*size/int64(n), int64(i+1)*size/int64(n), quote, dialect.Comment)
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Follow the quote state from segment to segment to find where each
+	// chunk of whole records starts.
+	var chunks []*parallelChunk
+	var starts []int64
+	quoted := false
+	line := 0
+	for _, s := range segments {
+		if s.end == s.start {
+			continue
+		}
+		switch {
+		case !quoted:
+			starts = append(starts, s.start)
+			chunks = append(chunks, &parallelChunk{line: line})
+		case s.first >= 0:
+			starts = append(starts, s.start+int64(s.first))
+			chunks = append(chunks, &parallelChunk{line: line + s.firstLines})
+		}
+		quoted = s.endQuoted[b2i(quoted)]
+		line += s.lines
+	}
+	starts = append(starts, size)
+
+	ordered := make(chan *parallelChunk, len(chunks))
+	for _, c := range chunks {
+		c.done = make(chan struct{})
+		ordered <- c
+	}
+	close(ordered)
+	stop := make(chan struct{})
+	defer close(stop)
+	go forEach(len(chunks), workers, func(i int) {
+		c := chunks[i]
+		select {
+		case <-stop:
+			close(c.done)
+			return
+		default:
+		}
+		c.data = make([]byte, starts[i+1]-starts[i])
+		if m, err := r.ReadAt(c.data, starts[i]); m < len(c.data) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			c.data, c.readErr = c.data[:m], err
+		}
+		c.parse(&dialect)
+	})
+	return collectChunks(ordered, dialect.FieldsPerRecord)
+}
+
+// forEach calls f(i) for each i in [0, n) on up to workers goroutines and
+// waits for the calls to return.
+func forEach(n, workers int, f func(i int)) {
+	next := make(chan int, n)
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// A segment is a run of whole lines of the input read by
+// ReadAllParallelAt, with the result of counting its quotes.
+type segment struct {
+	start, end int64   // Input offsets
+	lines      int     // Number of newlines
+	endQuoted  [2]bool // Whether the end is inside quotes, if the start is not or is
+	first      int     // If the start is inside quotes, the index just past the first record end, or -1
+	firstLines int     // Number of newlines before first
+}
+
+// scan reads and counts the quotes of the segment made of the lines of r
+// that start in [from, to).
+func (s *segment) scan(r io.ReaderAt, size, from, to int64, quote, comment rune) error {
+	var err error
+	if s.start, err = lineAfter(r, from, size); err != nil {
+		return err
+	}
+	if s.end, err = lineAfter(r, to, size); err != nil || s.end == s.start {
+		return err
+	}
+	b := make([]byte, s.end-s.start)
+	if m, err := r.ReadAt(b, s.start); m < len(b) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	s.lines = bytes.Count(b, []byte{'\n'})
+	_, s.endQuoted[0] = countQuotes(b, false, quote, comment)
+	s.first, s.endQuoted[1] = countQuotes(b, true, quote, comment)
+	if s.first >= 0 {
+		s.firstLines = bytes.Count(b[:s.first], []byte{'\n'})
+	}
+	return nil
+}
+
+// lineAfter returns the offset of the first line of r that starts at or
+// after off, or size if there is none.
+func lineAfter(r io.ReaderAt, off, size int64) (int64, error) {
+	if off == 0 || off >= size {
+		return min(off, size), nil
+	}
+	var buf [4096]byte
+	for off--; off < size; {
+		m, err := r.ReadAt(buf[:min(int64(len(buf)), size-off)], off)
+		if i := bytes.IndexByte(buf[:m], '\n'); i >= 0 {
+			return off + int64(i) + 1, nil
+		}
+		if m == 0 {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		off += int64(m)
+	}
+	return size, nil
+}
+
+// countQuotes counts the quotes in b, which starts at the start of a line
+// inside quotes if quoted is set, like lastRecordEnd. It returns the index
+// just past the first newline that ends a record, or -1, and whether the
+// end of b is inside quotes.
+func countQuotes(b []byte, quoted bool, quote, comment rune) (first int, endQuoted bool) {
+	special := string(quote) + "\n"
+	first = -1
+	lineStart := true
+	for i := 0; i < len(b); {
+		if lineStart && !quoted && comment != 0 {
+			if c, _ := utf8.DecodeRune(b[i:]); c == comment {
+				j := bytes.IndexByte(b[i:], '\n')
+				if j < 0 {
+					break
+				}
+				i += j + 1
+				if first < 0 {
+					first = i
+				}
+				continue
+			}
+		}
+		j := bytes.IndexAny(b[i:], special)
+		if j < 0 {
+			break
+		}
+		i += j
+		lineStart = false
+		if b[i] != '\n' {
+			quoted = !quoted
+			i += utf8.RuneLen(quote)
+			continue
+		}
+		if !quoted {
+			if first < 0 {
+				first = i + 1
+			}
+			lineStart = true
+		}
+		i++
+	}
+	return first, quoted
+}
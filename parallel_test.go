@@ -0,0 +1,248 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// randomCSV returns n records of CSV with quoted newlines, doubled quotes,
+// comment lines and blank lines sprinkled in.
+func randomCSV(rnd *rand.Rand, n int) string {
+	pieces := []string{"a", "bc", `"`, ",", "\n", "\r\n", " ", "#", "x y z", "λ"}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	for i := 0; i < n; i++ {
+		rec := make([]string, 4)
+		for j := range rec {
+			for k := rnd.Intn(4); k > 0; k-- {
+				rec[j] += pieces[rnd.Intn(len(pieces))]
+			}
+		}
+		w.Write(rec)
+		switch rnd.Intn(10) {
+		case 0:
+			w.Flush()
+			b.WriteString("\n")
+		case 1:
+			w.Flush()
+			b.WriteString("# a \"comment\n")
+		}
+	}
+	w.Flush()
+	return b.String()
+}
+
+func setParallelChunkSize(t *testing.T, n int) {
+	old := parallelChunkSize
+	parallelChunkSize = n
+	t.Cleanup(func() { parallelChunkSize = old })
+}
+
+func TestReadAllParallel(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, size := range []int{1, 7, 64, 1 << 10} {
+		setParallelChunkSize(t, size)
+		for i := 0; i < 20; i++ {
+			input := randomCSV(rnd, 200)
+			dialect := Dialect{Comment: '#'}
+			r := NewReader(strings.NewReader(input))
+			dialect.configure(r)
+			want, wantErr := r.ReadAll()
+			got, err := ReadAllParallel(strings.NewReader(input), dialect, 4)
+			if !reflect.DeepEqual(err, wantErr) {
+				t.Fatalf("chunk size %d: error mismatch:\ngot  %v\nwant %v", size, err, wantErr)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("chunk size %d: records mismatch for %q", size, input)
+			}
+			got, err = ReadAllParallelAt(strings.NewReader(input), int64(len(input)), dialect, 4)
+			if !reflect.DeepEqual(err, wantErr) {
+				t.Fatalf("chunk size %d: ReadAllParallelAt error mismatch:\ngot  %v\nwant %v", size, err, wantErr)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("chunk size %d: ReadAllParallelAt records mismatch for %q", size, input)
+			}
+		}
+	}
+}
+
+func TestReadAllParallelErrors(t *testing.T) {
+	setParallelChunkSize(t, 8)
+	tests := []struct {
+		Name    string
+		Input   string
+		Dialect Dialect
+	}{{
+		Name:  "FieldCount",
+		Input: "a,b\nc,d\n\"e\nf\",g\nh,i\nj\nk,l\n",
+	}, {
+		Name:    "FieldsPerRecord",
+		Input:   "a,b\nc,d\ne,f,g\n",
+		Dialect: Dialect{FieldsPerRecord: 2},
+	}, {
+		Name:  "BareQuote",
+		Input: "a,b\nc,d\n\"e\nf\",g\nh,i\nj\"j,k\n",
+	}, {
+		Name:  "UnterminatedQuote",
+		Input: "a,b\nc,d\n\"e\nf,g\nh,i\n",
+	}, {
+		Name:    "InvalidDialect",
+		Dialect: Dialect{Comma: '\n'},
+	}, {
+		Name:    "Lazy",
+		Input:   "a\"b,c\n\"d\ne\",f\ng,h\"\n",
+		Dialect: Dialect{LazyQuotes: true},
+	}, {
+		Name:    "Escape",
+		Input:   "a\\\"b,c\n\"d\\\"\ne\",f\n",
+		Dialect: Dialect{Escape: '\\'},
+	}, {
+		Name:    "BlankLineError",
+		Input:   "a,b\n\"c\nd\",e\nf,g\n\nh,i\n",
+		Dialect: Dialect{BlankLineMode: BlankLineError},
+	}, {
+		Name:    "BlankLineEmptyRecord",
+		Input:   "a,b\n\"c\nd\",e\nf,g\n\nh,i\n",
+		Dialect: Dialect{BlankLineMode: BlankLineEmptyRecord},
+	}, {
+		Name:    "Quote",
+		Input:   "'a\nb',c\n\"d,e\n'f''\ng',h\n",
+		Dialect: Dialect{Quote: '\''},
+	}, {
+		Name:    "RelaxedOpenQuote",
+		Input:   "\"a\n\"p\"\"\nq\"\n",
+		Dialect: Dialect{RelaxedOpenQuote: true},
+	}, {
+		Name:    "Variable",
+		Input:   "a\nb,c\n\nd,e,f",
+		Dialect: Dialect{FieldsPerRecord: -1},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(tt.Input))
+			tt.Dialect.configure(r)
+			want, wantErr := r.ReadAll()
+			for _, workers := range []int{0, 1, 3} {
+				got, err := ReadAllParallel(strings.NewReader(tt.Input), tt.Dialect, workers)
+				if !reflect.DeepEqual(err, wantErr) {
+					t.Errorf("workers=%d: error mismatch:\ngot  %v\nwant %v", workers, err, wantErr)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("workers=%d: got %q want %q", workers, got, want)
+				}
+				got, err = ReadAllParallelAt(strings.NewReader(tt.Input), int64(len(tt.Input)), tt.Dialect, workers)
+				if !reflect.DeepEqual(err, wantErr) {
+					t.Errorf("ReadAllParallelAt workers=%d: error mismatch:\ngot  %v\nwant %v", workers, err, wantErr)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("ReadAllParallelAt workers=%d: got %q want %q", workers, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadAllParallelAtQuotedNewlines(t *testing.T) {
+	// Quoted fields hold lines that look like records and comments, so a
+	// segment starting in one must find that it starts inside quotes.
+	const input = "id,text\n1,\"a\nb,c\nd\"\"e\n# f\n\"\n2,\"\n\n\"\"\n3,4\n\"\n# g\n3,\"h\ni\"\n"
+	dialect := Dialect{Comment: '#'}
+	r := NewReader(strings.NewReader(input))
+	dialect.configure(r)
+	want, err := r.ReadAll()
+	if err != nil || len(want) != 4 {
+		t.Fatalf("ReadAll = %q, %v; want 4 records", want, err)
+	}
+	for size := 1; size <= len(input); size++ {
+		setParallelChunkSize(t, size)
+		got, err := ReadAllParallelAt(strings.NewReader(input), int64(len(input)), dialect, 3)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("chunk size %d: ReadAllParallelAt = %q, %v; want %q", size, got, err, want)
+		}
+	}
+
+	// The field count is checked in input order.
+	const ragged = "a,\"b\nc\"\nd,e\nf\n\"g\nh\",i,j\n"
+	_, wantErr := NewReader(strings.NewReader(ragged)).ReadAll()
+	if !errors.Is(wantErr, ErrFieldCount) {
+		t.Fatalf("ReadAll error = %v, want ErrFieldCount", wantErr)
+	}
+	for size := 1; size <= len(ragged); size++ {
+		setParallelChunkSize(t, size)
+		_, err := ReadAllParallelAt(strings.NewReader(ragged), int64(len(ragged)), Dialect{}, 3)
+		if !reflect.DeepEqual(err, wantErr) {
+			t.Fatalf("chunk size %d: error = %v, want %v", size, err, wantErr)
+		}
+	}
+}
+
+func TestReadAllParallelAtShortInput(t *testing.T) {
+	const input = "a,b\nc,d\n"
+	setParallelChunkSize(t, 4)
+	if _, err := ReadAllParallelAt(strings.NewReader(input), int64(len(input))+10, Dialect{}, 2); err != io.ErrUnexpectedEOF {
+		t.Errorf("error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestLastRecordEnd(t *testing.T) {
+	tests := []struct {
+		in      string
+		comment rune
+		want    int
+	}{
+		{"", 0, -1},
+		{"abc", 0, -1},
+		{"a\nb", 0, 2},
+		{"a\nb\n", 0, 4},
+		{"\"a\nb", 0, -1},
+		{"\"a\nb\"\nc", 0, 6},
+		{"\"a\"\"\nb\"\n", 0, 8},
+		{"#\"\na\nb", '#', 5},
+		{"#\"\na\nb", 0, -1},
+		{"a\n#\"", '#', 2},
+	}
+	for _, tt := range tests {
+		if got := lastRecordEnd([]byte(tt.in), '"', tt.comment); got != tt.want {
+			t.Errorf("lastRecordEnd(%q, %q) = %d, want %d", tt.in, tt.comment, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkReadAllParallel(b *testing.B) {
+	input := strings.Repeat(benchmarkCSVData, 20000)
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadAllParallel(strings.NewReader(input), Dialect{}, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadAllParallelAt(b *testing.B) {
+	input := strings.Repeat(benchmarkCSVData, 20000)
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadAllParallelAt(strings.NewReader(input), int64(len(input)), Dialect{}, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadAllSequential(b *testing.B) {
+	input := strings.Repeat(benchmarkCSVData, 20000)
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		if _, err := NewReader(strings.NewReader(input)).ReadAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+// readerState is the part of the state of a Reader that Peek puts back
+// after reading ahead.
+type readerState struct {
+	offset, recordOffset int64
+	numLine, linesRead   int
+	numRecords           int
+	numComments          int
+	numBlank             int
+	numSkipped           int
+	numFields            int
+	fieldPositions       []position
+	fieldQuoted          []bool
+	fieldNull            []bool
+	unterminated         *ParseError
+}
+
+// save copies the state of r to s, reusing the buffers of s.
+func (s *readerState) save(r *Reader) {
+	positions, quoted, null := s.fieldPositions, s.fieldQuoted, s.fieldNull
+	*s = readerState{
+		offset:         r.offset,
+		recordOffset:   r.recordOffset,
+		numLine:        r.numLine,
+		linesRead:      r.linesRead,
+		numRecords:     r.numRecords,
+		numComments:    r.numComments,
+		numBlank:       r.numBlank,
+		numSkipped:     r.numSkipped,
+		numFields:      r.numFields,
+		fieldPositions: append(positions[:0], r.fieldPositions...),
+		fieldQuoted:    append(quoted[:0], r.fieldQuoted...),
+		fieldNull:      append(null[:0], r.fieldNull...),
+		unterminated:   r.unterminated,
+	}
+}
+
+// restore copies s back to r.
+func (s *readerState) restore(r *Reader) {
+	r.offset, r.recordOffset = s.offset, s.recordOffset
+	r.numLine, r.linesRead = s.numLine, s.linesRead
+	r.numRecords, r.numComments, r.numBlank, r.numSkipped = s.numRecords, s.numComments, s.numBlank, s.numSkipped
+	r.numFields = s.numFields
+	r.fieldPositions = append(r.fieldPositions[:0], s.fieldPositions...)
+	r.fieldQuoted = append(r.fieldQuoted[:0], s.fieldQuoted...)
+	r.fieldNull = append(r.fieldNull[:0], s.fieldNull...)
+	r.unterminated = s.unterminated
+}
+
+// Peek returns the record that the next Read will return, along with the
+// error Read will return with it, without consuming it: InputOffset,
+// RecordOffset, FieldPos, Stats and the other methods about the record
+// most recently read keep describing the input before it. Peeking again
+// returns the same record until it is consumed by a read method such as
+// Read, ReadBytes or ReadRaw. Only one record can be peeked at.
+//
+// The record is parsed by Peek, so callbacks such as OnError and
+// OnRepair are called then, and not again when it is read.
+func (r *Reader) Peek() ([]string, error) {
+	if r.peeked {
+		return r.peekRecord, r.peekErr
+	}
+	r.peekBefore.save(r)
+	keepRaw := r.keepRaw
+	r.keepRaw = true
+	record, err := r.readRecord(nil)
+	r.keepRaw = keepRaw
+	r.peeked, r.peekOK, r.peekRecord, r.peekErr = true, record != nil || err == nil, record, err
+	r.peekAfter.save(r)
+	r.peekBefore.restore(r)
+	return record, err
+}
+
+// takePeeked consumes the record read by Peek, if any, and reports
+// whether there was one, along with what parseRecord returned for it.
+func (r *Reader) takePeeked() (peeked, ok bool, err error) {
+	if !r.peeked {
+		return false, false, nil
+	}
+	r.peekAfter.restore(r)
+	ok, err = r.peekOK, r.peekErr
+	r.peeked, r.peekRecord, r.peekErr = false, nil, nil
+	return true, ok, err
+}
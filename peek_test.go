@@ -0,0 +1,58 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPeek(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\n# note\n\"c\nd\",e\nf\n"))
+	r.Comment = '#'
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	offset, stats := r.InputOffset(), r.Stats()
+	for i := 0; i < 2; i++ {
+		record, err := r.Peek()
+		if want := []string{"c\nd", "e"}; err != nil || !reflect.DeepEqual(record, want) {
+			t.Fatalf("Peek = %q, %v, want %q", record, err, want)
+		}
+		if r.InputOffset() != offset || r.Stats() != stats || r.RecordOffset() != 0 {
+			t.Errorf("after Peek: InputOffset() = %d, RecordOffset() = %d, Stats() = %+v, want them unchanged", r.InputOffset(), r.RecordOffset(), r.Stats())
+		}
+		if line, col := r.FieldPos(1); line != 1 || col != 3 {
+			t.Errorf("after Peek: FieldPos(1) = %d, %d, want 1, 3", line, col)
+		}
+	}
+	record, raw, err := r.ReadRaw()
+	if want := []string{"c\nd", "e"}; err != nil || !reflect.DeepEqual(record, want) || string(raw) != "\"c\nd\",e\n" {
+		t.Fatalf("ReadRaw = %q, %q, %v, want %q", record, raw, err, want)
+	}
+	if line, col := r.FieldPos(1); line != 4 || col != 4 {
+		t.Errorf("FieldPos(1) = %d, %d, want 4, 4", line, col)
+	}
+	if r.InputOffset() != 19 || r.RecordOffset() != 11 || r.Stats().Records != 2 {
+		t.Errorf("InputOffset() = %d, RecordOffset() = %d, Stats() = %+v, want 19, 11 and 2 records", r.InputOffset(), r.RecordOffset(), r.Stats())
+	}
+
+	// A record with an error is peeked with it.
+	if record, err := r.Peek(); err == nil || !reflect.DeepEqual(record, []string{"f"}) {
+		t.Errorf("Peek = %q, %v, want the field count error", record, err)
+	}
+	if record, err := r.Read(); err == nil || !reflect.DeepEqual(record, []string{"f"}) {
+		t.Errorf("Read = %q, %v, want the field count error", record, err)
+	}
+	if _, err := r.Peek(); err != io.EOF {
+		t.Errorf("Peek at EOF = %v, want io.EOF", err)
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Read at EOF = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,55 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"io"
+	"sync"
+)
+
+// A WriterPool reuses Writers, and their output buffers, for programs
+// that write many small CSV files. The zero WriterPool is ready to use
+// and hands out Writers in the format of NewWriter. A WriterPool is safe
+// for concurrent use.
+type WriterPool struct {
+	// Dialect is the format of the Writers returned by Get: its Comma,
+	// Quote, QuoteOpen, QuoteClose, Comment, Null, Terminator,
+	// DoubledDelimiterEscape and Strict set the Writer fields of the same
+	// name, QuoteEscape, or else Escape, sets Escape, UnescapeNewlines
+	// sets EscapeNewlines, DecodeBackslashEscapes sets
+	// EncodeBackslashEscapes and a positive FieldsPerRecord sets
+	// FieldsPerRecord. Dialect must not be changed while the pool is in
+	// use.
+	Dialect Dialect
+
+	pool sync.Pool
+}
+
+// Get returns a Writer writing to w, with the options of NewWriter
+// changed as Dialect describes.
+func (p *WriterPool) Get(w io.Writer) *Writer {
+	cw, _ := p.pool.Get().(*Writer)
+	if cw == nil {
+		cw = NewWriter(w)
+	} else {
+		cw.init(w, cw.w)
+	}
+	p.Dialect.configureWriter(cw)
+	return cw
+}
+
+// Put returns w to the pool, stopping its auto-flush and dropping its
+// output and options, so that a later Get starts afresh. w must have been
+// flushed or closed, since any buffered output is discarded, and must not
+// be used after Put.
+func (p *WriterPool) Put(w *Writer) {
+	w.mu.Lock()
+	done := w.stopTicker()
+	w.mu.Unlock()
+	waitDone(done) // A flush in progress must finish before w is reset
+	w.init(nil, w.w)
+	p.pool.Put(w)
+}
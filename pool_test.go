@@ -0,0 +1,79 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterPool(t *testing.T) {
+	p := &WriterPool{Dialect: Dialect{Comma: ';', Quote: '\'', Terminator: "|"}}
+	for i := 0; i < 3; i++ {
+		var b strings.Builder
+		w := p.Get(&b)
+		if err := w.Write([]string{"a;b", "c"}); err != nil {
+			t.Fatalf("Write error = %v", err)
+		}
+		w.Flush()
+		if want := "'a;b';c|"; b.String() != want {
+			t.Errorf("output = %q, want %q", b.String(), want)
+		}
+		w.QuoteAll = true // Put must not let options leak to the next Get
+		w.AutoFlush(0, time.Millisecond)
+		p.Put(w)
+	}
+}
+
+func TestWriterPoolZero(t *testing.T) {
+	var p WriterPool
+	var b strings.Builder
+	w := p.Get(&b)
+	w.Write([]string{"a,b", "c"})
+	w.Flush()
+	p.Put(w)
+	if want := "\"a,b\",c\n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriterPoolAutoFlushClose(t *testing.T) {
+	var p WriterPool
+	for i := 0; i < 100; i++ {
+		var b syncBuffer
+		w := p.Get(&b)
+		w.AutoFlush(0, time.Microsecond)
+		for j := 0; j < 10; j++ {
+			w.Write([]string{"a", "b"})
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close error = %v", err)
+		}
+		p.Put(w)
+		var b2 strings.Builder
+		w = p.Get(&b2)
+		w.Write([]string{"c"})
+		w.Flush()
+		if want := strings.Repeat("a,b\n", 10); b.String() != want || b2.String() != "c\n" {
+			t.Fatalf("output = %q, %q; want %q, %q", b.String(), b2.String(), want, "c\n")
+		}
+		p.Put(w)
+	}
+}
+
+func BenchmarkWriterPool(b *testing.B) {
+	var p WriterPool
+	record := []string{"a", "b", "c"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var sb strings.Builder
+		w := p.Get(&sb)
+		w.Write(record)
+		w.Flush()
+		p.Put(w)
+	}
+}
@@ -62,6 +62,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 )
@@ -73,25 +74,69 @@ type ParseError struct {
 	Line      int   // Line where the error occurred
 	Column    int   // Column (1-based byte index) where the error occurred
 	Err       error // The actual error
+
+	// Snippet, set only if the Reader's ErrorSnippets is true, holds the
+	// text of the line where the error occurred, cut to 256 bytes around
+	// Column with "..." marking the cuts, and a second line with a ^
+	// below Column.
+	Snippet string
+
+	// Leniency, if not empty, names the Reader option that would have
+	// accepted the input, such as "AllowBareQuotes".
+	Leniency string
 }
 
 func (e *ParseError) Error() string {
 	if e.Err == ErrFieldCount {
 		return fmt.Sprintf("record on line %d: %v", e.Line, e.Err)
 	}
+	var hint string
+	if e.Leniency != "" {
+		hint = " (" + e.Leniency + " would accept it)"
+	}
 	if e.StartLine != e.Line {
-		return fmt.Sprintf("record on line %d; parse error on line %d, column %d: %v", e.StartLine, e.Line, e.Column, e.Err)
+		return fmt.Sprintf("record on line %d; parse error on line %d, column %d: %v%s", e.StartLine, e.Line, e.Column, e.Err, hint)
 	}
-	return fmt.Sprintf("parse error on line %d, column %d: %v", e.Line, e.Column, e.Err)
+	return fmt.Sprintf("parse error on line %d, column %d: %v%s", e.Line, e.Column, e.Err, hint)
 }
 
 func (e *ParseError) Unwrap() error { return e.Err }
 
+// A LimitError is the Err of a ParseError for a record that exceeds
+// MaxColumns or MaxRecordBytes.
+type LimitError struct {
+	Err   error // ErrTooManyFields or ErrRecordTooLarge
+	Limit int   // The limit that was exceeded
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v (limit %d)", e.Err, e.Limit)
+}
+
+func (e *LimitError) Unwrap() error { return e.Err }
+
 // These are the errors that can be returned in ParseError.Err.
 var (
-	ErrBareQuote  = errors.New("bare \" in non-quoted-field")
-	ErrQuote      = errors.New("extraneous or missing \" in quoted-field")
-	ErrFieldCount = errors.New("wrong number of fields")
+	ErrBareQuote     = errors.New("bare \" in non-quoted-field")
+	ErrQuote         = errors.New("extraneous or missing \" in quoted-field")
+	ErrFieldCount    = errors.New("wrong number of fields")
+	ErrEscape        = errors.New("escape character at end of input")
+	ErrBlankLine     = errors.New("blank line")
+	ErrFieldTooLarge = errors.New("field too large")
+	ErrBareCR        = errors.New("bare \\r in field")
+	ErrBadLineEnding = errors.New("record not terminated by \\r\\n")
+	ErrUnknownEscape = errors.New("unknown escape sequence")
+	ErrNUL           = errors.New("NUL byte in field")
+	ErrInvalidUTF8   = errors.New("invalid UTF-8 in field")
+
+	// ErrUnterminatedField is not returned by Read but reported by
+	// Unterminated for a quoted field accepted at the end of the input.
+	ErrUnterminatedField = errors.New("quoted field not terminated at end of input")
+
+	// ErrTooManyFields and ErrRecordTooLarge are reported wrapped in a
+	// LimitError giving the limit that was exceeded.
+	ErrTooManyFields  = errors.New("too many fields")
+	ErrRecordTooLarge = errors.New("record too large")
 
 	// Deprecated: ErrTrailingComma is no longer used.
 	ErrTrailingComma = errors.New("extra delimiter at end of line")
@@ -99,10 +144,124 @@ var (
 
 var errInvalidDelim = errors.New("csv: invalid field or comment delimiter")
 
+var errSelectColumns = errors.New("csv: negative column in SelectColumns")
+
+// ErrUTF16 is returned by Read if StripBOM is set and the input starts
+// with a UTF-16 byte order mark.
+var ErrUTF16 = errors.New("csv: input is UTF-16 encoded (byte order mark found)")
+
 func validDelim(r rune) bool {
 	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
 }
 
+func validQuote(r rune) bool {
+	return r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+func validSeparator(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if !validDelim(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// A BlankLineMode controls how a Reader handles blank lines.
+// A line is blank if it holds nothing but its line terminator; a line with
+// only white space is not blank and is read as a record with one field.
+type BlankLineMode int
+
+const (
+	// BlankLineSkip ignores blank lines. This is the default.
+	BlankLineSkip BlankLineMode = iota
+
+	// BlankLineEmptyRecord returns each blank line as a record with a
+	// single empty field. Such records are exempt from the
+	// FieldsPerRecord check and never set FieldsPerRecord.
+	BlankLineEmptyRecord
+
+	// BlankLineError returns a ParseError with Err set to ErrBlankLine for
+	// each blank line. Reading may continue with the next line.
+	BlankLineError
+)
+
+// A BareCRMode controls how a Reader handles a carriage return that is
+// not followed by a newline (a bare carriage return) inside field data.
+type BareCRMode int
+
+const (
+	// BareCRKeep keeps bare carriage returns as field data. This is the
+	// default.
+	BareCRKeep BareCRMode = iota
+
+	// BareCRStrip silently removes bare carriage returns from fields.
+	BareCRStrip
+
+	// BareCRError returns a ParseError with Err set to ErrBareCR at the
+	// first bare carriage return in a record.
+	BareCRError
+
+	// BareCRNewline replaces bare carriage returns in fields with
+	// newlines, so that line breaks written by classic Mac OS tools read
+	// like any other.
+	BareCRNewline
+)
+
+// An InvalidUTF8Mode controls how a Reader handles field data that is not
+// valid UTF-8.
+type InvalidUTF8Mode int
+
+const (
+	// InvalidUTF8PassThrough keeps invalid bytes as field data. This is
+	// the default.
+	InvalidUTF8PassThrough InvalidUTF8Mode = iota
+
+	// InvalidUTF8Replace replaces each byte that is not part of a valid
+	// UTF-8 sequence with the replacement character U+FFFD.
+	InvalidUTF8Replace
+
+	// InvalidUTF8Error returns a ParseError with Err set to
+	// ErrInvalidUTF8 at the first invalid byte in a record.
+	InvalidUTF8Error
+)
+
+// A ShortRowMode controls how a Reader handles a record with fewer fields
+// than FieldsPerRecord.
+type ShortRowMode int
+
+const (
+	// ShortRowError returns the record along with a ParseError with Err
+	// set to ErrFieldCount. This is the default.
+	ShortRowError ShortRowMode = iota
+
+	// ShortRowPad appends empty fields to the record up to
+	// FieldsPerRecord.
+	ShortRowPad
+
+	// ShortRowKeep returns the record as it is, without an error.
+	ShortRowKeep
+)
+
+// A LongRowMode controls how a Reader handles a record with more fields
+// than FieldsPerRecord.
+type LongRowMode int
+
+const (
+	// LongRowError returns the record along with a ParseError with Err
+	// set to ErrFieldCount. This is the default.
+	LongRowError LongRowMode = iota
+
+	// LongRowTruncate drops the fields past FieldsPerRecord.
+	LongRowTruncate
+
+	// LongRowKeep returns the record as it is, without an error.
+	LongRowKeep
+)
+
 // A Reader reads records from a CSV-encoded file.
 //
 // As returned by NewReader, a Reader expects input conforming to RFC 4180.
@@ -117,17 +276,86 @@ type Reader struct {
 	// It is set to comma (',') by NewReader.
 	// Comma must be a valid rune and must not be \r, \n,
 	// or the Unicode replacement character (0xFFFD).
+	// Comma is ignored if Separator is set.
 	Comma rune
 
+	// Separator, if not empty, is a multi-character field delimiter that
+	// overrides Comma. Fields are split on the exact byte sequence, which
+	// may still appear inside quoted fields.
+	// Separator must be valid UTF-8 and must not contain ", \r, \n,
+	// or the Unicode replacement character (0xFFFD).
+	// It must also not begin with the Comment character.
+	Separator string
+
+	// If CollapseDelimiters is true, a run of consecutive delimiters
+	// outside quoted fields separates two fields like a single one, and
+	// delimiters at the start and end of a record are ignored, as for
+	// whitespace-aligned reports read with Comma set to ' '. An empty
+	// field can then only be written as a quoted "".
+	CollapseDelimiters bool
+
+	// If Whitespace is true, fields are separated by runs of spaces and
+	// tabs, in any mix, instead of by Comma or Separator, which are
+	// ignored, and white space at the start and end of a line is ignored,
+	// as for column-aligned output such as that of ps or of old Fortran
+	// programs. It implies CollapseDelimiters, so that an empty field can
+	// only be written as a quoted "", and a line holding only white space
+	// is a blank line. Quoted fields may hold spaces and tabs.
+	Whitespace bool
+
+	// If DoubledDelimiterEscape is true, two consecutive delimiters
+	// outside quoted fields stand for a literal delimiter in the field
+	// rather than for an empty field, so that a,,b is the single field
+	// "a,b". An empty field therefore cannot appear between two others
+	// unless it is quoted. Of three delimiters in a row, the first two
+	// are taken as a literal one. DoubledDelimiterEscape cannot be
+	// combined with CollapseDelimiters or Whitespace. A Writer with the
+	// same option writes records that read back unchanged.
+	DoubledDelimiterEscape bool
+
+	// Quote is the character that encloses quoted fields.
+	// It is set to '"' by NewReader. If Quote is 0, quoting is disabled:
+	// no field is quoted and quote characters are ordinary field data.
+	// Quote must be a valid rune and must not be \r, \n,
+	// or the Unicode replacement character (0xFFFD).
+	// It must also not be equal to Comma or Comment, or appear in Separator.
+	Quote rune
+
+	// QuoteOpen and QuoteClose, if not 0, replace Quote as the characters
+	// that open and close a quoted field, for formats such as «a»,«b».
+	// Each defaults to Quote. Inside a quoted field, a doubled QuoteClose
+	// stands for one, and QuoteEscape and Escape escape QuoteClose. Only
+	// QuoteOpen is a bare quote in an unquoted field. They must be valid
+	// as Quote is.
+	QuoteOpen  rune
+	QuoteClose rune
+
 	// Comment, if not 0, is the comment character. Lines beginning with the
 	// Comment character without preceding whitespace are ignored.
 	// With leading whitespace the Comment character becomes part of the
 	// field, even if TrimLeadingSpace is true.
+	// A Comment character inside a quoted field or in the middle of a field
+	// is ordinary field data, unless InlineComments is true. Skipped
+	// comment lines still count toward the line numbers reported by
+	// FieldPos and ParseError.
 	// Comment must be a valid rune and must not be \r, \n,
 	// or the Unicode replacement character (0xFFFD).
-	// It must also not be equal to Comma.
+	// It must also not be equal to Comma or Quote.
 	Comment rune
 
+	// If InlineComments is true, a Comment character outside of a quoted
+	// field also ends the record, and the rest of the line is ignored.
+	// An escaped Comment character is field data. White space before the
+	// comment is dropped from an unquoted field unless KeepCommentSpace is
+	// true, and is always allowed between a closing quote and the comment.
+	// Lines holding only white space before the comment are ignored like
+	// full-line comments. InlineComments has no effect if Comment is 0.
+	InlineComments bool
+
+	// If KeepCommentSpace is true, white space before an inline comment
+	// is kept as part of an unquoted field.
+	KeepCommentSpace bool
+
 	// FieldsPerRecord is the number of expected fields per record.
 	// If FieldsPerRecord is positive, Read requires each record to
 	// have the given number of fields. If FieldsPerRecord is 0, Read sets it to
@@ -136,30 +364,426 @@ type Reader struct {
 	// made and records may have a variable number of fields.
 	FieldsPerRecord int
 
+	// OnShortRow and OnLongRow control how records with fewer or more
+	// fields than a positive FieldsPerRecord, including one set from the
+	// first record, are handled. By default they are errors. Padded fields
+	// report the position of the last field of the input to FieldPos.
+	// FieldCount gives the number of fields a record had in the input.
+	OnShortRow ShortRowMode
+	OnLongRow  LongRowMode
+
 	// If LazyQuotes is true, a quote may appear in an unquoted field and a
-	// non-doubled quote may appear in a quoted field.
+	// non-doubled quote may appear in a quoted field. It is a shorthand for
+	// setting all of AllowBareQuotes, AllowTextAfterClosingQuote and
+	// AllowUnterminatedQuote.
 	LazyQuotes bool
 
+	// If AllowBareQuotes is true, a quote may appear in an unquoted field,
+	// as in 5" pipe, and is taken literally.
+	AllowBareQuotes bool
+
+	// If AllowTextAfterClosingQuote is true, a quote in a quoted field that
+	// is not doubled and not followed by a delimiter or the end of the
+	// line, as in "a"b", is taken literally and the field goes on.
+	AllowTextAfterClosingQuote bool
+
+	// If AllowUnterminatedQuote is true, a quoted field still open at the
+	// end of the input ends there instead of being an error, so that the
+	// last record of a truncated file is returned with the rest of the
+	// input as its last field. Unterminated reports when this happened.
+	AllowUnterminatedQuote bool
+
+	// If RelaxedOpenQuote is true, a field that starts with a quote but
+	// whose line has no closing quote after it is read as an unquoted
+	// field, with the quote taken literally, instead of running on to
+	// the next line. For example, "abc,def is read as the two fields
+	// "abc and def, while "abc,def" is still the single field abc,def.
+	// Unlike LazyQuotes, it leaves the parsing of well-formed quoted
+	// fields alone. A quoted field can then span lines only if its first
+	// line holds another quote after the opening one: "p"" is read as a
+	// quoted field, with a doubled quote, that runs on to the next line.
+	RelaxedOpenQuote bool
+
+	// If RepairQuotes is true, malformed quoting is repaired instead of
+	// failing the record: a quote that is not at a field boundary, as in
+	// `5" pipe,steel`, is taken literally, and a quoted field that is not
+	// closed by the end of its line is closed there, so it takes the rest
+	// of the line but never the following lines. Quoted fields therefore
+	// cannot span lines. Each repair counts toward Repairs and is passed
+	// to OnRepair, if set, as the ParseError it avoided. Literal quotes
+	// accepted by LazyQuotes or an Allow option are not counted as repairs.
+	RepairQuotes bool
+
+	// OnRepair, if set, is called for each repair made by RepairQuotes.
+	OnRepair func(err *ParseError)
+
+	// OnError, if set, is called with the ParseError of each record that
+	// fails to parse or has the wrong number of fields, and with the raw
+	// input of the record, as ReadRaw returns it, which is only valid
+	// during the call. If OnError returns true, the record is skipped as
+	// described by ReadAllLenient and reading goes on with the next one;
+	// otherwise the error is returned as without OnError. The Reader then
+	// keeps a copy of the input of each record.
+	OnError func(err *ParseError, rawLine []byte) (continueParsing bool)
+
+	// If Strict is true, the input must follow RFC 4180 exactly. The
+	// options that relax its rules are ignored: LazyQuotes, the Allow
+	// options, RepairQuotes, RelaxedOpenQuote and AcceptCR are taken to
+	// be false, and OnShortRow and OnLongRow to be ShortRowError and
+	// LongRowError. A bare quote in an unquoted field
+	// (ErrBareQuote), text after a closing quote (ErrQuote), a carriage
+	// return not followed by a newline outside quoted fields (ErrBareCR)
+	// and, unless FieldsPerRecord is negative, a record with the wrong
+	// number of fields (ErrFieldCount) are then all errors, reported in a
+	// ParseError with their position. Options that describe the format
+	// rather than relax it, such as Comma and Comment, keep their meaning.
+	Strict bool
+
+	// If RequireCRLF is true, each record must end in \r\n, as RFC 4180
+	// requires, except a last record at EOF, which need not be terminated.
+	// A record ending in a bare \n is reported as a ParseError with Err
+	// set to ErrBadLineEnding at the position of the \n. RequireCRLF has
+	// no effect with a Terminator.
+	RequireCRLF bool
+
+	// Escape, if not 0, is the escape character. Inside and outside of
+	// quoted fields, the character following Escape is taken literally,
+	// so that for Escape == '\\' the sequences \", \, and \\ stand for
+	// a quote, a delimiter and a backslash. An Escape before a line break
+	// continues the field on the next line. Doubled quotes are still
+	// recognized inside quoted fields.
+	// Escape must be a valid rune and must not be \r, \n, ",
+	// or the Unicode replacement character (0xFFFD).
+	// It must also not be equal to Comma or Comment, or appear in Separator.
+	Escape rune
+
+	// QuoteEscape, if not 0, is an alternative way of escaping quotes
+	// inside quoted fields: QuoteEscape followed by the quote character or
+	// by another QuoteEscape stands for that character, so that for
+	// QuoteEscape == '^' the field "a^"b^^" reads as a"b^. Elsewhere,
+	// including outside quoted fields, QuoteEscape is ordinary data.
+	// Doubled quotes are still recognized unless StrictQuoteEscape is true.
+	// A Writer with Escape set to the same character writes fields that
+	// read back unchanged.
+	// QuoteEscape must be a valid rune and must not be \r, \n, ",
+	// or the Unicode replacement character (0xFFFD).
+	// It must also not be equal to Quote or Comment, appear in Separator,
+	// or be combined with Escape.
+	QuoteEscape rune
+
+	// If StrictQuoteEscape is true, only QuoteEscape escapes a quote in a
+	// quoted field and doubled quotes are not recognized, so that they are
+	// an error unless AllowTextAfterClosingQuote or LazyQuotes is true. It
+	// has no effect if QuoteEscape is 0.
+	StrictQuoteEscape bool
+
+	// If DecodeEscapes is true, the escape sequences for n, t, r and 0 are
+	// decoded to newline, tab, carriage return and NUL respectively
+	// instead of being taken literally.
+	DecodeEscapes bool
+
+	// If UnescapeNewlines is true, the sequences \n, \r and \\ in field
+	// data, as written by a Writer with EscapeNewlines, are decoded to a
+	// newline, a carriage return and a backslash. Any other backslash is
+	// kept. The sequences are decoded after quotes and Escape, so they
+	// may appear in quoted and unquoted fields alike. UnescapeNewlines is
+	// ignored with DecodeBackslashEscapes, which decodes them itself.
+	UnescapeNewlines bool
+
+	// If DecodeBackslashEscapes is true, fields are read as in the text
+	// formats of database dumps, such as those of Postgres COPY and MySQL
+	// SELECT INTO OUTFILE: Escape is taken to be '\\' and DecodeEscapes to
+	// be true, so that \n, \t, \r, \0 and \\ stand for a newline, a tab,
+	// a carriage return, NUL and a backslash and any other escaped
+	// character, such as the delimiter, is taken literally. An unquoted
+	// field that is exactly \N is read as an empty field and reported as
+	// NULL by LastRecordNull. Quote is usually set to 0 for such files.
+	// A Writer with EncodeBackslashEscapes writes records that read back
+	// unchanged.
+	DecodeBackslashEscapes bool
+
+	// If RejectUnknownEscapes is true, an escaped ASCII letter or digit
+	// other than n, t, r, 0 and N, such as \q, is reported as a ParseError
+	// with Err set to ErrUnknownEscape instead of being taken literally.
+	// It has no effect unless DecodeBackslashEscapes is true.
+	RejectUnknownEscapes bool
+
+	// If NullIfUnquotedEmpty is true, an empty field that is not quoted,
+	// as between the delimiters of a,,b, is NULL, unlike the quoted "",
+	// so that ReadPtr returns it as nil and LastRecordNull reports it.
+	// A field left empty by TrimLeadingSpace or TrimSpace is NULL too,
+	// as are the fields added by OnShortRow and the columns a record is
+	// missing for SelectColumns. With Quote set to 0, every empty field
+	// is NULL. A Writer writes a nil field given to WritePtr unquoted and
+	// with QuoteEmpty set quotes the empty strings.
+	NullIfUnquotedEmpty bool
+
+	// Null, if not empty, is the text of a NULL field, such as NA or
+	// NULL: an unquoted field that is exactly Null, after any trimming of
+	// white space, is read as an empty field, returned as nil by ReadPtr
+	// and reported by LastRecordNull. A quoted "NA" is the literal text.
+	// A Writer with the same Null writes records that read back
+	// unchanged.
+	Null string
+
+	// If AcceptCR is true, a carriage return that is not followed by a
+	// newline also ends a record, as in files from classic Mac OS.
+	// Inside a quoted field, such a carriage return is kept as field data.
+	AcceptCR bool
+
+	// Terminator, if not empty, is the record terminator. Records are split
+	// on this exact byte sequence outside of quoted fields instead of on
+	// newlines, which become ordinary field data, and AcceptCR is ignored.
+	// Line numbers then count terminated records, not newlines. A final
+	// record at EOF need not be terminated.
+	// Terminator must be valid UTF-8 and must not contain ", Escape or the
+	// field delimiter.
+	Terminator string
+
+	// BareCR controls how a carriage return that is not followed by a
+	// newline is handled inside field data. By default it is kept.
+	// A carriage return taken literally after Escape, or decoded by
+	// DecodeEscapes, is always kept. BareCR does not apply to carriage
+	// returns that end a record; to treat a bare carriage return as a
+	// record separator, as in files from classic Mac OS, set AcceptCR.
+	BareCR BareCRMode
+
+	// If NormalizeLineEndings is true, each \r\n in field data becomes
+	// \n, even with Terminator set, where line breaks are otherwise kept
+	// as they are; without Terminator, the Reader already reads line
+	// breaks that way. To convert carriage returns that are not followed
+	// by a newline as well, set BareCR to BareCRNewline. Only the fields
+	// returned change: positions, InputOffset and ReadRaw still describe
+	// the input as it is.
+	NormalizeLineEndings bool
+
+	// NUL bytes in fields, quoted or not, are field data like any other
+	// byte. If RejectNUL is true, a NUL byte in a field is instead
+	// reported as a ParseError with Err set to ErrNUL at its position, as
+	// NUL bytes in text usually mean UTF-16 input read as UTF-8. A NUL
+	// decoded from an escape sequence by DecodeEscapes is still accepted.
+	RejectNUL bool
+
+	// InvalidUTF8 controls how field data that is not valid UTF-8, such
+	// as a stray Latin-1 byte, is handled, so that it can be caught at
+	// the record it is in. By default it is kept as it is. Each field is
+	// checked once, with utf8.Valid, so that valid input costs little.
+	InvalidUTF8 InvalidUTF8Mode
+
+	// BlankLineMode controls how blank lines are handled.
+	// By default they are skipped.
+	BlankLineMode BlankLineMode
+
+	// MaxRecords, if positive, is the number of records Read returns
+	// before it reports io.EOF, so that ReadAll and the other read methods
+	// stop there too. The input after the last record returned is left
+	// unread, and InputOffset gives its position. Comment and blank lines,
+	// the header read by ReadHeader and records skipped by SkipRows do not
+	// count toward MaxRecords. Raising MaxRecords lets reading continue.
+	// Zero means no limit.
+	MaxRecords int
+
+	// SkipRows is the number of records to discard before the first
+	// record is returned, for example a preamble before the header. The
+	// skipped records are parsed like any other, so a quoted field in them
+	// may span lines, but they are not checked against FieldsPerRecord and
+	// parse errors in them are ignored. InputOffset and line numbers count
+	// the skipped input.
+	SkipRows int
+
+	// If ErrorSnippets is true, the ParseErrors of Read carry a Snippet of
+	// the input line where the error occurred. The Reader then keeps a
+	// copy of up to 64 KiB of each line of the current record. Beware that
+	// snippets can expose sensitive field data wherever errors are logged;
+	// by default no input is kept or reported.
+	ErrorSnippets bool
+
+	// If StripBOM is true, a UTF-8 byte order mark at the start of the
+	// input is discarded, and a UTF-16 byte order mark makes Read return
+	// ErrUTF16. Only the first bytes of the input are checked. The
+	// discarded bytes count toward InputOffset. With a Decoder, the
+	// decoded input is checked, so a UTF-16 Decoder reads UTF-16 input.
+	StripBOM bool
+
+	// Decoder, if set, converts the input from a legacy character
+	// encoding, such as Latin-1, to UTF-8 before it is parsed. InputOffset
+	// and the columns of FieldPos and ParseError still count bytes of the
+	// undecoded input. Decoder must be set before the first Read; an
+	// error it returns is returned by Read.
+	Decoder Transformer
+
 	// If TrimLeadingSpace is true, leading white space in a field is ignored.
 	// This is done even if the field delimiter, Comma, is white space.
 	TrimLeadingSpace bool
 
+	// If TrimSpace is true, leading and trailing white space in unquoted
+	// fields is ignored, as is white space around quoted fields, whose
+	// content is kept verbatim. TrimSpace implies TrimLeadingSpace.
+	// A field holding only white space becomes empty; it still counts
+	// toward FieldsPerRecord and reads exactly like an empty field.
+	TrimSpace bool
+
+	// MaxFieldSize, if positive, is the maximum size of a field in bytes,
+	// after quotes and escapes are decoded. A longer field makes Read
+	// return a ParseError with Err set to ErrFieldTooLarge giving the
+	// position where the field starts. Reading stops within the field, so
+	// memory stays bounded even for an unterminated quoted field, and may
+	// continue with the following line. Zero means no limit. Each line is
+	// still read whole before its fields are checked; set MaxRecordBytes
+	// as well to bound the memory used by input without line breaks.
+	MaxFieldSize int
+
+	// MaxQuotedLines, if positive, is the maximum number of lines a quoted
+	// field may span. A field running onto more lines makes Read return a
+	// ParseError with Err set to ErrQuote at the opening quote, so that a
+	// missing closing quote is reported where the field starts instead of
+	// at the end of the input. Zero means no limit.
+	MaxQuotedLines int
+
+	// MaxRecordBytes, if positive, is the maximum size of a record in
+	// bytes of input, including all its lines and line terminators, as
+	// measured by InputOffset. MaxColumns, if positive, is the maximum
+	// number of fields in a record. A record exceeding either limit makes
+	// Read return a ParseError whose Err is a LimitError wrapping
+	// ErrRecordTooLarge or ErrTooManyFields. Reading stops as soon as a
+	// limit is exceeded and may continue with the following line. Bytes
+	// of a single line beyond MaxRecordBytes are read and discarded rather
+	// than buffered. Zero means no limit.
+	MaxRecordBytes int
+	MaxColumns     int
+
+	// InitialRecordBuffer and InitialFieldCount, if positive, are the
+	// number of bytes and of fields the Reader makes room for in its
+	// line and record buffers before reading a record, so that a caller
+	// who knows the shape of the data avoids growing them. The buffers
+	// are kept and reused from record to record whether or not
+	// ReuseRecord is set, so the hints only matter while they are larger
+	// than the records read so far. The fields returned by Read are
+	// allocated separately, as described for ReuseRecord.
+	InitialRecordBuffer int
+	InitialFieldCount   int
+
+	// HeaderMatch controls how column names are matched against the
+	// names in the header record. By default they must match exactly.
+	HeaderMatch HeaderMatchMode
+
+	// NormalizeHeader, if set, is applied to each name of the header read
+	// by ReadHeader, such as NormalizeSnake or strings.ToLower, so that
+	// ReadHeader returns the normalized names and ReadMap, Field and
+	// SelectNames match against them. Data records are not changed.
+	NormalizeHeader func(name string) string
+
+	// If RejectDuplicateHeaders is true, ReadHeader reports a name that
+	// appears twice in the header with an AmbiguousHeaderError. By default
+	// a repeated name refers to its first column.
+	RejectDuplicateHeaders bool
+
+	// If RejectExtraFields is true, ReadMap reports a record with more
+	// fields than the header has names with a ParseError whose Err is
+	// ErrFieldCount. By default the extra fields are stored under
+	// generated keys.
+	RejectExtraFields bool
+
+	// SelectColumns, if not empty, lists the 0-based columns that Read
+	// returns, in the order given, so that only their fields are converted
+	// to strings. A column the record does not have is returned as an empty
+	// field. FieldsPerRecord, OnShortRow, OnLongRow and FieldCount still
+	// apply to all the fields of the record, while FieldPos,
+	// LastRecordQuoted, LastRecordNull and the other methods and options
+	// that work on a record, such as ReadBytes, ReadFunc and ReadHeader,
+	// see only the selected columns. The columns must not be negative.
+	SelectColumns []int
+
+	// SelectNames, if not empty, selects columns by their names in the
+	// header, matched as set by HeaderMatch, instead of SelectColumns. The
+	// header is read by ReadHeader before the first record if it has not
+	// been already, and ReadHeader then returns the selected names as the
+	// header has them. A name matching no column makes ReadHeader and every
+	// later read fail with a MissingColumnsError unless LenientSelect is
+	// true, in which case the column is read as empty fields.
+	SelectNames   []string
+	LenientSelect bool
+
 	// ReuseRecord controls whether calls to Read may return a slice sharing
 	// the backing array of the previous call's returned slice for performance.
 	// By default, each call to Read returns newly allocated memory owned by the caller.
 	ReuseRecord bool
 
-	// Deprecated: TrailingComma is no longer used.
+	// InternStrings makes Read and the methods built on it return the same
+	// string for every field holding a given value shorter than 64 bytes,
+	// so that a column with few distinct values, such as a country code,
+	// does not keep a copy of the value per record. It trades CPU time, a
+	// map lookup per field, for memory. Up to 65536 distinct values are
+	// remembered; the other fields are then copied one by one, so that a
+	// long field does not keep the rest of its record in memory.
+	InternStrings bool
+
+	// If BoolCaseInsensitive is true, ConvertBool matches the values set
+	// by BoolValues regardless of case, so that "Yes" is a true value if
+	// "yes" is.
+	BoolCaseInsensitive bool
+
+	// If AllowTrailingComma is true, a delimiter at the end of a record
+	// ends the record instead of starting an empty last field, so that
+	// a,b,c, holds three fields, as written by many exporters. The rule
+	// is exact: a record of two or more fields loses its last field if
+	// that field is unquoted and empty, after any trimming of white space,
+	// and not read as NULL from the Null token or \N.
+	// A quoted empty field is kept, so that a,b,"" and a,b,"", hold three
+	// fields, and a record that is only a delimiter holds one empty field.
+	// The field is dropped before FieldsPerRecord is checked or set from
+	// the first record.
+	AllowTrailingComma bool
+
+	// Deprecated: TrailingComma is no longer used; see AllowTrailingComma.
 	TrailingComma bool
 
 	r *bufio.Reader
 
+	// closer is the underlying io.Reader, if it is also an io.Closer.
+	closer io.Closer
+	closed bool
+
 	// numLine is the current line being read in the CSV file.
 	numLine int
 
 	// offset is the input stream byte offset of the current reader position.
 	offset int64
 
+	// sep, quote and term are the encoded field delimiter, opening quote
+	// character and record terminator for the record being read, and
+	// closeQuote is the encoded closing quote character.
+	// comment is the encoded Comment character if InlineComments is set,
+	// and qescape is the encoded QuoteEscape character.
+	sep        []byte
+	quote      []byte
+	closeQuote []byte
+	term       []byte
+	comment    []byte
+	qescape    []byte
+
+	// escape and decodeEscapes are the effective Escape and DecodeEscapes,
+	// which DecodeBackslashEscapes overrides.
+	escape        rune
+	decodeEscapes bool
+
+	// The lenient options as in effect for the record being read, all
+	// turned off by Strict.
+	lazyQuotes                 bool
+	allowBareQuotes            bool
+	allowTextAfterClosingQuote bool
+	allowUnterminatedQuote     bool
+	relaxedOpenQuote           bool
+	repairQuotes               bool
+	acceptCR                   bool
+	onShortRow                 ShortRowMode
+	onLongRow                  LongRowMode
+
+	// plain reports that unquoted fields need no processing beyond
+	// finding the single-byte delimiter, so that parseRecord can take
+	// them on its fast path.
+	plain bool
+
 	// rawBuffer is a line buffer only used by the readLine method.
 	rawBuffer []byte
 
@@ -177,16 +801,211 @@ type Reader struct {
 	// last record returned by Read.
 	fieldPositions []position
 
+	// fieldQuoted records which fields of the last record were quoted in
+	// the input, and quotedField whether the field being parsed is.
+	fieldQuoted []bool
+	quotedField bool
+
+	// fieldNull records which fields of the last record were NULL, and
+	// nullField whether the field being parsed is.
+	fieldNull []bool
+	nullField bool
+
+	// bareLF is the column of the \n ending the last line read if it is
+	// not preceded by \r, for RequireCRLF, or 0.
+	bareLF int
+
+	// interned holds the field values shared by InternStrings.
+	interned map[string]string
+
 	// lastRecord is a record cache and only used when ReuseRecord == true.
 	lastRecord []string
+
+	// lastBytes is the record cache used by ReadBytes, and lastPtrs the
+	// one used by ReadPtr when ReuseRecord == true.
+	lastBytes [][]byte
+	lastPtrs  []*string
+
+	// numFields is the number of fields of the last record in the input.
+	numFields int
+
+	// inferredFields is the FieldsPerRecord set from the first record, if
+	// any, so that Reset can clear it.
+	inferredFields int
+
+	// recordOffset is the input offset of the last record read.
+	recordOffset int64
+
+	// rawRecord holds the input lines of the current record while keepRaw
+	// is set by ReadRaw or OnError is set.
+	rawRecord []byte
+	keepRaw   bool
+
+	// snippetLines holds the start of each line of the current record
+	// for ErrorSnippets.
+	snippetLines [][]byte
+
+	// numRepairs counts the repairs made by RepairQuotes.
+	numRepairs int
+
+	// unterminated is the error reported by Unterminated for the last
+	// record, or nil.
+	unterminated *ParseError
+
+	// skippedRows records that SkipRows has been applied.
+	skippedRows bool
+
+	// numRecords counts the records read toward MaxRecords, unless
+	// noCount is set while reading the header or skipped records.
+	numRecords int
+	noCount    bool
+
+	// linesRead counts the lines read, unlike numLine not counting the
+	// empty read at EOF. numComments and numBlank count the comment and
+	// blank lines skipped.
+	linesRead   int
+	numComments int
+	numBlank    int
+
+	// numSkipped counts the records skipped by ReadAllLenient.
+	numSkipped int
+
+	// resume is the position after the first line of the current record,
+	// where ReadAllLenient resumes after a failed record spanning lines,
+	// and replay holds the input it has put back to be read again.
+	resume struct {
+		offset             int64
+		numLine, linesRead int
+	}
+	replay *replayReader
+
+	// peeked records that Peek has read ahead peekRecord and peekErr,
+	// with peekOK what parseRecord reported for it, and peekBefore and
+	// peekAfter hold the state before and after the record.
+	peeked     bool
+	peekOK     bool
+	peekRecord []string
+	peekErr    error
+	peekBefore readerState
+	peekAfter  readerState
+
+	// numChecks counts the calls of checkContext.
+	numChecks int
+
+	// iterErr is the error that stopped Values.
+	iterErr error
+
+	// converters are the column converters set by SetConverter.
+	converters []func(string) (any, error)
+
+	// trueValues and falseValues are the values set by BoolValues.
+	trueValues, falseValues []string
+
+	// bomChecked records that StripBOM has been applied.
+	bomChecked bool
+
+	// dec is the input decoded by Decoder, and lineStarts holds the
+	// decoded offsets of the lines of the current record, also kept for
+	// a Reader made by NewBytesReader.
+	dec        *decodeReader
+	lineStarts []int64
+
+	// data is the input of a Reader made by NewBytesReader, which the
+	// fields it returns share.
+	data []byte
+
+	// header, headerIndex and headerErr are the result of ReadHeader.
+	header      []string
+	headerIndex *headerIndex
+	headerErr   error
+
+	// selected holds the columns of SelectNames, once resolved by
+	// ReadHeader, and selectErr the error resolving them.
+	selected  []int
+	selectErr error
 }
 
 // NewReader returns a new Reader that reads from r.
 func NewReader(r io.Reader) *Reader {
+	return NewReaderSize(r, 0)
+}
+
+// NewReaderSize returns a new Reader that reads from r through an input
+// buffer of at least size bytes, as for bufio.NewReaderSize. Lines longer
+// than the buffer are still read, at the cost of a copy. A size of 0
+// selects the default of NewReader.
+func NewReaderSize(r io.Reader, size int) *Reader {
+	c, _ := r.(io.Closer)
+	br := bufio.NewReader(r)
+	if size > 0 {
+		br = bufio.NewReaderSize(r, size)
+	}
 	return &Reader{
-		Comma: ',',
-		r:     bufio.NewReader(r),
+		Comma:  ',',
+		Quote:  '"',
+		r:      br,
+		closer: c,
+	}
+}
+
+// Close marks the Reader as closed and, if the underlying io.Reader
+// implements io.Closer, closes it and returns its error. For other sources
+// Close is a no-op that returns nil. Reads after Close fail, as does a
+// second call to Close.
+func (r *Reader) Close() error {
+	if r.closed {
+		return errClosed
+	}
+	r.closed = true
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// Reset discards any buffered input and the state kept from earlier
+// records, and makes the Reader read from in as if it were new, while
+// reusing its buffers. The options, such as Comma and LazyQuotes, the
+// converters set by SetConverter, the values set by BoolValues and the
+// values remembered for InternStrings are kept, except that a
+// FieldsPerRecord set from the first record, and not changed since, is
+// reset to 0. The header read by
+// ReadHeader is forgotten, and a closed Reader is reopened.
+func (r *Reader) Reset(in io.Reader) {
+	if r.inferredFields != 0 && r.FieldsPerRecord == r.inferredFields {
+		r.FieldsPerRecord = 0
 	}
+	r.r.Reset(in)
+	r.closer, _ = in.(io.Closer)
+	r.closed = false
+	r.numLine = 0
+	r.offset = 0
+	r.recordBuffer = r.recordBuffer[:0]
+	r.fieldIndexes = r.fieldIndexes[:0]
+	r.fieldPositions = r.fieldPositions[:0]
+	r.fieldQuoted = r.fieldQuoted[:0]
+	r.fieldNull = r.fieldNull[:0]
+	r.numFields = 0
+	r.inferredFields = 0
+	r.recordOffset = 0
+	r.rawRecord = r.rawRecord[:0]
+	r.snippetLines = r.snippetLines[:0]
+	r.numRepairs = 0
+	r.unterminated = nil
+	r.skippedRows = false
+	r.numRecords = 0
+	r.linesRead, r.numComments, r.numBlank = 0, 0, 0
+	r.numSkipped = 0
+	r.replay = nil
+	r.peeked, r.peekRecord, r.peekErr = false, nil, nil
+	r.numChecks = 0
+	r.iterErr = nil
+	r.bomChecked = false
+	r.dec = nil
+	r.lineStarts = r.lineStarts[:0]
+	r.data = nil
+	r.header, r.headerIndex, r.headerErr = nil, nil, nil
+	r.selected, r.selectErr = nil, nil
 }
 
 // Read reads one record (a slice of fields) from r.
@@ -208,9 +1027,96 @@ func (r *Reader) Read() (record []string, err error) {
 	return record, err
 }
 
+// ReadRaw is like Read but also returns the input the record was parsed
+// from: all its lines exactly as read, including quotes, escapes and line
+// terminators, but not the comment and blank lines before it. The raw
+// bytes are decoded by Decoder, if set. They are returned even along with
+// a parse error, so that rejected records can be written out verbatim,
+// and they are not reused by later calls.
+func (r *Reader) ReadRaw() (record []string, raw []byte, err error) {
+	r.keepRaw = true
+	defer func() { r.keepRaw = false }()
+	if r.ReuseRecord {
+		record, err = r.readRecord(r.lastRecord)
+		r.lastRecord = record
+	} else {
+		record, err = r.readRecord(nil)
+	}
+	if record == nil && err != nil {
+		return nil, nil, err
+	}
+	return record, bytes.Clone(r.rawRecord), err
+}
+
+// ReadBytes is like Read but returns the fields of the record as byte
+// slices, sparing the string allocation for callers that parse the fields
+// themselves, for example with strconv on the bytes. The fields are
+// unescaped exactly as by Read, so a quoted "" reads as a single ".
+//
+// The returned slices, and the fields they hold, point into the Reader's
+// internal buffers: they are only valid until the next call to any of the
+// Reader's read methods, which overwrites them. Copy any field that must
+// outlive that call, and never retain or modify the slices. ReadBytes
+// ignores ReuseRecord since it allocates no memory for the record.
+func (r *Reader) ReadBytes() (record [][]byte, err error) {
+	ok, err := r.nextRecord()
+	if !ok {
+		return nil, err
+	}
+	record = r.lastBytes[:0]
+	var preIdx int
+	for _, idx := range r.fieldIndexes {
+		record = append(record, r.recordBuffer[preIdx:idx:idx])
+		preIdx = idx
+	}
+	r.lastBytes = record
+	return record, err
+}
+
+// ReadFunc reads one record and calls fn with each of its fields and the
+// field's 0-based column, in order, without allocating a slice for the
+// record. The fields share one string allocation, as those returned by
+// Read do, unless InternStrings is set. If fn returns an error, ReadFunc
+// skips the rest of the record and returns that error. Otherwise it
+// returns the error Read would return: io.EOF if there is no data left,
+// without calling fn, and a ParseError after calling fn with the fields
+// of the record, or those read before the error.
+func (r *Reader) ReadFunc(fn func(field string, col int) error) error {
+	ok, err := r.nextRecord()
+	if !ok {
+		return err
+	}
+	var str string
+	if !r.InternStrings {
+		str = string(r.recordBuffer)
+	}
+	var preIdx int
+	for i, idx := range r.fieldIndexes {
+		var field string
+		if r.InternStrings {
+			field = r.intern(r.recordBuffer[preIdx:idx])
+		} else {
+			field = str[preIdx:idx]
+		}
+		if ferr := fn(field, i); ferr != nil {
+			return ferr
+		}
+		preIdx = idx
+	}
+	return err
+}
+
+// LastRecordQuoted reports for each field of the record most recently
+// returned by Read or ReadBytes whether it was quoted in the input, so
+// that Writer.WriteWithQuoting can write it back quoted the same way.
+// Fields added by OnShortRow are reported as unquoted.
+func (r *Reader) LastRecordQuoted() []bool {
+	return append([]bool(nil), r.fieldQuoted...)
+}
+
 // FieldPos returns the line and column corresponding to
 // the start of the field with the given index in the slice most recently
-// returned by Read. Numbering of lines and columns starts at 1;
+// returned by Read or ReadBytes. Numbering of lines and columns starts at 1;
 // columns are counted in bytes, not runes.
 //
 // If this is called with an out-of-bounds index, it panics.
@@ -222,13 +1128,144 @@ func (r *Reader) FieldPos(field int) (line, column int) {
 	return p.line, p.col
 }
 
+// Repairs returns the number of repairs RepairQuotes has made so far.
+func (r *Reader) Repairs() int {
+	return r.numRepairs
+}
+
+// ReaderStats holds the counters returned by Reader.Stats.
+type ReaderStats struct {
+	// Records is the number of records read, including those returned
+	// with a ParseError, such as ErrFieldCount. As for MaxRecords, the
+	// header read by ReadHeader and records skipped by SkipRows are not
+	// counted, nor are records skipped by ReadAllLenient.
+	Records int
+
+	// Lines is the number of lines read, counting every physical line of
+	// a multi-line record as well as comment and blank lines. With a
+	// Terminator, it counts terminated records as line numbers do.
+	Lines int
+
+	// CommentLines and BlankLines are the numbers of comment and blank
+	// lines skipped. Blank lines read as records or errors because of
+	// BlankLineMode are not counted.
+	CommentLines int
+	BlankLines   int
+
+	// BytesRead is the number of bytes consumed, as given by InputOffset.
+	BytesRead int64
+
+	// MalformedSkipped is the number of invalid records skipped by
+	// ReadAllLenient.
+	MalformedSkipped int
+}
+
+// Stats returns counters of the input read since the Reader was created
+// or last Reset.
+func (r *Reader) Stats() ReaderStats {
+	return ReaderStats{
+		Records:          r.numRecords,
+		Lines:            r.linesRead,
+		CommentLines:     r.numComments,
+		BlankLines:       r.numBlank,
+		BytesRead:        r.InputOffset(),
+		MalformedSkipped: r.numSkipped,
+	}
+}
+
+// repair records a repair made by RepairQuotes, described by the error it
+// avoided.
+func (r *Reader) repair(recLine, line, col int, err error) {
+	r.numRepairs++
+	if r.OnRepair != nil {
+		r.OnRepair(&ParseError{StartLine: recLine, Line: line, Column: col, Err: err})
+	}
+}
+
+// LastRecordNull reports for each field of the record most recently
+// returned by Read or ReadBytes whether it was NULL in the input, as \N
+// is with DecodeBackslashEscapes and an unquoted empty field is with
+// NullIfUnquotedEmpty. NULL fields are returned as empty strings.
+func (r *Reader) LastRecordNull() []bool {
+	return append([]bool(nil), r.fieldNull...)
+}
+
+// ReadPtr is like Read but returns each NULL field, as reported by
+// LastRecordNull, as nil and the other fields as pointers to their value.
+// If ReuseRecord is true, the returned slice and the strings it points to
+// may be shared between multiple calls to ReadPtr.
+func (r *Reader) ReadPtr() (record []*string, err error) {
+	var fields []string
+	if r.ReuseRecord {
+		fields, err = r.readRecord(r.lastRecord)
+		r.lastRecord = fields
+		record = r.lastPtrs[:0]
+	} else {
+		fields, err = r.readRecord(nil)
+	}
+	if fields == nil {
+		return nil, err
+	}
+	for i := range fields {
+		if i < len(r.fieldNull) && r.fieldNull[i] {
+			record = append(record, nil)
+		} else {
+			record = append(record, &fields[i])
+		}
+	}
+	if r.ReuseRecord {
+		r.lastPtrs = record
+	}
+	return record, err
+}
+
+// Unterminated returns a ParseError with Err set to ErrUnterminatedField,
+// giving the position of the end of the input, if the record most
+// recently read ended inside a quoted field accepted by
+// AllowUnterminatedQuote or LazyQuotes, and nil otherwise. The record
+// itself is returned by Read without an error, since it may be all that
+// is left of a truncated file. The error is kept when the following read
+// reaches the end of the input, so that it can be checked after ReadAll.
+func (r *Reader) Unterminated() error {
+	if r.unterminated == nil {
+		return nil // Not a nil *ParseError
+	}
+	return r.unterminated
+}
+
+// FieldCount returns the number of fields the record most recently read
+// had in the input, before OnShortRow or OnLongRow padded or truncated it.
+func (r *Reader) FieldCount() int {
+	return r.numFields
+}
+
 // InputOffset returns the input stream byte offset of the current reader
 // position. The offset gives the location of the end of the most recently
 // read row and the beginning of the next row.
+//
+// The offset counts every byte consumed from the underlying io.Reader,
+// including line terminators, comment lines, and all physical lines of a
+// multi-line record, so a new Reader with the same options started at the
+// offset of a seekable source resumes parsing at the next record.
 func (r *Reader) InputOffset() int64 {
+	if r.dec != nil {
+		return r.dec.srcOffset(r.offset)
+	}
 	return r.offset
 }
 
+// RecordOffset returns the input stream byte offset of the start of the
+// record most recently read, after any comment and blank lines before it.
+// The offset counts bytes as InputOffset does, so a new Reader with the
+// same options started at the offset of a seekable source reads the same
+// record again.
+func (r *Reader) RecordOffset() int64 {
+	if r.dec != nil {
+		return r.dec.srcOffset(r.recordOffset)
+	}
+	return r.recordOffset
+}
+
 // pos holds the position of a field in the current line.
 type position struct {
 	line, col int
@@ -252,30 +1289,124 @@ func (r *Reader) ReadAll() (records [][]string, err error) {
 	}
 }
 
+// ReadN reads at most n of the remaining records from r, stopping early
+// at end of file, which is not reported as an error. Skipped comment and
+// blank lines do not count toward n. The rest of the input is left unread,
+// so that reading can continue after a sample has been taken.
+// If n is not positive, ReadN reads nothing and returns nil.
+func (r *Reader) ReadN(n int) (records [][]string, err error) {
+	for len(records) < n {
+		record, err := r.readRecord(nil)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// stripBOM discards a UTF-8 byte order mark at the start of the input
+// if StripBOM is set.
+func (r *Reader) stripBOM() error {
+	if !r.StripBOM || r.bomChecked {
+		return nil
+	}
+	b, err := r.r.Peek(3)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch {
+	case bytes.HasPrefix(b, []byte("\xef\xbb\xbf")):
+		r.r.Discard(3)
+		r.offset += 3
+	case bytes.HasPrefix(b, []byte("\xff\xfe")), bytes.HasPrefix(b, []byte("\xfe\xff")):
+		return ErrUTF16
+	}
+	r.bomChecked = true
+	return nil
+}
+
+// skipRows discards the first SkipRows records.
+func (r *Reader) skipRows() error {
+	if r.skippedRows {
+		return nil
+	}
+	r.skippedRows = true
+	fields, noCount := r.FieldsPerRecord, r.noCount
+	r.FieldsPerRecord, r.noCount = -1, true
+	defer func() { r.FieldsPerRecord, r.noCount = fields, noCount }()
+	for i := 0; i < r.SkipRows; i++ {
+		_, err := r.parseRecord()
+		if err == io.EOF {
+			return nil
+		}
+		if _, ok := err.(*ParseError); err != nil && !ok {
+			return err
+		}
+	}
+	return nil
+}
+
 // readLine reads the next line (with the trailing endline).
 // If EOF is hit without a trailing endline, it will be omitted.
 // If some bytes were read, then the error is never io.EOF.
 // The result is only valid until the next call to readLine.
 func (r *Reader) readLine() ([]byte, error) {
-	line, err := r.r.ReadSlice('\n')
-	if err == bufio.ErrBufferFull {
-		r.rawBuffer = append(r.rawBuffer[:0], line...)
-		for err == bufio.ErrBufferFull {
-			line, err = r.r.ReadSlice('\n')
-			r.rawBuffer = append(r.rawBuffer, line...)
+	var line []byte
+	var dropped int // Bytes of an overlong line read but not kept
+	var err error
+	if r.Terminator != "" {
+		line, dropped, err = r.readSlice(len(r.term) - 1)
+	} else if r.acceptCR {
+		line, dropped, err = r.readSlice(1)
+	} else {
+		line, err = r.r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			r.presizeLine()
+			r.rawBuffer = append(r.rawBuffer[:0], line...)
+			for err == bufio.ErrBufferFull {
+				line, err = r.r.ReadSlice('\n')
+				if r.MaxRecordBytes > 0 && len(r.rawBuffer) > r.MaxRecordBytes {
+					dropped += len(line)
+				} else {
+					r.rawBuffer = append(r.rawBuffer, line...)
+				}
+			}
+			line = r.rawBuffer
 		}
-		line = r.rawBuffer
 	}
 	readSize := len(line)
+	if r.keepRaw || r.OnError != nil {
+		r.rawRecord = append(r.rawRecord, line...)
+	}
 	if readSize > 0 && err == io.EOF {
 		err = nil
 		// For backwards compatibility, drop trailing \r before EOF.
-		if line[readSize-1] == '\r' {
+		if line[readSize-1] == '\r' && r.Terminator == "" {
 			line = line[:readSize-1]
 		}
 	}
+	r.bareLF = 0
+	if n := len(line); n > 0 && line[n-1] == '\n' && (n == 1 || line[n-2] != '\r') {
+		r.bareLF = n
+	}
+	if r.dec != nil || r.data != nil {
+		r.lineStarts = append(r.lineStarts, r.offset)
+	}
+	if r.ErrorSnippets {
+		r.keepSnippetLine(line)
+	}
 	r.numLine++
-	r.offset += int64(readSize)
+	if readSize+dropped > 0 {
+		r.linesRead++
+	}
+	r.offset += int64(readSize + dropped)
+	if r.Terminator != "" {
+		return line, err
+	}
 	// Normalize \r\n to \n on all input lines.
 	if n := len(line); n >= 2 && line[n-2] == '\r' && line[n-1] == '\n' {
 		line[n-2] = '\n'
@@ -284,133 +1415,1196 @@ func (r *Reader) readLine() ([]byte, error) {
 	return line, err
 }
 
-// lengthNL reports the number of bytes for the trailing \n.
-func lengthNL(b []byte) int {
-	if len(b) > 0 && b[len(b)-1] == '\n' {
-		return 1
+const (
+	snippetKeep = 64 << 10 // Bytes of each line kept for ErrorSnippets
+	snippetSize = 256      // Bytes of a line shown in a Snippet
+)
+
+// keepSnippetLine copies the start of line to snippetLines, reusing the
+// buffers of earlier records.
+func (r *Reader) keepSnippetLine(line []byte) {
+	var buf []byte
+	if n := len(r.snippetLines); n < cap(r.snippetLines) {
+		buf = r.snippetLines[:n+1][n][:0]
 	}
-	return 0
+	r.snippetLines = append(r.snippetLines, append(buf, line[:min(len(line), snippetKeep)]...))
 }
 
-// nextRune returns the next rune in b or utf8.RuneError.
+// snippet returns the Snippet of a ParseError at the 1-based byte column
+// col of line.
+func (r *Reader) snippet(line []byte, col int) string {
+	if r.Terminator != "" {
+		line = bytes.TrimSuffix(line, r.term)
+	} else {
+		line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+	}
+	i := min(max(col-1, 0), len(line))
+	start := max(0, i-snippetSize/2)
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	end := min(len(line), start+snippetSize)
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	b.Write(line[start:end])
+	if end < len(line) {
+		b.WriteString("...")
+	}
+	b.WriteByte('\n')
+	if start > 0 {
+		b.WriteString("   ")
+	}
+	for _, c := range string(line[start:min(i, end)]) {
+		if c == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteByte('^')
+	return b.String()
+}
+
+// readSlice reads up to and including the first line terminator found by
+// indexEnd, accumulating the line in rawBuffer. overlap is the number of
+// trailing bytes indexEnd needs to see again once more input is available.
+// At EOF, readSlice returns whatever was read along with the error.
+// Past MaxRecordBytes, the middle of the line is dropped to bound memory;
+// readSlice also returns the number of bytes dropped.
+func (r *Reader) readSlice(overlap int) ([]byte, int, error) {
+	r.presizeLine()
+	r.rawBuffer = r.rawBuffer[:0]
+	dropped := 0
+	for {
+		n := r.r.Buffered()
+		if n == 0 {
+			if _, err := r.r.Peek(1); err != nil {
+				return r.rawBuffer, dropped, err
+			}
+			n = r.r.Buffered()
+		}
+		buf, _ := r.r.Peek(n)
+		from := max(0, len(r.rawBuffer)-overlap)
+		r.rawBuffer = append(r.rawBuffer, buf...)
+		if i := r.indexEnd(r.rawBuffer[from:]); i >= 0 {
+			i += from
+			r.r.Discard(n - (len(r.rawBuffer) - i))
+			r.rawBuffer = r.rawBuffer[:i]
+			return r.rawBuffer, dropped, nil
+		}
+		r.r.Discard(n)
+		if keep := r.MaxRecordBytes; keep > 0 && len(r.rawBuffer) > keep+overlap {
+			// Keep the start and the bytes indexEnd must see again.
+			dropped += len(r.rawBuffer) - keep - overlap
+			r.rawBuffer = append(r.rawBuffer[:keep], r.rawBuffer[len(r.rawBuffer)-overlap:]...)
+		}
+	}
+}
+
+// indexEnd returns the index just past the first line terminator in b,
+// or -1 if there is none. Without a Terminator, lines end in \n, \r\n or
+// a lone \r, and a trailing \r is not yet known to be complete.
+func (r *Reader) indexEnd(b []byte) int {
+	if r.Terminator != "" {
+		if i := bytes.Index(b, r.term); i >= 0 {
+			return i + len(r.term)
+		}
+		return -1
+	}
+	i := bytes.IndexAny(b, "\r\n")
+	switch {
+	case i < 0:
+		return -1
+	case b[i] == '\n':
+		return i + 1
+	case i+1 == len(b):
+		return -1 // Cannot tell \r from \r\n yet.
+	case b[i+1] == '\n':
+		return i + 2
+	}
+	return i + 1
+}
+
+// lengthNL reports the number of bytes for the trailing \n,
+// or a trailing \r if AcceptCR is set, or the trailing Terminator.
+func (r *Reader) lengthNL(b []byte) int {
+	if r.Terminator != "" {
+		if bytes.HasSuffix(b, r.term) {
+			return len(r.term)
+		}
+		return 0
+	}
+	if len(b) > 0 && (b[len(b)-1] == '\n' || (r.acceptCR && b[len(b)-1] == '\r')) {
+		return 1
+	}
+	return 0
+}
+
+// hasPrefix is bytes.HasPrefix with a shortcut for the single-byte
+// delimiters and quotes of most inputs.
+func hasPrefix(b, prefix []byte) bool {
+	if len(prefix) == 1 {
+		return len(b) > 0 && b[0] == prefix[0]
+	}
+	return bytes.HasPrefix(b, prefix)
+}
+
+// nextRune returns the next rune in b or utf8.RuneError.
 func nextRune(b []byte) rune {
 	r, _ := utf8.DecodeRune(b)
 	return r
 }
 
+// firstRune returns the first rune in s or utf8.RuneError.
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+// indexSep returns the index of the first field delimiter in line that
+// is not escaped, or -1 if there is none.
+func (r *Reader) indexSep(line []byte) int {
+	if r.Whitespace {
+		if r.escape == 0 {
+			return bytes.IndexAny(line, " \t")
+		}
+		i, j := r.indexUnescaped(line, r.sep), r.indexUnescaped(line, []byte{'\t'})
+		if i < 0 || j >= 0 && j < i {
+			return j
+		}
+		return i
+	}
+	if len(r.sep) == 1 && r.escape == 0 {
+		return bytes.IndexByte(line, r.sep[0])
+	}
+	return r.indexUnescaped(line, r.sep)
+}
+
+// hasSep reports whether line starts with a field delimiter.
+func (r *Reader) hasSep(line []byte) bool {
+	return hasPrefix(line, r.sep) || r.Whitespace && len(line) > 0 && line[0] == '\t'
+}
+
+// isBlank reports whether line is a blank line, one holding nothing but
+// its line terminator or, with Whitespace, spaces and tabs.
+func (r *Reader) isBlank(line []byte) bool {
+	if r.Whitespace {
+		line = bytes.TrimLeft(line, " \t")
+	}
+	return len(line) == r.lengthNL(line)
+}
+
+// indexComment returns the index of the first inline comment in line,
+// or -1 if there is none or inline comments are disabled.
+func (r *Reader) indexComment(line []byte) int {
+	if len(r.comment) == 0 {
+		return -1
+	}
+	return r.indexUnescaped(line, r.comment)
+}
+
+// isComment reports whether b starts with an inline comment.
+func (r *Reader) isComment(b []byte) bool {
+	return len(r.comment) > 0 && bytes.HasPrefix(b, r.comment)
+}
+
+// indexUnescaped returns the index of the first instance of sub in line
+// that is not escaped, or -1 if there is none.
+func (r *Reader) indexUnescaped(line, sub []byte) int {
+	if r.escape == 0 {
+		return bytes.Index(line, sub)
+	}
+	escLen := utf8.RuneLen(r.escape)
+	for off := 0; ; {
+		i := bytes.Index(line[off:], sub)
+		j := bytes.IndexRune(line[off:], r.escape)
+		if j < 0 || (i >= 0 && i < j) {
+			if i < 0 {
+				return -1
+			}
+			return off + i
+		}
+		off += j + escLen
+		if off >= len(line) {
+			return -1
+		}
+		_, size := utf8.DecodeRune(line[off:])
+		off += size
+	}
+}
+
+// indexQuote returns the index of the first closing quote, escape or quote escape
+// character in line, or -1 if there is none.
+func (r *Reader) indexQuote(line []byte) int {
+	if len(r.closeQuote) == 1 && r.escape == 0 && len(r.qescape) == 0 {
+		return bytes.IndexByte(line, r.closeQuote[0])
+	}
+	i := bytes.Index(line, r.closeQuote)
+	if r.escape != 0 {
+		if j := bytes.IndexRune(line, r.escape); j >= 0 && (i < 0 || j < i) {
+			return j
+		}
+	}
+	if len(r.qescape) > 0 {
+		if j := bytes.Index(line, r.qescape); j >= 0 && (i < 0 || j < i) {
+			return j
+		}
+	}
+	return i
+}
+
+// indexBareQuote returns the index of the first quote in the unquoted
+// field, or -1 if there is none or quoting is disabled.
+func (r *Reader) indexBareQuote(field []byte) int {
+	switch len(r.quote) {
+	case 0:
+		return -1
+	case 1:
+		return bytes.IndexByte(field, r.quote[0])
+	}
+	return bytes.Index(field, r.quote)
+}
+
+// appendField appends the unquoted field to dst, resolving any escape
+// sequences. It also returns the index in field of the first unescaped
+// quote, or -1, and whether field ends in a dangling escape character.
+func (r *Reader) appendField(dst, field []byte) ([]byte, int, bool) {
+	if r.escape == 0 {
+		return r.appendRaw(dst, field), r.indexBareQuote(field), false
+	}
+	escLen := utf8.RuneLen(r.escape)
+	quote := -1
+	for off := 0; ; {
+		seg := field[off:]
+		j := bytes.IndexRune(seg, r.escape)
+		if j >= 0 {
+			seg = seg[:j]
+		}
+		if k := r.indexBareQuote(seg); k >= 0 && quote < 0 {
+			quote = off + k
+		}
+		dst = r.appendRaw(dst, seg)
+		if j < 0 {
+			return dst, quote, false
+		}
+		off += j + escLen
+		if off >= len(field) {
+			return dst, quote, true
+		}
+		_, size := utf8.DecodeRune(field[off:])
+		dst = r.appendEscaped(dst, field[off:off+size])
+		off += size
+	}
+}
+
+// appendRaw appends the unescaped field data b to dst, removing or
+// converting bare carriage returns as set by BareCR and those of \r\n
+// if NormalizeLineEndings is set.
+func (r *Reader) appendRaw(dst, b []byte) []byte {
+	if r.BareCR != BareCRStrip && r.BareCR != BareCRNewline && !r.NormalizeLineEndings {
+		return append(dst, b...)
+	}
+	for {
+		i := bytes.IndexByte(b, '\r')
+		if i < 0 {
+			return append(dst, b...)
+		}
+		dst = append(dst, b[:i]...)
+		switch {
+		case i+1 < len(b) && b[i+1] == '\n':
+			if !r.NormalizeLineEndings {
+				dst = append(dst, '\r') // Not bare; keep it.
+			}
+		case r.BareCR == BareCRNewline:
+			dst = append(dst, '\n')
+		case r.BareCR != BareCRStrip:
+			dst = append(dst, '\r')
+		}
+		b = b[i+1:]
+	}
+}
+
+// appendQuoted appends the data b of the quoted field, which starts at pos,
+// to recordBuffer. It returns an ErrBareCR error if b holds a bare carriage
+// return and BareCR is BareCRError, an ErrNUL error if b holds a NUL
+// byte and RejectNUL is set, and an ErrInvalidUTF8 error if b is not
+// valid UTF-8 and InvalidUTF8 is InvalidUTF8Error.
+func (r *Reader) appendQuoted(b []byte, pos position, recLine int) error {
+	if r.BareCR == BareCRError {
+		if i := r.indexBareCR(b); i >= 0 {
+			return &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col + i, Err: ErrBareCR}
+		}
+	}
+	if r.RejectNUL {
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			return &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col + i, Err: ErrNUL}
+		}
+	}
+	if r.InvalidUTF8 == InvalidUTF8Error {
+		if i := indexInvalidUTF8(b); i >= 0 {
+			return &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col + i, Err: ErrInvalidUTF8}
+		}
+	}
+	r.recordBuffer = r.appendRaw(r.recordBuffer, b)
+	return nil
+}
+
+// indexBareCR returns the index of the first unescaped carriage return
+// in b that is not followed by a newline, or -1 if there is none.
+func (r *Reader) indexBareCR(b []byte) int {
+	for off := 0; ; off++ {
+		i := r.indexUnescaped(b[off:], []byte{'\r'})
+		if i < 0 {
+			return -1
+		}
+		off += i
+		if off+1 == len(b) || b[off+1] != '\n' {
+			return off
+		}
+	}
+}
+
+// dropTrailingField removes the last field of the record just parsed if
+// it is unquoted and empty and follows another field, for
+// AllowTrailingComma. A field read as the Null token or as \N, which
+// nullField still reports, is kept.
+func (r *Reader) dropTrailingField() {
+	n := len(r.fieldIndexes)
+	if n < 2 || r.fieldIndexes[n-1] != r.fieldIndexes[n-2] || r.fieldQuoted[n-1] || r.nullField {
+		return
+	}
+	r.fieldIndexes = r.fieldIndexes[:n-1]
+	r.fieldPositions = r.fieldPositions[:n-1]
+	r.fieldQuoted = r.fieldQuoted[:n-1]
+	r.fieldNull = r.fieldNull[:n-1]
+}
+
+// checkFieldSize returns an ErrFieldTooLarge error if the field starting
+// at offset start in recordBuffer and at pos in the input is longer than
+// MaxFieldSize.
+func (r *Reader) checkFieldSize(start int, pos position, recLine int) error {
+	if r.MaxFieldSize > 0 && len(r.recordBuffer)-start > r.MaxFieldSize {
+		return &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col, Err: ErrFieldTooLarge}
+	}
+	return nil
+}
+
+// checkRecordSize returns an ErrRecordTooLarge error if the record
+// starting at input offset start is longer than MaxRecordBytes.
+func (r *Reader) checkRecordSize(start int64, recLine int) error {
+	if r.MaxRecordBytes > 0 && r.offset-start > int64(r.MaxRecordBytes) {
+		return &ParseError{StartLine: recLine, Line: r.numLine, Column: 1,
+			Err: &LimitError{Err: ErrRecordTooLarge, Limit: r.MaxRecordBytes}}
+	}
+	return nil
+}
+
+// endField records the end of the field starting at offset start in
+// recordBuffer and at pos in the input, after checking the limits on the
+// field size and the number of fields.
+func (r *Reader) endField(start int, pos position, recLine int) error {
+	if err := r.checkFieldSize(start, pos, recLine); err != nil {
+		return err
+	}
+	if r.UnescapeNewlines && !r.DecodeBackslashEscapes {
+		r.recordBuffer = unescapeNewlines(r.recordBuffer, start)
+	}
+	if r.InvalidUTF8 == InvalidUTF8Replace {
+		r.recordBuffer = replaceInvalidUTF8(r.recordBuffer, start)
+	}
+	if r.MaxColumns > 0 && len(r.fieldIndexes) == r.MaxColumns {
+		return &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col,
+			Err: &LimitError{Err: ErrTooManyFields, Limit: r.MaxColumns}}
+	}
+	r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer))
+	r.fieldPositions = append(r.fieldPositions, pos)
+	r.fieldQuoted = append(r.fieldQuoted, r.quotedField)
+	r.fieldNull = append(r.fieldNull, r.nullField || r.NullIfUnquotedEmpty && !r.quotedField && len(r.recordBuffer) == start)
+	return nil
+}
+
+// indexInvalidUTF8 returns the index of the first byte of b that is not
+// part of a valid UTF-8 sequence, or -1 if b is valid UTF-8.
+func indexInvalidUTF8(b []byte) int {
+	if utf8.Valid(b) {
+		return -1
+	}
+	for i := 0; i < len(b); {
+		c, size := utf8.DecodeRune(b[i:])
+		if c == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// replaceInvalidUTF8 replaces each byte of b[start:] that is not part of
+// a valid UTF-8 sequence with U+FFFD.
+func replaceInvalidUTF8(b []byte, start int) []byte {
+	field := b[start:]
+	if utf8.Valid(field) {
+		return b
+	}
+	fixed := make([]byte, 0, len(field)+2*utf8.UTFMax)
+	for len(field) > 0 {
+		c, size := utf8.DecodeRune(field)
+		if c == utf8.RuneError && size == 1 {
+			fixed = utf8.AppendRune(fixed, utf8.RuneError)
+		} else {
+			fixed = append(fixed, field[:size]...)
+		}
+		field = field[size:]
+	}
+	return append(b[:start], fixed...)
+}
+
+// unescapeNewlines decodes the sequences of UnescapeNewlines in b[start:]
+// in place.
+func unescapeNewlines(b []byte, start int) []byte {
+	i := bytes.IndexByte(b[start:], '\\')
+	if i < 0 {
+		return b
+	}
+	w := start + i
+	for r := w; r < len(b); r++ {
+		c := b[r]
+		if c == '\\' && r+1 < len(b) {
+			switch b[r+1] {
+			case 'n':
+				c = '\n'
+				r++
+			case 'r':
+				c = '\r'
+				r++
+			case '\\':
+				r++
+			}
+		}
+		b[w] = c
+		w++
+	}
+	return b[:w]
+}
+
+// indexUnknownEscape returns the index of the first backslash in b that
+// escapes an ASCII letter or digit that DecodeBackslashEscapes does not
+// know, or -1 if there is none.
+func indexUnknownEscape(b []byte) int {
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] == '\\' {
+			if unknownEscape(b[i+1]) {
+				return i
+			}
+			i++
+		}
+	}
+	return -1
+}
+
+// unknownEscape reports whether c, following a backslash, is an ASCII
+// letter or digit that DecodeBackslashEscapes does not know.
+func unknownEscape(c byte) bool {
+	if c == 'n' || c == 't' || c == 'r' || c == '0' || c == 'N' {
+		return false
+	}
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// leniency returns opt, the name of an option that would have accepted
+// the input, or "" in Strict mode, where it would not.
+func (r *Reader) leniency(opt string) string {
+	if r.Strict {
+		return ""
+	}
+	return opt
+}
+
+// selectedColumns returns the columns of SelectNames, if set, or else
+// SelectColumns.
+func (r *Reader) selectedColumns() []int {
+	if len(r.SelectNames) > 0 {
+		return r.selected
+	}
+	return r.SelectColumns
+}
+
+// selectColumns keeps only the selected fields in the record. The
+// selected fields are appended after the record, then moved to its start.
+func (r *Reader) selectColumns() {
+	n, size := len(r.fieldIndexes), len(r.recordBuffer)
+	for _, c := range r.selectedColumns() {
+		missing := c >= n
+		if missing {
+			c = n - 1 // An empty field at the position of the last one
+		} else {
+			start := 0
+			if c > 0 {
+				start = r.fieldIndexes[c-1]
+			}
+			r.recordBuffer = append(r.recordBuffer, r.recordBuffer[start:r.fieldIndexes[c]]...)
+		}
+		r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer)-size)
+		r.fieldPositions = append(r.fieldPositions, r.fieldPositions[c])
+		r.fieldQuoted = append(r.fieldQuoted, r.fieldQuoted[c] && !missing)
+		r.fieldNull = append(r.fieldNull, r.fieldNull[c] && !missing || missing && r.NullIfUnquotedEmpty)
+	}
+	r.recordBuffer = r.recordBuffer[:copy(r.recordBuffer, r.recordBuffer[size:])]
+	r.fieldIndexes = r.fieldIndexes[:copy(r.fieldIndexes, r.fieldIndexes[n:])]
+	r.fieldPositions = r.fieldPositions[:copy(r.fieldPositions, r.fieldPositions[n:])]
+	r.fieldQuoted = r.fieldQuoted[:copy(r.fieldQuoted, r.fieldQuoted[n:])]
+	r.fieldNull = r.fieldNull[:copy(r.fieldNull, r.fieldNull[n:])]
+}
+
+// fitFieldCount pads or truncates a record whose number of fields differs
+// from FieldsPerRecord as OnShortRow or OnLongRow ask. It reports whether
+// the record is accepted.
+func (r *Reader) fitFieldCount() bool {
+	n, want := len(r.fieldIndexes), r.FieldsPerRecord
+	switch {
+	case n < want && r.onShortRow == ShortRowPad:
+		end, pos := len(r.recordBuffer), r.fieldPositions[n-1]
+		for len(r.fieldIndexes) < want {
+			r.fieldIndexes = append(r.fieldIndexes, end)
+			r.fieldPositions = append(r.fieldPositions, pos)
+			r.fieldQuoted = append(r.fieldQuoted, false)
+			r.fieldNull = append(r.fieldNull, r.NullIfUnquotedEmpty)
+		}
+	case n > want && r.onLongRow == LongRowTruncate:
+		r.recordBuffer = r.recordBuffer[:r.fieldIndexes[want-1]]
+		r.fieldIndexes = r.fieldIndexes[:want]
+		r.fieldPositions = r.fieldPositions[:want]
+		r.fieldQuoted = r.fieldQuoted[:want]
+		r.fieldNull = r.fieldNull[:want]
+	case n < want && r.onShortRow == ShortRowKeep, n > want && r.onLongRow == LongRowKeep:
+	default:
+		return false
+	}
+	return true
+}
+
+// trimRightSpace removes trailing white space from the unquoted field,
+// keeping a white space character that is escaped.
+func (r *Reader) trimRightSpace(field []byte) []byte {
+	t := bytes.TrimRightFunc(field, unicode.IsSpace)
+	if r.escape == 0 || len(t) == len(field) {
+		return t
+	}
+	n := 0
+	for b := t; len(b) > 0; n++ {
+		c, size := utf8.DecodeLastRune(b)
+		if c != r.escape {
+			break
+		}
+		b = b[:len(b)-size]
+	}
+	if n%2 == 1 {
+		_, size := utf8.DecodeRune(field[len(t):])
+		t = field[:len(t)+size]
+	}
+	return t
+}
+
+// spaceBeforeEnd returns the length of the white space at the start of
+// line if it is followed by an inline comment or, with TrimSpace, by a
+// delimiter or the end of the line. Otherwise it returns -1.
+func (r *Reader) spaceBeforeEnd(line []byte) int {
+	for k := 0; k < len(line); {
+		if r.isComment(line[k:]) {
+			return k
+		}
+		if r.TrimSpace && (r.hasSep(line[k:]) || r.lengthNL(line[k:]) == len(line)-k) {
+			return k
+		}
+		c, size := utf8.DecodeRune(line[k:])
+		if !unicode.IsSpace(c) {
+			return -1
+		}
+		k += size
+	}
+	if r.TrimSpace {
+		return len(line)
+	}
+	return -1
+}
+
+// appendEscaped appends the escaped character c to dst,
+// decoding it if DecodeEscapes is set.
+func (r *Reader) appendEscaped(dst, c []byte) []byte {
+	if r.decodeEscapes && len(c) == 1 {
+		switch c[0] {
+		case 'n':
+			return append(dst, '\n')
+		case 't':
+			return append(dst, '\t')
+		case 'r':
+			return append(dst, '\r')
+		case '0':
+			return append(dst, 0)
+		}
+	}
+	return append(dst, c...)
+}
+
+// quoteRunes returns the opening and closing quote characters, which
+// are 0 if quoting is disabled.
+func (r *Reader) quoteRunes() (open, close rune) {
+	open, close = r.Quote, r.Quote
+	if r.QuoteOpen != 0 {
+		open = r.QuoteOpen
+	}
+	if r.QuoteClose != 0 {
+		close = r.QuoteClose
+	}
+	return
+}
+
+// isQuote reports whether c is the opening or closing quote character.
+func (r *Reader) isQuote(c rune) bool {
+	return c != 0 && (bytes.ContainsRune(r.quote, c) || bytes.ContainsRune(r.closeQuote, c))
+}
+
+// containsQuote reports whether b contains the opening or closing quote
+// character.
+func (r *Reader) containsQuote(b []byte) bool {
+	return len(r.quote) > 0 && (bytes.Contains(b, r.quote) || bytes.Contains(b, r.closeQuote))
+}
+
+// setup validates the options of r and encodes the delimiters
+// for the record about to be read.
+func (r *Reader) setup() error {
+	r.escape, r.decodeEscapes = r.Escape, r.DecodeEscapes
+	if r.DecodeBackslashEscapes {
+		r.escape, r.decodeEscapes = '\\', true
+	}
+	r.lazyQuotes, r.allowBareQuotes, r.allowTextAfterClosingQuote = r.LazyQuotes, r.AllowBareQuotes, r.AllowTextAfterClosingQuote
+	r.allowUnterminatedQuote, r.relaxedOpenQuote, r.repairQuotes = r.AllowUnterminatedQuote, r.RelaxedOpenQuote, r.RepairQuotes
+	r.acceptCR, r.onShortRow, r.onLongRow = r.AcceptCR, r.OnShortRow, r.OnLongRow
+	if r.Strict {
+		r.lazyQuotes, r.allowBareQuotes, r.allowTextAfterClosingQuote = false, false, false
+		r.allowUnterminatedQuote, r.relaxedOpenQuote, r.repairQuotes = false, false, false
+		r.acceptCR, r.onShortRow, r.onLongRow = false, ShortRowError, LongRowError
+	}
+	if r.Whitespace {
+		if r.Comment == ' ' || r.Comment == '\t' || r.escape == '\t' || r.isQuote('\t') {
+			return errInvalidDelim
+		}
+		r.sep = append(r.sep[:0], ' ')
+	} else if r.Separator != "" {
+		if !validSeparator(r.Separator) || (r.Comment != 0 && firstRune(r.Separator) == r.Comment) {
+			return errInvalidDelim
+		}
+		r.sep = append(r.sep[:0], r.Separator...)
+	} else {
+		if r.Comma == r.Comment || !validDelim(r.Comma) {
+			return errInvalidDelim
+		}
+		r.sep = utf8.AppendRune(r.sep[:0], r.Comma)
+	}
+	if r.Comment != 0 && !validDelim(r.Comment) {
+		return errInvalidDelim
+	}
+	r.quote, r.closeQuote = r.quote[:0], r.closeQuote[:0]
+	qopen, qclose := r.quoteRunes()
+	if qopen != 0 || qclose != 0 {
+		for _, q := range [2]rune{qopen, qclose} {
+			if q == 0 || !validQuote(q) || q == r.Comment || bytes.ContainsRune(r.sep, q) {
+				return errInvalidDelim
+			}
+		}
+		r.quote = utf8.AppendRune(r.quote, qopen)
+		r.closeQuote = utf8.AppendRune(r.closeQuote, qclose)
+	}
+	if r.escape != 0 && (!validDelim(r.escape) || r.escape == r.Comment || r.isQuote(r.escape) || bytes.ContainsRune(r.sep, r.escape)) {
+		return errInvalidDelim
+	}
+	r.qescape = r.qescape[:0]
+	if r.QuoteEscape != 0 {
+		if !validDelim(r.QuoteEscape) || r.isQuote(r.QuoteEscape) || r.QuoteEscape == r.Comment ||
+			bytes.ContainsRune(r.sep, r.QuoteEscape) || r.escape != 0 {
+			return errInvalidDelim
+		}
+		r.qescape = utf8.AppendRune(r.qescape, r.QuoteEscape)
+	}
+	if r.DoubledDelimiterEscape && (r.CollapseDelimiters || r.Whitespace) {
+		return errInvalidDelim
+	}
+	for _, c := range r.SelectColumns {
+		if c < 0 {
+			return errSelectColumns
+		}
+	}
+	r.comment = r.comment[:0]
+	if r.InlineComments && r.Comment != 0 {
+		r.comment = utf8.AppendRune(r.comment, r.Comment)
+	}
+	if r.Terminator != "" {
+		r.term = append(r.term[:0], r.Terminator...)
+		if !utf8.Valid(r.term) || r.containsQuote(r.term) || bytes.Contains(r.term, r.sep) ||
+			(r.escape != 0 && bytes.ContainsRune(r.term, r.escape)) {
+			return errInvalidDelim
+		}
+	}
+	r.plain = len(r.sep) == 1 && len(r.quote) <= 1 && len(r.comment) == 0 && r.escape == 0 &&
+		!r.CollapseDelimiters && !r.Whitespace && !r.DoubledDelimiterEscape && !r.TrimLeadingSpace && !r.TrimSpace &&
+		r.BareCR == BareCRKeep && !r.NormalizeLineEndings && !r.Strict && !r.RejectNUL && !r.DecodeBackslashEscapes && r.Null == "" &&
+		r.InvalidUTF8 != InvalidUTF8Error
+	return nil
+}
+
+// presize grows the record buffer to InitialRecordBuffer and the
+// per-field buffers to InitialFieldCount. The line buffer is grown by
+// presizeLine once a line turns out not to fit the input buffer.
+func (r *Reader) presize() {
+	if n := r.InitialRecordBuffer; n > cap(r.recordBuffer) {
+		r.recordBuffer = make([]byte, 0, n)
+	}
+	if n := r.InitialFieldCount; n > cap(r.fieldIndexes) {
+		r.fieldIndexes = make([]int, 0, n)
+		r.fieldPositions = make([]position, 0, n)
+		r.fieldQuoted = make([]bool, 0, n)
+		r.fieldNull = make([]bool, 0, n)
+	}
+}
+
+// presizeLine grows rawBuffer to InitialRecordBuffer.
+func (r *Reader) presizeLine() {
+	if n := r.InitialRecordBuffer; n > cap(r.rawBuffer) {
+		r.rawBuffer = make([]byte, 0, n)
+	}
+}
+
 func (r *Reader) readRecord(dst []string) ([]string, error) {
-	if r.Comma == r.Comment || !validDelim(r.Comma) || (r.Comment != 0 && !validDelim(r.Comment)) {
-		return nil, errInvalidDelim
+	ok, err := r.nextRecord()
+	if !ok {
+		return nil, err
+	}
+
+	dst = dst[:0]
+	if cap(dst) < len(r.fieldIndexes) {
+		dst = make([]string, len(r.fieldIndexes))
+	}
+	dst = dst[:len(r.fieldIndexes)]
+	var preIdx int
+	if r.InternStrings {
+		for i, idx := range r.fieldIndexes {
+			dst[i] = r.intern(r.recordBuffer[preIdx:idx])
+			preIdx = idx
+		}
+		return dst, err
+	}
+	if r.data != nil && r.dec == nil {
+		r.aliasFields(dst)
+		return dst, err
+	}
+
+	// Create a single string and create slices out of it.
+	// This pins the memory of the fields together, but allocates once.
+	str := string(r.recordBuffer) // Convert to string once to batch allocations
+	for i, idx := range r.fieldIndexes {
+		dst[i] = str[preIdx:idx]
+		preIdx = idx
+	}
+	return dst, err
+}
+
+const (
+	maxInternLen = 64      // Fields at least this long are not interned
+	maxInterned  = 1 << 16 // Most distinct values kept for InternStrings
+)
+
+// intern returns field as a string, shared with the earlier fields
+// holding the same value if it is short enough to be interned.
+func (r *Reader) intern(field []byte) string {
+	if len(field) == 0 || len(field) >= maxInternLen {
+		return string(field)
+	}
+	if s, ok := r.interned[string(field)]; ok {
+		return s
 	}
+	s := string(field)
+	if len(r.interned) < maxInterned {
+		if r.interned == nil {
+			r.interned = make(map[string]string)
+		}
+		r.interned[s] = s
+	}
+	return s
+}
+
+// parseRecord parses the next record into recordBuffer and fieldIndexes.
+// It reports whether a record was read; if not, err explains why.
+// A record may be read along with a ParseError, as for Read.
+func (r *Reader) parseRecord() (bool, error) {
+	if r.closed {
+		return false, errClosed
+	}
+	if len(r.SelectNames) > 0 && !r.noCount {
+		if _, err := r.ReadHeader(); err != nil && r.header == nil {
+			return false, err
+		}
+		if r.selectErr != nil {
+			return false, r.selectErr
+		}
+	}
+	if err := r.setup(); err != nil {
+		return false, err
+	}
+	r.useDecoder()
+	if err := r.stripBOM(); err != nil {
+		return false, err
+	}
+	if err := r.skipRows(); err != nil {
+		return false, err
+	}
+	if r.MaxRecords > 0 && r.numRecords >= r.MaxRecords && !r.noCount {
+		return false, io.EOF
+	}
+	if r.dec != nil {
+		r.dec.discard(r.offset)
+	}
+
+	r.presize()
 
 	// Read line (automatically skipping past empty lines and any comments).
 	var line []byte
 	var errRead error
+	var recStart int64 // Input offset of the record
+	blank := false
 	for errRead == nil {
+		recStart = r.offset
+		r.lineStarts = r.lineStarts[:0]
+		r.snippetLines = r.snippetLines[:0]
+		r.rawRecord = r.rawRecord[:0]
 		line, errRead = r.readLine()
 		if r.Comment != 0 && nextRune(line) == r.Comment {
 			line = nil
+			r.numComments++
 			continue // Skip comment lines
 		}
-		if errRead == nil && len(line) == lengthNL(line) {
+		if len(r.comment) > 0 && r.isComment(bytes.TrimLeftFunc(line, unicode.IsSpace)) {
 			line = nil
-			continue // Skip empty lines
+			r.numComments++
+			continue // Skip lines holding only an inline comment
+		}
+		if errRead == nil && r.isBlank(line) {
+			switch r.BlankLineMode {
+			case BlankLineEmptyRecord:
+				blank = true // Parsed below as a single empty field
+			case BlankLineError:
+				return false, &ParseError{StartLine: r.numLine, Line: r.numLine, Column: 1, Err: ErrBlankLine}
+			default:
+				line = nil
+				r.numBlank++
+				continue // Skip empty lines
+			}
 		}
 		break
 	}
 	if errRead == io.EOF {
-		return nil, errRead
+		return false, errRead
 	}
+	r.unterminated = nil
+	r.recordOffset = recStart
+	r.resume.offset, r.resume.numLine, r.resume.linesRead = r.offset, r.numLine, r.linesRead
 
 	// Parse each field in the record.
 	var err error
-	const quoteLen = len(`"`)
-	commaLen := utf8.RuneLen(r.Comma)
+	quoteLen, closeLen := len(r.quote), len(r.closeQuote)
+	commaLen := len(r.sep)
 	recLine := r.numLine // Starting line for record
 	r.recordBuffer = r.recordBuffer[:0]
 	r.fieldIndexes = r.fieldIndexes[:0]
 	r.fieldPositions = r.fieldPositions[:0]
+	r.fieldQuoted = r.fieldQuoted[:0]
+	r.fieldNull = r.fieldNull[:0]
 	pos := position{line: r.numLine, col: 1}
+	if err = r.checkRecordSize(recStart, recLine); err != nil {
+		line = nil
+	}
 parseField:
-	for {
-		if r.TrimLeadingSpace {
+	for err == nil {
+		if r.CollapseDelimiters || r.Whitespace {
+			for r.hasSep(line) {
+				line = line[commaLen:]
+				pos.col += commaLen
+			}
+			if len(r.fieldIndexes) > 0 && r.lengthNL(line) == len(line) {
+				break parseField // Trailing delimiters
+			}
+		}
+		if r.TrimLeadingSpace || r.TrimSpace {
 			i := bytes.IndexFunc(line, func(r rune) bool {
 				return !unicode.IsSpace(r)
 			})
 			if i < 0 {
 				i = len(line)
-				pos.col -= lengthNL(line)
+				pos.col -= r.lengthNL(line)
 			}
 			line = line[i:]
 			pos.col += i
 		}
-		if len(line) == 0 || line[0] != '"' {
-			// Non-quoted string field
-			i := bytes.IndexRune(line, r.Comma)
+		fieldStart := len(r.recordBuffer)
+		r.quotedField = quoteLen > 0 && hasPrefix(line, r.quote)
+		relaxed := r.quotedField && r.relaxedOpenQuote && bytes.Index(line[quoteLen:], r.closeQuote) < 0
+		if relaxed {
+			r.quotedField = false // The opening quote is literal
+		}
+		r.nullField = false
+		if !r.quotedField && r.plain {
+			// Fast path: an unquoted field without a bare quote, taken
+			// as it is. Anything else is left to the general path below.
+			i := bytes.IndexByte(line, r.sep[0])
 			field := line
 			if i >= 0 {
 				field = field[:i]
 			} else {
-				field = field[:len(field)-lengthNL(field)]
+				field = field[:len(field)-r.lengthNL(field)]
 			}
-			// Check to make sure a quote does not appear in field.
-			if !r.LazyQuotes {
-				if j := bytes.IndexByte(field, '"'); j >= 0 {
-					col := pos.col + j
-					err = &ParseError{StartLine: recLine, Line: r.numLine, Column: col, Err: ErrBareQuote}
+			if quoteLen == 0 || bytes.IndexByte(field, r.quote[0]) < 0 {
+				r.recordBuffer = append(r.recordBuffer, field...)
+				if err = r.endField(fieldStart, pos, recLine); err != nil {
 					break parseField
 				}
-			}
-			r.recordBuffer = append(r.recordBuffer, field...)
-			r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer))
-			r.fieldPositions = append(r.fieldPositions, pos)
-			if i >= 0 {
-				line = line[i+commaLen:]
-				pos.col += i + commaLen
+				if i < 0 {
+					break parseField
+				}
+				line = line[i+1:]
+				pos.col += i + 1
 				continue parseField
 			}
-			break parseField
+		}
+		if !r.quotedField {
+			// Non-quoted string field
+			fieldPos := pos
+			for {
+				i := r.indexSep(line)
+				field := line
+				comment := false
+				if c := r.indexComment(line); c >= 0 && (i < 0 || c < i) {
+					i = -1
+					field = field[:c]
+					comment = true
+				}
+				if i >= 0 {
+					field = field[:i]
+				} else if !comment {
+					field = field[:len(field)-r.lengthNL(field)]
+				}
+				doubled := i >= 0 && r.DoubledDelimiterEscape && hasPrefix(line[i+commaLen:], r.sep)
+				if (r.TrimSpace || (comment && !r.KeepCommentSpace)) && !doubled {
+					field = r.trimRightSpace(field)
+				}
+				if r.BareCR == BareCRError || r.Strict {
+					if j := r.indexBareCR(field); j >= 0 {
+						err = &ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col + j, Err: ErrBareCR}
+						break parseField
+					}
+				}
+				if r.RejectNUL {
+					if j := bytes.IndexByte(field, 0); j >= 0 {
+						err = &ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col + j, Err: ErrNUL}
+						break parseField
+					}
+				}
+				if r.InvalidUTF8 == InvalidUTF8Error {
+					if j := indexInvalidUTF8(field); j >= 0 {
+						err = &ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col + j, Err: ErrInvalidUTF8}
+						break parseField
+					}
+				}
+				if r.DecodeBackslashEscapes && r.RejectUnknownEscapes {
+					if j := indexUnknownEscape(field); j >= 0 {
+						err = &ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col + j, Err: ErrUnknownEscape}
+						break parseField
+					}
+				}
+				var j int
+				var dangling bool
+				r.recordBuffer, j, dangling = r.appendField(r.recordBuffer, field)
+				if relaxed && j == 0 && pos == fieldPos {
+					if j = r.indexBareQuote(field[quoteLen:]); j >= 0 {
+						j += quoteLen
+					}
+				}
+				if r.DecodeBackslashEscapes && pos == fieldPos && string(field) == `\N` {
+					r.recordBuffer = r.recordBuffer[:fieldStart]
+					r.nullField = true
+				}
+				if r.Null != "" && pos == fieldPos && !doubled && !dangling && string(field) == r.Null {
+					r.recordBuffer = r.recordBuffer[:fieldStart]
+					r.nullField = true
+				}
+				// Check to make sure a quote does not appear in field.
+				if j >= 0 && !r.lazyQuotes && !r.allowBareQuotes {
+					col := pos.col + j
+					if !r.repairQuotes {
+						err = &ParseError{StartLine: recLine, Line: r.numLine, Column: col, Err: ErrBareQuote, Leniency: r.leniency("AllowBareQuotes")}
+						break parseField
+					}
+					r.repair(recLine, r.numLine, col, ErrBareQuote)
+				}
+				if err = r.checkFieldSize(fieldStart, fieldPos, recLine); err != nil {
+					break parseField
+				}
+				if dangling {
+					if r.lengthNL(line) == 0 {
+						col := pos.col + len(field) - utf8.RuneLen(r.escape)
+						err = &ParseError{StartLine: recLine, Line: r.numLine, Column: col, Err: ErrEscape}
+						break parseField
+					}
+					// Escaped line break; the field continues on the next line.
+					r.recordBuffer = append(r.recordBuffer, line[len(line)-r.lengthNL(line):]...)
+					line, errRead = r.readLine()
+					pos.line++
+					pos.col = 1
+					if errRead == io.EOF {
+						errRead = nil
+					}
+					if err = r.checkRecordSize(recStart, recLine); err != nil {
+						break parseField
+					}
+					continue
+				}
+				if doubled {
+					// Doubled delimiter; the field continues after it.
+					r.recordBuffer = append(r.recordBuffer, r.sep...)
+					line = line[i+2*commaLen:]
+					pos.col += i + 2*commaLen
+					continue
+				}
+				if err = r.endField(fieldStart, fieldPos, recLine); err != nil {
+					break parseField
+				}
+				if i >= 0 {
+					line = line[i+commaLen:]
+					pos.col += i + commaLen
+					continue parseField
+				}
+				break parseField
+			}
 		} else {
 			// Quoted string field
 			fieldPos := pos
 			line = line[quoteLen:]
 			pos.col += quoteLen
 			for {
-				i := bytes.IndexByte(line, '"')
-				if i >= 0 {
+				i := r.indexQuote(line)
+				if i >= 0 && len(r.qescape) > 0 && hasPrefix(line[i:], r.qescape) {
+					// Hit a quote escape; it escapes a following quote or
+					// quote escape and is taken literally otherwise.
+					if err = r.appendQuoted(line[:i], pos, recLine); err != nil {
+						break parseField
+					}
+					n := len(r.qescape)
+					if rest := line[i+n:]; hasPrefix(rest, r.closeQuote) || hasPrefix(rest, r.qescape) {
+						_, size := utf8.DecodeRune(rest)
+						r.recordBuffer = append(r.recordBuffer, rest[:size]...)
+						n += size
+					} else {
+						r.recordBuffer = append(r.recordBuffer, r.qescape...)
+					}
+					line = line[i+n:]
+					pos.col += i + n
+				} else if i >= 0 && !hasPrefix(line[i:], r.closeQuote) {
+					// Hit an escape; take the next character literally.
+					escLen := utf8.RuneLen(r.escape)
+					if err = r.appendQuoted(line[:i], pos, recLine); err != nil {
+						break parseField
+					}
+					line = line[i+escLen:]
+					pos.col += i + escLen
+					if len(line) == 0 {
+						err = &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col - escLen, Err: ErrEscape}
+						break parseField
+					}
+					if r.lengthNL(line) == len(line) {
+						continue // Escaped line break, kept as is below.
+					}
+					if r.DecodeBackslashEscapes && r.RejectUnknownEscapes && unknownEscape(line[0]) {
+						err = &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col - escLen, Err: ErrUnknownEscape}
+						break parseField
+					}
+					if r.RejectNUL && line[0] == 0 {
+						err = &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col, Err: ErrNUL}
+						break parseField
+					}
+					c, size := utf8.DecodeRune(line)
+					if r.InvalidUTF8 == InvalidUTF8Error && c == utf8.RuneError && size == 1 {
+						err = &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col, Err: ErrInvalidUTF8}
+						break parseField
+					}
+					r.recordBuffer = r.appendEscaped(r.recordBuffer, line[:size])
+					line = line[size:]
+					pos.col += size
+				} else if i >= 0 {
 					// Hit next quote.
-					r.recordBuffer = append(r.recordBuffer, line[:i]...)
-					line = line[i+quoteLen:]
-					pos.col += i + quoteLen
-					switch rn := nextRune(line); {
-					case rn == '"':
+					if err = r.appendQuoted(line[:i], pos, recLine); err != nil {
+						break parseField
+					}
+					line = line[i+closeLen:]
+					pos.col += i + closeLen
+					if r.TrimSpace || len(r.comment) > 0 {
+						// Skip white space between the closing quote and the delimiter.
+						if k := r.spaceBeforeEnd(line); k > 0 {
+							line = line[k:]
+							pos.col += k
+						}
+					}
+					switch {
+					case hasPrefix(line, r.closeQuote) && !(r.StrictQuoteEscape && len(r.qescape) > 0):
 						// `""` sequence (append quote).
-						r.recordBuffer = append(r.recordBuffer, '"')
-						line = line[quoteLen:]
-						pos.col += quoteLen
-					case rn == r.Comma:
+						r.recordBuffer = append(r.recordBuffer, r.closeQuote...)
+						line = line[closeLen:]
+						pos.col += closeLen
+					case r.hasSep(line):
 						// `",` sequence (end of field).
 						line = line[commaLen:]
 						pos.col += commaLen
-						r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer))
-						r.fieldPositions = append(r.fieldPositions, fieldPos)
+						if err = r.endField(fieldStart, fieldPos, recLine); err != nil {
+							break parseField
+						}
 						continue parseField
-					case lengthNL(line) == len(line):
+					case r.lengthNL(line) == len(line):
 						// `"\n` sequence (end of line).
-						r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer))
-						r.fieldPositions = append(r.fieldPositions, fieldPos)
+						err = r.endField(fieldStart, fieldPos, recLine)
 						break parseField
-					case r.LazyQuotes:
+					case r.isComment(line):
+						// `"#` sequence (inline comment ends the record).
+						err = r.endField(fieldStart, fieldPos, recLine)
+						break parseField
+					case r.lazyQuotes || r.allowTextAfterClosingQuote || r.repairQuotes:
 						// `"` sequence (bare quote).
-						r.recordBuffer = append(r.recordBuffer, '"')
+						if !r.lazyQuotes && !r.allowTextAfterClosingQuote {
+							r.repair(recLine, r.numLine, pos.col-closeLen, ErrQuote)
+						}
+						r.recordBuffer = append(r.recordBuffer, r.closeQuote...)
 					default:
 						// `"*` sequence (invalid non-escaped quote).
-						err = &ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col - quoteLen, Err: ErrQuote}
+						err = &ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col - closeLen, Err: ErrQuote, Leniency: r.leniency("AllowTextAfterClosingQuote")}
+						break parseField
+					}
+				} else if len(line) > 0 && r.repairQuotes {
+					// Hit end of line; close the field there.
+					if err = r.appendQuoted(line[:len(line)-r.lengthNL(line)], pos, recLine); err != nil {
 						break parseField
 					}
+					r.repair(recLine, fieldPos.line, fieldPos.col, ErrQuote)
+					err = r.endField(fieldStart, fieldPos, recLine)
+					break parseField
 				} else if len(line) > 0 {
 					// Hit end of line (copy all data so far).
-					r.recordBuffer = append(r.recordBuffer, line...)
+					if err = r.appendQuoted(line, pos, recLine); err != nil {
+						break parseField
+					}
+					if err = r.checkFieldSize(fieldStart, fieldPos, recLine); err != nil {
+						break parseField
+					}
 					if errRead != nil {
 						break parseField
 					}
@@ -423,14 +2617,23 @@ parseField:
 					if errRead == io.EOF {
 						errRead = nil
 					}
+					if err = r.checkRecordSize(recStart, recLine); err != nil {
+						break parseField
+					}
+					if r.MaxQuotedLines > 0 && pos.line-fieldPos.line >= r.MaxQuotedLines {
+						err = &ParseError{StartLine: recLine, Line: fieldPos.line, Column: fieldPos.col, Err: ErrQuote}
+						break parseField
+					}
 				} else {
 					// Abrupt end of file (EOF or error).
-					if !r.LazyQuotes && errRead == nil {
-						err = &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col, Err: ErrQuote}
+					if !r.lazyQuotes && !r.allowUnterminatedQuote && !r.repairQuotes && errRead == nil {
+						err = &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col, Err: ErrQuote, Leniency: r.leniency("AllowUnterminatedQuote")}
 						break parseField
 					}
-					r.fieldIndexes = append(r.fieldIndexes, len(r.recordBuffer))
-					r.fieldPositions = append(r.fieldPositions, fieldPos)
+					if errRead == nil {
+						r.unterminated = &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col, Err: ErrUnterminatedField}
+					}
+					err = r.endField(fieldStart, fieldPos, recLine)
 					break parseField
 				}
 			}
@@ -439,24 +2642,20 @@ parseField:
 	if err == nil {
 		err = errRead
 	}
-
-	// Create a single string and create slices out of it.
-	// This pins the memory of the fields together, but allocates once.
-	str := string(r.recordBuffer) // Convert to string once to batch allocations
-	dst = dst[:0]
-	if cap(dst) < len(r.fieldIndexes) {
-		dst = make([]string, len(r.fieldIndexes))
+	if err == nil && r.RequireCRLF && r.Terminator == "" && r.bareLF > 0 {
+		err = &ParseError{StartLine: recLine, Line: r.numLine, Column: r.bareLF, Err: ErrBadLineEnding}
 	}
-	dst = dst[:len(r.fieldIndexes)]
-	var preIdx int
-	for i, idx := range r.fieldIndexes {
-		dst[i] = str[preIdx:idx]
-		preIdx = idx
+
+	if err == nil && r.AllowTrailingComma && !blank {
+		r.dropTrailingField()
 	}
 
 	// Check or update the expected fields per record.
-	if r.FieldsPerRecord > 0 {
-		if len(dst) != r.FieldsPerRecord && err == nil {
+	r.numFields = len(r.fieldIndexes)
+	if blank {
+		// Blank lines are not data records.
+	} else if r.FieldsPerRecord > 0 {
+		if len(r.fieldIndexes) != r.FieldsPerRecord && err == nil && !r.fitFieldCount() {
 			err = &ParseError{
 				StartLine: recLine,
 				Line:      recLine,
@@ -465,7 +2664,31 @@ parseField:
 			}
 		}
 	} else if r.FieldsPerRecord == 0 {
-		r.FieldsPerRecord = len(dst)
+		r.FieldsPerRecord = len(r.fieldIndexes)
+		r.inferredFields = r.FieldsPerRecord
 	}
-	return dst, err
+	if len(r.selectedColumns()) > 0 && len(r.fieldIndexes) > 0 {
+		r.selectColumns()
+	}
+
+	if pe, ok := err.(*ParseError); ok && r.ErrorSnippets {
+		if i := pe.Line - recLine; i >= 0 && i < len(r.snippetLines) {
+			pe.Snippet = r.snippet(r.snippetLines[i], pe.Column)
+		}
+	}
+
+	// Report columns in bytes of the undecoded input.
+	if r.dec != nil {
+		for i := range r.fieldPositions {
+			p := &r.fieldPositions[i]
+			p.col = r.inputColumn(p.line, p.col, recLine)
+		}
+		if pe, ok := err.(*ParseError); ok {
+			pe.Column = r.inputColumn(pe.Line, pe.Column, recLine)
+		}
+	}
+	if !r.noCount {
+		r.numRecords++
+	}
+	return true, err
 }
@@ -10,13 +10,19 @@
 package flexcsv
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"unicode/utf8"
+	"unsafe"
 )
 
 type readTest struct {
@@ -27,13 +33,46 @@ type readTest struct {
 	Errors    []error
 
 	// These fields are copied into the Reader
-	Comma              rune
-	Comment            rune
-	UseFieldsPerRecord bool // false (default) means FieldsPerRecord is -1
-	FieldsPerRecord    int
-	LazyQuotes         bool
-	TrimLeadingSpace   bool
-	ReuseRecord        bool
+	Comma                rune
+	Separator            string
+	CollapseDelimiters   bool
+	Whitespace           bool
+	AllowTrailingComma   bool
+	DoubledDelimiter     bool // Sets DoubledDelimiterEscape
+	Quote                rune
+	QuoteOpen            rune
+	QuoteClose           rune
+	Comment              rune
+	InlineComments       bool
+	KeepCommentSpace     bool
+	UseFieldsPerRecord   bool // false (default) means FieldsPerRecord is -1
+	FieldsPerRecord      int
+	LazyQuotes           bool
+	AllowBareQuotes      bool
+	AllowTextAfter       bool // Sets AllowTextAfterClosingQuote
+	AllowUnterminated    bool // Sets AllowUnterminatedQuote
+	RelaxedOpenQuote     bool
+	Strict               bool
+	RequireCRLF          bool
+	Escape               rune
+	QuoteEscape          rune
+	StrictQuoteEscape    bool
+	DecodeEscapes        bool
+	BackslashEscapes     bool // Sets DecodeBackslashEscapes
+	RejectUnknown        bool // Sets RejectUnknownEscapes
+	BareCR               BareCRMode
+	NormalizeLineEndings bool
+	RejectNUL            bool
+	MaxQuotedLines       int
+	InvalidUTF8          InvalidUTF8Mode
+	BlankLineMode        BlankLineMode
+	TrimLeadingSpace     bool
+	TrimSpace            bool
+	Null                 string
+	MaxFieldSize         int
+	MaxRecordBytes       int
+	MaxColumns           int
+	ReuseRecord          bool
 }
 
 // In these tests, the §, ¶ and ∑ characters in readTest.Input are used to denote
@@ -137,7 +176,7 @@ field"`,
 }, {
 	Name:   "BadDoubleQuotes",
 	Input:  `§a∑""b,c`,
-	Errors: []error{&ParseError{Err: ErrBareQuote}},
+	Errors: []error{&ParseError{Err: ErrBareQuote, Leniency: "AllowBareQuotes"}},
 }, {
 	Name:             "TrimQuote",
 	Input:            ` §"a",§" b",§c`,
@@ -146,15 +185,76 @@ field"`,
 }, {
 	Name:   "BadBareQuote",
 	Input:  `§a ∑"word","b"`,
-	Errors: []error{&ParseError{Err: ErrBareQuote}},
+	Errors: []error{&ParseError{Err: ErrBareQuote, Leniency: "AllowBareQuotes"}},
 }, {
 	Name:   "BadTrailingQuote",
 	Input:  `§"a word",b∑"`,
-	Errors: []error{&ParseError{Err: ErrBareQuote}},
+	Errors: []error{&ParseError{Err: ErrBareQuote, Leniency: "AllowBareQuotes"}},
 }, {
 	Name:   "ExtraneousQuote",
 	Input:  `§"a ∑"word","b"`,
-	Errors: []error{&ParseError{Err: ErrQuote}},
+	Errors: []error{&ParseError{Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}},
+}, {
+	Name:             "RelaxedOpenQuote",
+	Input:            "§\"abc,§def\n¶§\"a,b\",§c\n¶§x,§\"y\n¶§\",§x\n",
+	Output:           [][]string{{`"abc`, "def"}, {"a,b", "c"}, {"x", `"y`}, {`"`, "x"}},
+	RelaxedOpenQuote: true,
+}, {
+	Name:             "RelaxedOpenQuoteClosedLater",
+	Input:            "§\"abc,∑\"d\"e\n",
+	Errors:           []error{&ParseError{Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}},
+	RelaxedOpenQuote: true,
+}, {
+	Name:             "RelaxedOpenQuoteStrict",
+	Input:            "§\"abc,def∑",
+	Errors:           []error{&ParseError{Err: ErrQuote}},
+	RelaxedOpenQuote: true,
+	Strict:           true,
+}, {
+	Name:            "AllowBareQuotes",
+	Input:           `§5" pipe,§a"b""c`,
+	Output:          [][]string{{`5" pipe`, `a"b""c`}},
+	AllowBareQuotes: true,
+}, {
+	Name:            "AllowBareQuotesUnterminated",
+	Input:           `§5" pipe,§"steel∑`,
+	Errors:          []error{&ParseError{Err: ErrQuote, Leniency: "AllowUnterminatedQuote"}},
+	AllowBareQuotes: true,
+}, {
+	Name:            "AllowBareQuotesTextAfter",
+	Input:           `§"a ∑"b"`,
+	Errors:          []error{&ParseError{Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}},
+	AllowBareQuotes: true,
+}, {
+	Name:           "AllowTextAfter",
+	Input:          `§"a "b" c",§d`,
+	Output:         [][]string{{`a "b" c`, "d"}},
+	AllowTextAfter: true,
+}, {
+	Name:           "AllowTextAfterBareQuote",
+	Input:          `§a ∑"word"`,
+	Errors:         []error{&ParseError{Err: ErrBareQuote, Leniency: "AllowBareQuotes"}},
+	AllowTextAfter: true,
+}, {
+	Name:           "AllowTextAfterUnterminated",
+	Input:          "§\"a \"b∑",
+	Errors:         []error{&ParseError{Err: ErrQuote, Leniency: "AllowUnterminatedQuote"}},
+	AllowTextAfter: true,
+}, {
+	Name:              "AllowUnterminated",
+	Input:             "§a,§\"b\nc",
+	Output:            [][]string{{"a", "b\nc"}},
+	AllowUnterminated: true,
+}, {
+	Name:              "AllowUnterminatedBareQuote",
+	Input:             `§a∑"b`,
+	Errors:            []error{&ParseError{Err: ErrBareQuote, Leniency: "AllowBareQuotes"}},
+	AllowUnterminated: true,
+}, {
+	Name:              "AllowUnterminatedTextAfter",
+	Input:             `§"a∑"b`,
+	Errors:            []error{&ParseError{Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}},
+	AllowUnterminated: true,
 }, {
 	Name:               "BadFieldCount",
 	Input:              "§a,§b,§c\n¶∑§d,§e",
@@ -251,11 +351,11 @@ field"`,
 }, {
 	Name:   "StartLine1", // Issue 19019
 	Input:  "§a,\"b\nc∑\"d,e",
-	Errors: []error{&ParseError{Err: ErrQuote}},
+	Errors: []error{&ParseError{Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}},
 }, {
 	Name:   "StartLine2",
 	Input:  "§a,§b\n¶§\"d\n\n,e∑",
-	Errors: []error{nil, &ParseError{Err: ErrQuote}},
+	Errors: []error{nil, &ParseError{Err: ErrQuote, Leniency: "AllowUnterminatedQuote"}},
 	Output: [][]string{{"a", "b"}},
 }, {
 	Name:  "CRLFInQuotedField", // Issue 21201
@@ -278,7 +378,7 @@ field"`,
 }, {
 	Name:   "QuotedTrailingCRCR",
 	Input:  "§\"field∑\"\r\r",
-	Errors: []error{&ParseError{Err: ErrQuote}},
+	Errors: []error{&ParseError{Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}},
 }, {
 	Name:   "FieldCR",
 	Input:  "§field\rfield\r",
@@ -350,7 +450,7 @@ field"`,
 }, {
 	Name:   "QuoteWithTrailingCRLF",
 	Input:  "§\"foo∑\"bar\"\r\n",
-	Errors: []error{&ParseError{Err: ErrQuote}},
+	Errors: []error{&ParseError{Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}},
 }, {
 	Name:       "LazyQuoteWithTrailingCRLF",
 	Input:      "§\"foo\"bar\"\r\n",
@@ -367,12 +467,746 @@ field"`,
 }, {
 	Name:   "OddQuotes",
 	Input:  `§"""""""∑`,
-	Errors: []error{&ParseError{Err: ErrQuote}},
+	Errors: []error{&ParseError{Err: ErrQuote, Leniency: "AllowUnterminatedQuote"}},
 }, {
 	Name:       "LazyOddQuotes",
 	Input:      `§"""""""`,
 	Output:     [][]string{{`"""`}},
 	LazyQuotes: true,
+}, {
+	Name:      "Separator",
+	Input:     "§a||§b||§c\n¶§d|e||§f||§g|\n",
+	Output:    [][]string{{"a", "b", "c"}, {"d|e", "f", "g|"}},
+	Separator: "||",
+}, {
+	Name:      "SeparatorAtLineEdges",
+	Input:     "§||§a||§\n¶§||§\n",
+	Output:    [][]string{{"", "a", ""}, {"", ""}},
+	Separator: "||",
+}, {
+	Name:      "SeparatorInQuotes",
+	Input:     "§\"a||b\"||§\"c|\"||§\"||\"\n",
+	Output:    [][]string{{"a||b", "c|", "||"}},
+	Separator: "||",
+}, {
+	Name:      "SeparatorMultiByte",
+	Input:     "§a∷§\"b∷c\"∷§d\n",
+	Output:    [][]string{{"a", "b∷c", "d"}},
+	Separator: "∷",
+}, {
+	// The separator straddles the read buffer boundary.
+	Name:      "SeparatorBufferBoundary",
+	Input:     "§" + strings.Repeat("x", 4095) + "||§y\n",
+	Output:    [][]string{{strings.Repeat("x", 4095), "y"}},
+	Separator: "||",
+}, {
+	Name:      "SeparatorOverridesComma",
+	Input:     "§a,b<>§c\n",
+	Output:    [][]string{{"a,b", "c"}},
+	Separator: "<>",
+	Comma:     ';',
+}, {
+	Name:             "SeparatorTrimLeadingSpace",
+	Input:            "§a::  §b:: §",
+	Output:           [][]string{{"a", "b", ""}},
+	Separator:        "::",
+	TrimLeadingSpace: true,
+}, {
+	Name:      "BadSeparatorQuote",
+	Separator: `|"`,
+	Errors:    []error{errInvalidDelim},
+}, {
+	Name:      "BadSeparatorNewline",
+	Separator: "|\n",
+	Errors:    []error{errInvalidDelim},
+}, {
+	Name:      "BadSeparatorComment",
+	Separator: "#|",
+	Comment:   '#',
+	Errors:    []error{errInvalidDelim},
+}, {
+	Name:   "Escape",
+	Input:  `§a\,b,§c\"d,§e\\f,§\g` + "\n",
+	Output: [][]string{{"a,b", `c"d`, `e\f`, "g"}},
+	Escape: '\\',
+}, {
+	Name:   "EscapeQuoted",
+	Input:  `§"a\"b",§"c\\",§"d""e",§"\,"` + "\n",
+	Output: [][]string{{`a"b`, `c\`, `d"e`, ","}},
+	Escape: '\\',
+}, {
+	Name:   "EscapeLineBreak",
+	Input:  "§a\\\nb,§c\n¶§\"d\\\ne\"\n",
+	Output: [][]string{{"a\nb", "c"}, {"d\ne"}},
+	Escape: '\\',
+}, {
+	Name:          "DecodeEscapes",
+	Input:         `§a\nb,§"c\td",§\r\0\x` + "\n",
+	Output:        [][]string{{"a\nb", "c\td", "\r\x00x"}},
+	Escape:        '\\',
+	DecodeEscapes: true,
+}, {
+	Name:             "BackslashEscapes",
+	Input:            `§a\nb\tc,§d\,e,§\\,§\N,§x\N,§\q\0` + "\n",
+	Output:           [][]string{{"a\nb\tc", "d,e", `\`, "", "xN", "q\x00"}},
+	BackslashEscapes: true,
+}, {
+	Name:             "BackslashEscapesQuoted",
+	Input:            `§"a\"\n",§\"b` + "\n",
+	Output:           [][]string{{"a\"\n", `"b`}},
+	BackslashEscapes: true,
+}, {
+	Name:             "RejectUnknownEscapes",
+	Input:            `§a\n,§\,\N\\,§b∑\q`,
+	Errors:           []error{&ParseError{Err: ErrUnknownEscape}},
+	BackslashEscapes: true,
+	RejectUnknown:    true,
+}, {
+	Name:             "RejectUnknownEscapesQuoted",
+	Input:            `§"a∑\7"`,
+	Errors:           []error{&ParseError{Err: ErrUnknownEscape}},
+	BackslashEscapes: true,
+	RejectUnknown:    true,
+}, {
+	Name:          "RejectUnknownEscapesOnly",
+	Input:         `§\q`,
+	Output:        [][]string{{"q"}},
+	Escape:        '\\',
+	RejectUnknown: true,
+}, {
+	Name:   "EscapeUndecoded",
+	Input:  `§a\nb,§"c\td"` + "\n",
+	Output: [][]string{{"anb", "ctd"}},
+	Escape: '\\',
+}, {
+	Name:   "EscapeNonASCII",
+	Input:  "§a¬,b,§\"c¬\"¬¬\"\n",
+	Output: [][]string{{"a,b", `c"¬`}},
+	Escape: '¬',
+}, {
+	Name:   "EscapeBareQuote",
+	Input:  `§a\"b∑"c`,
+	Errors: []error{&ParseError{Err: ErrBareQuote, Leniency: "AllowBareQuotes"}},
+	Escape: '\\',
+}, {
+	Name:   "EscapeAtEOF",
+	Input:  `§a,§b∑\`,
+	Errors: []error{&ParseError{Err: ErrEscape}},
+	Escape: '\\',
+}, {
+	Name:   "EscapeAtEOFQuoted",
+	Input:  `§a,§"b∑\`,
+	Errors: []error{&ParseError{Err: ErrEscape}},
+	Escape: '\\',
+}, {
+	Name:   "BadEscapeComma",
+	Escape: ',',
+	Errors: []error{errInvalidDelim},
+}, {
+	Name:    "BadEscapeComment",
+	Escape:  '#',
+	Comment: '#',
+	Errors:  []error{errInvalidDelim},
+}, {
+	Name:   "BadEscapeQuote",
+	Escape: '"',
+	Errors: []error{errInvalidDelim},
+}, {
+	Name:          "BlankLineEmptyRecord",
+	Input:         "§\n¶§a,§b\n¶§\n¶§\n¶§c,§d\n¶§\n",
+	Output:        [][]string{{""}, {"a", "b"}, {""}, {""}, {"c", "d"}, {""}},
+	BlankLineMode: BlankLineEmptyRecord,
+}, {
+	Name:               "BlankLineEmptyRecordFieldCount",
+	Input:              "§\n¶§a,§b\n¶§\n¶§c,§d\r\n¶§\r\n",
+	Output:             [][]string{{""}, {"a", "b"}, {""}, {"c", "d"}, {""}},
+	BlankLineMode:      BlankLineEmptyRecord,
+	UseFieldsPerRecord: true,
+}, {
+	Name:          "BlankLineWhitespace",
+	Input:         "§a\n¶§   \n¶§\t\n",
+	Output:        [][]string{{"a"}, {"   "}, {"\t"}},
+	BlankLineMode: BlankLineError,
+}, {
+	Name:          "BlankLineErrorStart",
+	Input:         "§∑\na\n",
+	Errors:        []error{&ParseError{Err: ErrBlankLine}},
+	BlankLineMode: BlankLineError,
+}, {
+	Name:          "BlankLineErrorMiddle",
+	Input:         "§a\n¶§∑\n\nb\n",
+	Output:        [][]string{{"a"}},
+	Errors:        []error{nil, &ParseError{Err: ErrBlankLine}},
+	BlankLineMode: BlankLineError,
+}, {
+	Name:          "BlankLineErrorEnd",
+	Input:         "§a\n¶§∑\n",
+	Output:        [][]string{{"a"}},
+	Errors:        []error{nil, &ParseError{Err: ErrBlankLine}},
+	BlankLineMode: BlankLineError,
+}, {
+	Name:          "BlankLineErrorQuoted",
+	Input:         "§\"a\n\nb\"\n",
+	Output:        [][]string{{"a\n\nb"}},
+	BlankLineMode: BlankLineError,
+}, {
+	Name:   "Quote",
+	Input:  "§'a,b',§'c''d',§\"e\",§'f\ng'\n",
+	Output: [][]string{{"a,b", "c'd", `"e"`, "f\ng"}},
+	Quote:  '\'',
+}, {
+	Name:   "QuoteNonASCII",
+	Input:  "§«a,b«,§c∑«d",
+	Errors: []error{&ParseError{Err: ErrBareQuote, Leniency: "AllowBareQuotes"}},
+	Quote:  '«',
+}, {
+	Name:   "QuoteEscape",
+	Input:  `§'a\'b',§c\'d` + "\n",
+	Output: [][]string{{"a'b", "c'd"}},
+	Quote:  '\'',
+	Escape: '\\',
+}, {
+	Name:    "CommentInQuotes",
+	Input:   "§\"#a\",§b#c\n¶§\"d\n#e\"\n",
+	Output:  [][]string{{"#a", "b#c"}, {"d\n#e"}},
+	Comment: '#',
+}, {
+	Name:               "CommentLineNumbers",
+	Input:              "#a,b,c\n§d,§e\n#f\n#g,h,i\n¶§j,§k\n",
+	Output:             [][]string{{"d", "e"}, {"j", "k"}},
+	Comment:            '#',
+	UseFieldsPerRecord: true,
+}, {
+	Name:    "BadQuoteComment",
+	Quote:   '#',
+	Comment: '#',
+	Errors:  []error{errInvalidDelim},
+}, {
+	Name:   "BadQuoteComma",
+	Quote:  ',',
+	Errors: []error{errInvalidDelim},
+}, {
+	Name:   "BadQuoteNewline",
+	Quote:  '\n',
+	Errors: []error{errInvalidDelim},
+}, {
+	Name:      "TrimBothSpace",
+	Input:     " §a ,\t§b\t,§c\u00a0\u00a0,  §d e  \n",
+	Output:    [][]string{{"a", "b", "c", "d e"}},
+	TrimSpace: true,
+}, {
+	Name:      "TrimSpaceQuoted",
+	Input:     " §\" a \" ,§\"b \"\t\n",
+	Output:    [][]string{{" a ", "b "}},
+	TrimSpace: true,
+}, {
+	Name:               "TrimSpaceEmpty",
+	Input:              "§a,   §,§\n¶ \t §,§b,§c\n",
+	Output:             [][]string{{"a", "", ""}, {"", "b", "c"}},
+	TrimSpace:          true,
+	UseFieldsPerRecord: true,
+}, {
+	Name:               "CollapseDelimiters",
+	Input:              "  §a    §b  §c \n¶§d §\"e  f\"   \n",
+	Output:             [][]string{{"a", "b", "c"}, {"d", "e  f"}},
+	Comma:              ' ',
+	CollapseDelimiters: true,
+}, {
+	Name:               "CollapseDelimitersQuotedEmpty",
+	Input:              ",§a,,,§\"\",§b,,\n",
+	Output:             [][]string{{"a", "", "b"}},
+	CollapseDelimiters: true,
+}, {
+	Name:               "CollapseDelimitersOnly",
+	Input:              ",,,§\n¶§a\n",
+	Output:             [][]string{{""}, {"a"}},
+	CollapseDelimiters: true,
+}, {
+	Name:               "CollapseDelimitersSeparator",
+	Input:              "§a::::§b::\n",
+	Output:             [][]string{{"a", "b"}},
+	Separator:          "::",
+	CollapseDelimiters: true,
+}, {
+	Name:       "Whitespace",
+	Input:      " \t§USER  \t§PID\t\t§CMD \n¶§root \t §1\t§\"/sbin/init  splash\"\t\n¶§\"\"   §2 §\"a\tb\"\n",
+	Output:     [][]string{{"USER", "PID", "CMD"}, {"root", "1", "/sbin/init  splash"}, {"", "2", "a\tb"}},
+	Whitespace: true,
+}, {
+	Name:       "WhitespaceBlankLine",
+	Input:      "§a §b\n \t \n\t\n¶§c\t§d\n",
+	Output:     [][]string{{"a", "b"}, {"c", "d"}},
+	Whitespace: true,
+}, {
+	Name:          "WhitespaceBlankLineEmptyRecord",
+	Input:         "§a\n¶ \t§\n¶§b\n",
+	Output:        [][]string{{"a"}, {""}, {"b"}},
+	Whitespace:    true,
+	BlankLineMode: BlankLineEmptyRecord,
+}, {
+	Name:               "AllowTrailingComma",
+	Input:              "§a,§b,§c,\r\n¶§1,§2,§3,\r\n¶§4,§5,§\"\",\r\n¶§7,§8,§\"\"\r\n",
+	Output:             [][]string{{"a", "b", "c"}, {"1", "2", "3"}, {"4", "5", ""}, {"7", "8", ""}},
+	AllowTrailingComma: true,
+}, {
+	Name:               "AllowTrailingCommaOnlyDelimiter",
+	Input:              "§,\n¶§a,\n¶§,§,\n",
+	Output:             [][]string{{""}, {"a"}, {"", ""}},
+	AllowTrailingComma: true,
+	UseFieldsPerRecord: true,
+	FieldsPerRecord:    -1,
+}, {
+	Name:               "AllowTrailingCommaFieldsPerRecord",
+	Input:              "§a,§b,\n¶§c,§d\n¶∑§e,§f,§g\n",
+	Output:             [][]string{{"a", "b"}, {"c", "d"}, {"e", "f", "g"}},
+	Errors:             []error{nil, nil, &ParseError{Err: ErrFieldCount}},
+	AllowTrailingComma: true,
+	UseFieldsPerRecord: true,
+}, {
+	Name:               "AllowTrailingCommaTrimSpace",
+	Input:              "§a,§b, \t\n",
+	Output:             [][]string{{"a", "b"}},
+	AllowTrailingComma: true,
+	TrimSpace:          true,
+}, {
+	Name:               "AllowTrailingCommaNull",
+	Input:              "§a,§NA\n¶§b,\n",
+	Output:             [][]string{{"a", ""}, {"b"}},
+	AllowTrailingComma: true,
+	Null:               "NA",
+	UseFieldsPerRecord: true,
+	FieldsPerRecord:    -1,
+}, {
+	Name:       "WhitespaceIgnoresComma",
+	Input:      "§a,b §c\n",
+	Output:     [][]string{{"a,b", "c"}},
+	Comma:      ';',
+	Whitespace: true,
+}, {
+	Name:       "WhitespaceEscape",
+	Input:      "§a\\ b\t§c\\\td\n",
+	Output:     [][]string{{"a b", "c\td"}},
+	Escape:     '\\',
+	Whitespace: true,
+}, {
+	Name:             "DoubledDelimiter",
+	Input:            "§a,,b,§c\n¶§d,§e,,f\n",
+	Output:           [][]string{{"a,b", "c"}, {"d", "e,f"}},
+	DoubledDelimiter: true,
+}, {
+	Name:             "DoubledDelimiterStart",
+	Input:            "§,,a,§b\n¶§,,,,,§c\n",
+	Output:           [][]string{{",a", "b"}, {",,", "c"}},
+	DoubledDelimiter: true,
+}, {
+	Name:             "DoubledDelimiterEnd",
+	Input:            "§a,,,§b,,\n",
+	Output:           [][]string{{"a,", "b,"}},
+	DoubledDelimiter: true,
+}, {
+	Name:             "DoubledDelimiterThree",
+	Input:            "§a,,,§b\n¶§,,,§c\n¶§d,,,,,§e\n",
+	Output:           [][]string{{"a,", "b"}, {",", "c"}, {"d,,", "e"}},
+	DoubledDelimiter: true,
+}, {
+	Name:             "DoubledDelimiterEmpty",
+	Input:            "§,§\"\",§\",\",§\n",
+	Output:           [][]string{{"", "", ",", ""}},
+	DoubledDelimiter: true,
+}, {
+	Name:             "DoubledDelimiterSeparator",
+	Input:            "§a::::b::§c\n",
+	Output:           [][]string{{"a::b", "c"}},
+	Separator:        "::",
+	DoubledDelimiter: true,
+}, {
+	Name:               "DoubledDelimiterCollapse",
+	Input:              "a,,b\n",
+	Errors:             []error{errInvalidDelim},
+	DoubledDelimiter:   true,
+	CollapseDelimiters: true,
+}, {
+	Name:             "TrimSpaceWithTrimLeadingSpace",
+	Input:            "  §a  , §\"b\" \n",
+	Output:           [][]string{{"a", "b"}},
+	TrimSpace:        true,
+	TrimLeadingSpace: true,
+}, {
+	Name:      "TrimSpaceTab",
+	Input:     "§a \t §b \t§\"c\" \t§d\n",
+	Output:    [][]string{{"a", "b", "c", "d"}},
+	Comma:     '\t',
+	TrimSpace: true,
+}, {
+	Name:      "TrimSpaceEscaped",
+	Input:     `§a\  ,§b\\  ,§c\\\  ` + "\n",
+	Output:    [][]string{{"a ", `b\`, `c\ `}},
+	Escape:    '\\',
+	TrimSpace: true,
+}, {
+	Name:      "TrimSpaceTextAfterQuote",
+	Input:     `§"a∑" b,c`,
+	Errors:    []error{&ParseError{Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}},
+	TrimSpace: true,
+}, {
+	Name:           "InlineComment",
+	Input:          "§value1,§value2  # explanation\n¶§x,§y\n",
+	Output:         [][]string{{"value1", "value2"}, {"x", "y"}},
+	Comment:        '#',
+	InlineComments: true,
+}, {
+	Name:           "InlineCommentAfterQuote",
+	Input:          "§\"a\"#c1\n¶§\"b\"  # c2\n¶§\"c#d\",§e\n",
+	Output:         [][]string{{"a"}, {"b"}, {"c#d", "e"}},
+	Comment:        '#',
+	InlineComments: true,
+}, {
+	Name:           "InlineCommentAfterDelimiter",
+	Input:          "§a,§#c\n¶§b,§ # c\n",
+	Output:         [][]string{{"a", ""}, {"b", ""}},
+	Comment:        '#',
+	InlineComments: true,
+}, {
+	Name:           "InlineCommentOnly",
+	Input:          "§a,§b\n  # note\n\t#\n#full\n¶§c,§d\n",
+	Output:         [][]string{{"a", "b"}, {"c", "d"}},
+	Comment:        '#',
+	InlineComments: true,
+}, {
+	Name:             "InlineCommentKeepSpace",
+	Input:            "§a,§b \t# c\n",
+	Output:           [][]string{{"a", "b \t"}},
+	Comment:          '#',
+	InlineComments:   true,
+	KeepCommentSpace: true,
+}, {
+	Name:           "InlineCommentEscaped",
+	Input:          `§a\#b,§c\ #d` + "\n",
+	Output:         [][]string{{"a#b", "c "}},
+	Comment:        '#',
+	InlineComments: true,
+	Escape:         '\\',
+}, {
+	Name:           "InlineCommentQuoted",
+	Input:          "§\"a,#b\nc\" # d,\"e\"\n",
+	Output:         [][]string{{"a,#b\nc"}},
+	Comment:        '#',
+	InlineComments: true,
+}, {
+	Name:               "InlineCommentFieldCount",
+	Input:              "§a,§b # two\n¶∑§a # one\n",
+	Output:             [][]string{{"a", "b"}, {"a"}},
+	Errors:             []error{nil, &ParseError{Err: ErrFieldCount}},
+	Comment:            '#',
+	InlineComments:     true,
+	UseFieldsPerRecord: true,
+}, {
+	Name:           "InlineCommentBareQuote",
+	Input:          "§a,§b # say \"hi\"\n",
+	Output:         [][]string{{"a", "b"}},
+	Comment:        '#',
+	InlineComments: true,
+}, {
+	Name:         "MaxFieldSize",
+	Input:        "§abcd,§\"ab\"\"c\",§\"a\nbc\"\n",
+	Output:       [][]string{{"abcd", `ab"c`, "a\nbc"}},
+	MaxFieldSize: 4,
+}, {
+	Name:         "MaxFieldSizeExceeded",
+	Input:        "§a,∑§abcde,f\n",
+	Errors:       []error{&ParseError{Err: ErrFieldTooLarge}},
+	MaxFieldSize: 4,
+}, {
+	Name:         "MaxFieldSizeQuoted",
+	Input:        "§a,∑§\"ab\"\"cd\"\n",
+	Errors:       []error{&ParseError{Err: ErrFieldTooLarge}},
+	MaxFieldSize: 4,
+}, {
+	Name:         "MaxFieldSizeMultiline",
+	Input:        "∑§\"ab\ncd\nef\",g\n",
+	Errors:       []error{&ParseError{Err: ErrFieldTooLarge}},
+	MaxFieldSize: 4,
+}, {
+	Name:   "BareCRKeep",
+	Input:  "§a\rb,§\"c\rd\",§e\r\n¶§\"f\r\ng\"\r\n",
+	Output: [][]string{{"a\rb", "c\rd", "e"}, {"f\ng"}},
+}, {
+	Name:   "BareCRStrip",
+	Input:  "§a\rb\r,§\"\rc\rd\",§e\r\n¶§\"f\r\ng\r\"\r\n",
+	Output: [][]string{{"ab", "cd", "e"}, {"f\ng"}},
+	BareCR: BareCRStrip,
+}, {
+	Name:   "BareCRStripEscaped",
+	Input:  "§a\\\rb\rc\n",
+	Output: [][]string{{"a\rbc"}},
+	Escape: '\\',
+	BareCR: BareCRStrip,
+}, {
+	Name:   "BareCRError",
+	Input:  "§a,§b∑\rc\n",
+	Errors: []error{&ParseError{Err: ErrBareCR}},
+	BareCR: BareCRError,
+}, {
+	Name:   "BareCRErrorQuoted",
+	Input:  "§a,§\"b\n∑\rc\"\n",
+	Errors: []error{&ParseError{Err: ErrBareCR}},
+	BareCR: BareCRError,
+}, {
+	Name:   "BareCRErrorCRLF",
+	Input:  "§a,§\"b\r\nc\"\r\n",
+	Output: [][]string{{"a", "b\nc"}},
+	BareCR: BareCRError,
+}, {
+	Name:   "BareCRNewline",
+	Input:  "§a\rb,§\"c\rd\r\ne\",§f\r\n",
+	Output: [][]string{{"a\nb", "c\nd\ne", "f"}},
+	BareCR: BareCRNewline,
+}, {
+	Name:                 "NormalizeLineEndings",
+	Input:                "§a\rb,§\"c\rd\r\ne\"\r\n",
+	Output:               [][]string{{"a\rb", "c\rd\ne"}},
+	NormalizeLineEndings: true,
+}, {
+	Name:   "NUL",
+	Input:  "§\x00a,§b\x00c,§d\x00\n¶§\"\x00e\",§\"f\x00\ng\",§\"h\x00\"\n¶§\x00,§\"\x00\",§\n",
+	Output: [][]string{{"\x00a", "b\x00c", "d\x00"}, {"\x00e", "f\x00\ng", "h\x00"}, {"\x00", "\x00", ""}},
+}, {
+	Name:      "RejectNUL",
+	Input:     "§a,§b∑\x00c\n",
+	Errors:    []error{&ParseError{Err: ErrNUL}},
+	RejectNUL: true,
+}, {
+	Name:      "RejectNULQuoted",
+	Input:     "§a,§\"b\n∑\x00c\"\n",
+	Errors:    []error{&ParseError{Err: ErrNUL}},
+	RejectNUL: true,
+}, {
+	Name:      "RejectNULEscaped",
+	Input:     "§\"a\\∑\x00\"\n",
+	Errors:    []error{&ParseError{Err: ErrNUL}},
+	Escape:    '\\',
+	RejectNUL: true,
+}, {
+	Name:          "RejectNULDecoded",
+	Input:         "§a\\0b\n",
+	Output:        [][]string{{"a\x00b"}},
+	Escape:        '\\',
+	DecodeEscapes: true,
+	RejectNUL:     true,
+}, {
+	Name:   "NullToken",
+	Input:  "§NA,§\"NA\",§NAN,§ NA\n¶§a,§NA,§\"NA\"\"\",§NA\n",
+	Output: [][]string{{"", "NA", "NAN", " NA"}, {"a", "", `NA"`, ""}},
+	Null:   "NA",
+}, {
+	Name:      "NullTokenTrimmed",
+	Input:     " §NA , §\"NA\"\n",
+	Output:    [][]string{{"", "NA"}},
+	Null:      "NA",
+	TrimSpace: true,
+}, {
+	Name:   "InvalidUTF8",
+	Input:  "§a\xff,§\"b\xe9\"\"\n\xc3\"\n",
+	Output: [][]string{{"a\xff", "b\xe9\"\n\xc3"}},
+}, {
+	Name:        "InvalidUTF8Replace",
+	Input:       "§a\xff\xfe,§\"b\xe9\"\"\n\xc3\",§\xef\xbf\xbd\xe2\x82\n",
+	Output:      [][]string{{"a\ufffd\ufffd", "b\ufffd\"\n\ufffd", "\ufffd\ufffd\ufffd"}},
+	InvalidUTF8: InvalidUTF8Replace,
+}, {
+	Name:        "InvalidUTF8Error",
+	Input:       "§ab,§c∑\xffd\n",
+	Errors:      []error{&ParseError{Err: ErrInvalidUTF8}},
+	InvalidUTF8: InvalidUTF8Error,
+}, {
+	Name:        "InvalidUTF8ErrorQuoted",
+	Input:       "§a,§\"b\"\"\nc∑\xe9\"\n",
+	Errors:      []error{&ParseError{Err: ErrInvalidUTF8}},
+	InvalidUTF8: InvalidUTF8Error,
+}, {
+	Name:        "InvalidUTF8ErrorEscaped",
+	Input:       "§\"a\\∑\xe9\"\n",
+	Errors:      []error{&ParseError{Err: ErrInvalidUTF8}},
+	Escape:      '\\',
+	InvalidUTF8: InvalidUTF8Error,
+}, {
+	Name:        "InvalidUTF8ErrorValid",
+	Input:       "§héllo,§\"wörld\n€\",§\xef\xbf\xbd\n",
+	Output:      [][]string{{"héllo", "wörld\n€", "\ufffd"}},
+	InvalidUTF8: InvalidUTF8Error,
+}, {
+	Name:           "MaxQuotedLines",
+	Input:          "§a,§\"b\nc\nd\",§e\n",
+	Output:         [][]string{{"a", "b\nc\nd", "e"}},
+	MaxQuotedLines: 10,
+}, {
+	Name:           "MaxQuotedLinesExact",
+	Input:          "§\"b\nc\nd\"\n¶§\"e\nf\"\n",
+	Output:         [][]string{{"b\nc\nd"}, {"e\nf"}},
+	MaxQuotedLines: 3,
+}, {
+	Name:           "MaxQuotedLinesExceeded",
+	Input:          "§a,∑\"b\nc\nd\ne\nf\",g\n",
+	Errors:         []error{&ParseError{Err: ErrQuote}},
+	MaxQuotedLines: 3,
+}, {
+	Name:           "MaxQuotedLinesUnterminated",
+	Input:          "§a\n¶§∑\"b,c\nd\ne\nf\ng\n",
+	Output:         [][]string{{"a"}},
+	Errors:         []error{nil, &ParseError{Err: ErrQuote}},
+	MaxQuotedLines: 2,
+}, {
+	Name:               "StrictValid",
+	Input:              "§a,§\"b,c\",§\"d\"\"e\"\r\n¶§\"f\r\ng\",§,§\"\"\r\n¶§\"h\ri\",§j,§k",
+	Output:             [][]string{{"a", "b,c", `d"e`}, {"f\ng", "", ""}, {"h\ri", "j", "k"}},
+	Strict:             true,
+	RequireCRLF:        true,
+	UseFieldsPerRecord: true,
+}, {
+	Name:       "StrictBareQuote",
+	Input:      "§a,§b∑\"c\n",
+	Errors:     []error{&ParseError{Err: ErrBareQuote}},
+	Strict:     true,
+	LazyQuotes: true,
+}, {
+	Name:       "StrictTextAfterQuote",
+	Input:      "§\"a∑\"b,c\n",
+	Errors:     []error{&ParseError{Err: ErrQuote}},
+	Strict:     true,
+	LazyQuotes: true,
+}, {
+	Name:       "StrictUnterminatedQuote",
+	Input:      "§a,§\"b\nc∑",
+	Errors:     []error{&ParseError{Err: ErrQuote}},
+	Strict:     true,
+	LazyQuotes: true,
+}, {
+	Name:   "StrictBareCR",
+	Input:  "§a∑\rb,§c\n",
+	Errors: []error{&ParseError{Err: ErrBareCR}},
+	Strict: true,
+}, {
+	Name:               "StrictFieldCount",
+	Input:              "§a,§b\n¶∑§c\n",
+	Output:             [][]string{{"a", "b"}, {"c"}},
+	Errors:             []error{nil, &ParseError{Err: ErrFieldCount}},
+	Strict:             true,
+	UseFieldsPerRecord: true,
+}, {
+	Name:        "RequireCRLF",
+	Input:       "§a,§b∑\n§c,§d\r\n",
+	Errors:      []error{&ParseError{Err: ErrBadLineEnding}},
+	RequireCRLF: true,
+}, {
+	Name:        "RequireCRLFQuoted",
+	Input:       "§a,§\"b\nc\"∑\n",
+	Errors:      []error{&ParseError{Err: ErrBadLineEnding}},
+	RequireCRLF: true,
+}, {
+	Name:       "NotStrict",
+	Input:      "§a,§b\"c\n¶§\"d\"e\",§\"f",
+	Output:     [][]string{{"a", `b"c`}, {`d"e`, "f"}},
+	LazyQuotes: true,
+}, {
+	Name:       "MaxColumns",
+	Input:      "§a,§b,§c\n¶§\"d\",§e,§\"f\"\n",
+	Output:     [][]string{{"a", "b", "c"}, {"d", "e", "f"}},
+	MaxColumns: 3,
+}, {
+	Name:       "MaxColumnsExceeded",
+	Input:      "§a,§b,§c,∑§d\n",
+	Errors:     []error{&ParseError{Err: &LimitError{Err: ErrTooManyFields, Limit: 3}}},
+	MaxColumns: 3,
+}, {
+	Name:       "MaxColumnsExceededQuoted",
+	Input:      "§a,§b,§c,∑§\"d\"\n",
+	Errors:     []error{&ParseError{Err: &LimitError{Err: ErrTooManyFields, Limit: 3}}},
+	MaxColumns: 3,
+}, {
+	Name:           "MaxRecordBytes",
+	Input:          "§a,§b\n¶§\"c\n\",§d\n",
+	Output:         [][]string{{"a", "b"}, {"c\n", "d"}},
+	MaxRecordBytes: 7,
+}, {
+	Name:           "MaxRecordBytesExceeded",
+	Input:          "§abc,§d\n¶∑§abc,§de\n",
+	Output:         [][]string{{"abc", "d"}},
+	Errors:         []error{nil, &ParseError{Err: &LimitError{Err: ErrRecordTooLarge, Limit: 6}}},
+	MaxRecordBytes: 6,
+}, {
+	Name:           "MaxRecordBytesMultiline",
+	Input:          "§a,§\"b\nc\n∑d\",e\n",
+	Errors:         []error{&ParseError{Err: &LimitError{Err: ErrRecordTooLarge, Limit: 8}}},
+	MaxRecordBytes: 8,
+}, {
+	Name:        "QuoteEscape",
+	Input:       "§\"a^\"b^^\",§\"c\"\"d\",§e^\"f,§\"g^h^\n^\"\"\n",
+	Output:      [][]string{{`a"b^`, `c"d`, `e^"f`, "g^h^\n\""}},
+	QuoteEscape: '^',
+	LazyQuotes:  true,
+}, {
+	Name:       "AsymmetricQuotes",
+	Input:      "§«a,b»,§«c»»d«e»,§f»\n¶§«g\nh»,§\"i\"\n",
+	Output:     [][]string{{"a,b", "c»d«e", "f»"}, {"g\nh", `"i"`}},
+	QuoteOpen:  '«',
+	QuoteClose: '»',
+}, {
+	Name:       "AsymmetricQuotesBareOpen",
+	Input:      "§a∑«b»\n",
+	Errors:     []error{&ParseError{Err: ErrBareQuote, Leniency: "AllowBareQuotes"}},
+	QuoteOpen:  '«',
+	QuoteClose: '»',
+}, {
+	Name:       "AsymmetricQuotesTextAfterClose",
+	Input:      "§«a∑»b\n",
+	Errors:     []error{&ParseError{Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}},
+	QuoteOpen:  '«',
+	QuoteClose: '»',
+}, {
+	Name:        "AsymmetricQuotesEscape",
+	Input:       "§«a^»b^«»,§c\n",
+	Output:      [][]string{{"a»b^«", "c"}},
+	QuoteOpen:   '«',
+	QuoteClose:  '»',
+	QuoteEscape: '^',
+}, {
+	Name:       "AsymmetricQuotesCloseOnly",
+	Input:      "§\"a))),§\"b\")\n",
+	Output:     [][]string{{"a)", `b"`}},
+	QuoteClose: ')',
+}, {
+	Name:              "StrictQuoteEscape",
+	Input:             "§\"a^\"b\",§\"\",§c\n",
+	Output:            [][]string{{`a"b`, "", "c"}},
+	QuoteEscape:       '^',
+	StrictQuoteEscape: true,
+}, {
+	Name:              "StrictQuoteEscapeDoubled",
+	Input:             `§"a∑""b"`,
+	Errors:            []error{&ParseError{Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}},
+	QuoteEscape:       '^',
+	StrictQuoteEscape: true,
+}, {
+	Name:              "StrictQuoteEscapeUnset",
+	Input:             `§"a""b"`,
+	Output:            [][]string{{`a"b`}},
+	StrictQuoteEscape: true,
+}, {
+	Name:        "QuoteEscapeEnd",
+	Input:       "§\"a^\"∑",
+	Errors:      []error{&ParseError{Err: ErrQuote, Leniency: "AllowUnterminatedQuote"}},
+	QuoteEscape: '^',
+}, {
+	Name:        "QuoteEscapeSameAsQuote",
+	Input:       `a`,
+	Errors:      []error{errInvalidDelim},
+	QuoteEscape: '"',
+}, {
+	Name:        "QuoteEscapeWithEscape",
+	Input:       `a`,
+	Errors:      []error{errInvalidDelim},
+	QuoteEscape: '^',
+	Escape:      '\\',
 }, {
 	Name:   "BadComma1",
 	Comma:  '\n',
@@ -416,6 +1250,14 @@ func TestRead(t *testing.T) {
 		if tt.Comma != 0 {
 			r.Comma = tt.Comma
 		}
+		r.Separator = tt.Separator
+		r.CollapseDelimiters = tt.CollapseDelimiters
+		r.Whitespace = tt.Whitespace
+		r.AllowTrailingComma = tt.AllowTrailingComma
+		r.DoubledDelimiterEscape = tt.DoubledDelimiter
+		if tt.Quote != 0 {
+			r.Quote = tt.Quote
+		}
 		r.Comment = tt.Comment
 		if tt.UseFieldsPerRecord {
 			r.FieldsPerRecord = tt.FieldsPerRecord
@@ -423,7 +1265,34 @@ func TestRead(t *testing.T) {
 			r.FieldsPerRecord = -1
 		}
 		r.LazyQuotes = tt.LazyQuotes
+		r.AllowBareQuotes = tt.AllowBareQuotes
+		r.AllowTextAfterClosingQuote = tt.AllowTextAfter
+		r.AllowUnterminatedQuote = tt.AllowUnterminated
+		r.RelaxedOpenQuote = tt.RelaxedOpenQuote
+		r.Strict = tt.Strict
+		r.RequireCRLF = tt.RequireCRLF
+		r.Escape = tt.Escape
+		r.QuoteOpen = tt.QuoteOpen
+		r.QuoteClose = tt.QuoteClose
+		r.QuoteEscape = tt.QuoteEscape
+		r.StrictQuoteEscape = tt.StrictQuoteEscape
+		r.DecodeEscapes = tt.DecodeEscapes
+		r.DecodeBackslashEscapes = tt.BackslashEscapes
+		r.RejectUnknownEscapes = tt.RejectUnknown
+		r.BareCR = tt.BareCR
+		r.NormalizeLineEndings = tt.NormalizeLineEndings
+		r.RejectNUL = tt.RejectNUL
+		r.MaxQuotedLines = tt.MaxQuotedLines
+		r.InvalidUTF8 = tt.InvalidUTF8
+		r.BlankLineMode = tt.BlankLineMode
 		r.TrimLeadingSpace = tt.TrimLeadingSpace
+		r.TrimSpace = tt.TrimSpace
+		r.Null = tt.Null
+		r.InlineComments = tt.InlineComments
+		r.KeepCommentSpace = tt.KeepCommentSpace
+		r.MaxFieldSize = tt.MaxFieldSize
+		r.MaxRecordBytes = tt.MaxRecordBytes
+		r.MaxColumns = tt.MaxColumns
 		r.ReuseRecord = tt.ReuseRecord
 		return r, positions, errPositions, input
 	}
@@ -489,10 +1358,549 @@ func TestRead(t *testing.T) {
 					}
 				}
 			}
+
+			// Check that ReadBytes returns the same records as Read.
+			r, _, _, _ = newReader(tt)
+			for recNum := 0; ; recNum++ {
+				rec, err := r.ReadBytes()
+				var wantErr error
+				if recNum < len(tt.Errors) && tt.Errors[recNum] != nil {
+					wantErr = errorWithPosition(tt.Errors[recNum], recNum, positions, errPositions)
+				} else if recNum >= len(tt.Output) {
+					wantErr = io.EOF
+				}
+				if !reflect.DeepEqual(err, wantErr) {
+					t.Fatalf("ReadBytes() error at record %d:\ngot %v (%#v)\nwant %v (%#v)", recNum, err, err, wantErr, wantErr)
+				}
+				if err != nil && !errors.Is(err, ErrFieldCount) {
+					break
+				}
+				var got []string
+				for _, field := range rec {
+					got = append(got, string(field))
+				}
+				if want := tt.Output[recNum]; !reflect.DeepEqual(got, want) {
+					t.Errorf("ReadBytes vs ReadAll mismatch;\ngot %q\nwant %q", got, want)
+				}
+			}
+
+			// Check that the fields of NewBytesReader, which share the
+			// memory of the input, are the same.
+			r, _, _, _ = newReader(tt)
+			data := []byte(input)
+			r.r.Reset(bytes.NewReader(data))
+			r.data = data
+			if all, berr := r.ReadAll(); !reflect.DeepEqual(all, out) || !reflect.DeepEqual(berr, err) {
+				t.Errorf("NewBytesReader ReadAll() = %q, %v; want %q, %v", all, berr, out, err)
+			}
+		})
+	}
+}
+
+func TestAcceptCR(t *testing.T) {
+	input := "a,b\rc,d\ne,f\r\ng,\"h\ri\"\r\"j\r\nk\",l\rm,n"
+	want := [][]string{{"a", "b"}, {"c", "d"}, {"e", "f"}, {"g", "h\ri"}, {"j\nk", "l"}, {"m", "n"}}
+	wantPos := [][][2]int{{{1, 1}, {1, 3}}, {{2, 1}, {2, 3}}, {{3, 1}, {3, 3}}, {{4, 1}, {4, 3}}, {{6, 1}, {7, 4}}, {{8, 1}, {8, 3}}}
+	r := NewReader(strings.NewReader(input))
+	r.AcceptCR = true
+	for i := 0; ; i++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Fatalf("got %d records, want %d", i, len(want))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(rec, want[i]) {
+			t.Fatalf("record %d: got %q want %q", i, rec, want[i])
+		}
+		for j := range rec {
+			line, col := r.FieldPos(j)
+			if got := [2]int{line, col}; got != wantPos[i][j] {
+				t.Errorf("record %d field %d: position %v, want %v", i, j, got, wantPos[i][j])
+			}
+		}
+	}
+	if got, want := r.InputOffset(), int64(len(input)); got != want {
+		t.Errorf("InputOffset = %d, want %d", got, want)
+	}
+
+	// Line numbers in errors count lone carriage returns.
+	r = NewReader(strings.NewReader("a\rb\r\nc\"d\n"))
+	r.AcceptCR = true
+	_, err := r.ReadAll()
+	want2 := &ParseError{StartLine: 3, Line: 3, Column: 2, Err: ErrBareQuote, Leniency: "AllowBareQuotes"}
+	if !reflect.DeepEqual(err, want2) {
+		t.Errorf("got error %v, want %v", err, want2)
+	}
+
+	// Lines holding only a terminator are blank.
+	r = NewReader(strings.NewReader("a\r\r\r\n\rb"))
+	r.AcceptCR = true
+	out, err := r.ReadAll()
+	if err != nil || !reflect.DeepEqual(out, [][]string{{"a"}, {"b"}}) {
+		t.Errorf("got %q, %v; want [[a] [b]]", out, err)
+	}
+
+	// A \r\n split across reads is still one terminator.
+	r = NewReader(io.MultiReader(strings.NewReader("a\r"), strings.NewReader("\nb\r")))
+	r.AcceptCR = true
+	out, err = r.ReadAll()
+	if err != nil || !reflect.DeepEqual(out, [][]string{{"a"}, {"b"}}) {
+		t.Errorf("got %q, %v; want [[a] [b]]", out, err)
+	}
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	records := [][]string{{"a\r\nb", "c\rd\ne"}, {"f", "g\r\n"}}
+	for _, tt := range []struct {
+		name       string
+		terminator string
+		bareCR     BareCRMode
+		want       [][]string
+	}{
+		// With UseCRLF, the Writer drops carriage returns in fields.
+		{"CRLF", "", BareCRKeep, [][]string{{"a\nb", "cd\ne"}, {"f", "g\n"}}},
+		{"Terminator", ";;", BareCRKeep, [][]string{{"a\nb", "c\rd\ne"}, {"f", "g\n"}}},
+		{"BareCRNewline", ";;", BareCRNewline, [][]string{{"a\nb", "c\nd\ne"}, {"f", "g\n"}}},
+		{"BareCRStrip", ";;", BareCRStrip, [][]string{{"a\nb", "cd\ne"}, {"f", "g\n"}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			w := NewWriter(&b)
+			w.UseCRLF = true
+			w.Terminator = tt.terminator
+			if err := w.WriteAll(records); err != nil {
+				t.Fatalf("WriteAll error = %v", err)
+			}
+			r := NewReader(strings.NewReader(b.String()))
+			r.Terminator = tt.terminator
+			r.NormalizeLineEndings = true
+			r.BareCR = tt.bareCR
+			got, err := r.ReadAll()
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadAll = %q, %v, want %q", got, err, tt.want)
+			}
+			if n := r.InputOffset(); n != int64(b.Len()) {
+				t.Errorf("InputOffset = %d, want %d", n, b.Len())
+			}
+		})
+	}
+}
+
+func TestTerminator(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Input      string
+		Terminator string
+		Output     [][]string
+	}{{
+		Name:       "NUL",
+		Input:      "a,b\x00c,d\x00",
+		Terminator: "\x00",
+		Output:     [][]string{{"a", "b"}, {"c", "d"}},
+	}, {
+		Name:       "SemicolonNewline",
+		Input:      "a,b\nc;\nd;e,f;\n",
+		Terminator: ";\n",
+		Output:     [][]string{{"a", "b\nc"}, {"d;e", "f"}},
+	}, {
+		Name:       "Unterminated",
+		Input:      "a;;b",
+		Terminator: ";;",
+		Output:     [][]string{{"a"}, {"b"}},
+	}, {
+		Name:       "SelfPrefix",
+		Input:      "a;b;;c;;;d;;",
+		Terminator: ";;",
+		Output:     [][]string{{"a;b"}, {"c"}, {";d"}},
+	}, {
+		Name:       "Quoted",
+		Input:      `"a;;b",c;;"d"";;"` + ";;",
+		Terminator: ";;",
+		Output:     [][]string{{"a;;b", "c"}, {`d";;`}},
+	}, {
+		Name:       "Blank",
+		Input:      "|a||b|",
+		Terminator: "|",
+		Output:     [][]string{{"a"}, {"b"}},
+	}, {
+		Name:       "CRLFIsData",
+		Input:      "a\r\nb\r.c\r",
+		Terminator: ".",
+		Output:     [][]string{{"a\r\nb\r"}, {"c\r"}},
+	}, {
+		// The terminator straddles the read buffer boundary.
+		Name:       "BufferBoundary",
+		Input:      strings.Repeat("x", 4095) + "<>y<>",
+		Terminator: "<>",
+		Output:     [][]string{{strings.Repeat("x", 4095)}, {"y"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(tt.Input))
+			r.Terminator = tt.Terminator
+			r.FieldsPerRecord = -1
+			out, err := r.ReadAll()
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if !reflect.DeepEqual(out, tt.Output) {
+				t.Fatalf("got %q want %q", out, tt.Output)
+			}
+			if got, want := r.InputOffset(), int64(len(tt.Input)); got != want {
+				t.Errorf("InputOffset = %d, want %d", got, want)
+			}
+		})
+	}
+
+	// Line numbers count terminated records.
+	r := NewReader(strings.NewReader("a;b;c\"d;"))
+	r.Terminator = ";"
+	_, err := r.ReadAll()
+	want := &ParseError{StartLine: 3, Line: 3, Column: 2, Err: ErrBareQuote, Leniency: "AllowBareQuotes"}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("got error %v, want %v", err, want)
+	}
+
+	for _, term := range []string{`;"`, ",;", "x,", "\xff"} {
+		r := NewReader(strings.NewReader("a"))
+		r.Terminator = term
+		if _, err := r.Read(); err != errInvalidDelim {
+			t.Errorf("Terminator %q: got error %v, want %v", term, err, errInvalidDelim)
+		}
+	}
+}
+
+func TestInputOffsetResume(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Input string
+		Init  func(*Reader)
+	}{{
+		Name:  "Simple",
+		Input: "a,b\nc,d\r\ne,f",
+	}, {
+		Name:  "MultiLine",
+		Input: "\"a\nb\",c\n\nd,\"e\r\n\r\nf\"\n#x\ng,h\n",
+		Init:  func(r *Reader) { r.Comment = '#' },
+	}, {
+		Name:  "Terminator",
+		Input: "a,\"b;;c\";;d,e;;;;f,g",
+		Init:  func(r *Reader) { r.Terminator = ";;" },
+	}, {
+		Name:  "Escape",
+		Input: "a\\\nb,c\nd,e\\,f\n",
+		Init:  func(r *Reader) { r.Escape = '\\' },
+	}, {
+		Name:  "AcceptCR",
+		Input: "a,b\r\"c\rd\",e\r\nf,g",
+		Init:  func(r *Reader) { r.AcceptCR = true },
+	}}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			newReader := func(s string) *Reader {
+				r := NewReader(strings.NewReader(s))
+				r.FieldsPerRecord = -1
+				if tt.Init != nil {
+					tt.Init(r)
+				}
+				return r
+			}
+			all, err := newReader(tt.Input).ReadAll()
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			r := newReader(tt.Input)
+			for i := range all {
+				if _, err := r.Read(); err != nil {
+					t.Fatalf("Read %d: %v", i, err)
+				}
+				off := r.InputOffset()
+				rest, err := newReader(tt.Input[off:]).ReadAll()
+				if err != nil {
+					t.Fatalf("ReadAll from offset %d: %v", off, err)
+				}
+				if len(rest)+len(all[i+1:]) > 0 && !reflect.DeepEqual(rest, all[i+1:]) {
+					t.Fatalf("resuming at offset %d after record %d: got %q want %q", off, i, rest, all[i+1:])
+				}
+			}
 		})
 	}
 }
 
+func TestBlankLineErrorContinue(t *testing.T) {
+	r := NewReader(strings.NewReader("a\n\n\nb\n"))
+	r.BlankLineMode = BlankLineError
+	var got []string
+	var errs []int
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if pe, ok := err.(*ParseError); ok && pe.Err == ErrBlankLine {
+			errs = append(errs, pe.Line)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		got = append(got, rec...)
+	}
+	if !reflect.DeepEqual(got, []string{"a", "b"}) || !reflect.DeepEqual(errs, []int{2, 3}) {
+		t.Errorf("got records %q and blank lines %v, want [a b] and [2 3]", got, errs)
+	}
+}
+
+// unterminatedReader produces an opening quote followed by n lines of
+// filler without ever closing the quote, and counts the bytes read.
+type unterminatedReader struct {
+	n, read int
+	line    []byte
+	pending []byte
+}
+
+func (u *unterminatedReader) Read(p []byte) (int, error) {
+	if len(u.pending) == 0 {
+		if u.n == 0 {
+			return 0, io.EOF
+		}
+		u.n--
+		u.pending = u.line
+	}
+	n := copy(p, u.pending)
+	u.pending = u.pending[n:]
+	u.read += n
+	return n, nil
+}
+
+func TestMaxFieldSizeUnterminated(t *testing.T) {
+	const limit = 1 << 20
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	u := &unterminatedReader{n: 100 << 10, line: line, pending: []byte(`"`)} // 100MB
+	r := NewReader(u)
+	r.MaxFieldSize = limit
+	_, err := r.Read()
+	if pe, ok := err.(*ParseError); !ok || pe.Err != ErrFieldTooLarge || pe.Line != 1 || pe.Column != 1 {
+		t.Fatalf("Read error = %v, want ErrFieldTooLarge at line 1, column 1", err)
+	}
+	if u.read > 2*limit {
+		t.Errorf("read %d bytes of input, want at most %d", u.read, 2*limit)
+	}
+	if c := cap(r.recordBuffer); c > 2*limit {
+		t.Errorf("record buffer grew to %d bytes, want at most %d", c, 2*limit)
+	}
+}
+
+func TestMaxFieldSizeContinue(t *testing.T) {
+	r := NewReader(strings.NewReader("a,abcde,f\ng,h\n"))
+	r.MaxFieldSize = 4
+	r.FieldsPerRecord = -1
+	if _, err := r.Read(); !errors.Is(err, ErrFieldTooLarge) {
+		t.Fatalf("Read error = %v, want ErrFieldTooLarge", err)
+	}
+	rec, err := r.Read()
+	if err != nil || !reflect.DeepEqual(rec, []string{"g", "h"}) {
+		t.Errorf("Read after ErrFieldTooLarge = %q, %v; want [g h]", rec, err)
+	}
+}
+
+func TestReadBytesAllocs(t *testing.T) {
+	r := NewReader(strings.NewReader(strings.Repeat("12,\"a \"\"b\"\"\",345\n", 200)))
+	if _, err := r.ReadBytes(); err != nil {
+		t.Fatal(err)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		rec, err := r.ReadBytes()
+		if err != nil || string(rec[1]) != `a "b"` {
+			t.Fatalf("ReadBytes() = %q, %v", rec, err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("ReadBytes allocated %v times per record, want 0", allocs)
+	}
+}
+
+func TestLimitError(t *testing.T) {
+	r := NewReader(strings.NewReader(strings.Repeat(",", 1e6) + "\na,b\n"))
+	r.MaxColumns = 100
+	r.FieldsPerRecord = -1
+	_, err := r.Read()
+	var le *LimitError
+	if !errors.Is(err, ErrTooManyFields) || !errors.As(err, &le) || le.Limit != 100 {
+		t.Fatalf("Read error = %v, want ErrTooManyFields with limit 100", err)
+	}
+	if want := "parse error on line 1, column 101: too many fields (limit 100)"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if c := cap(r.fieldIndexes); c > 1000 {
+		t.Errorf("fieldIndexes grew to %d entries", c)
+	}
+	rec, err := r.Read()
+	if err != nil || !reflect.DeepEqual(rec, []string{"a", "b"}) {
+		t.Errorf("Read after LimitError = %q, %v; want [a b]", rec, err)
+	}
+}
+
+func TestLimitErrorLongLine(t *testing.T) {
+	const limit = 1000
+	for _, term := range []string{"", "\r", "||"} {
+		long := "a,\"" + strings.Repeat("x", 1<<20) // No line break nor closing quote
+		end := "\n"
+		if term != "" {
+			end = term
+		}
+		r := NewReader(strings.NewReader(long + end + "b,c" + end))
+		r.MaxRecordBytes = limit
+		r.AcceptCR = term == "\r"
+		if term == "||" {
+			r.Terminator = term
+		}
+		_, err := r.Read()
+		var pe *ParseError
+		if !errors.As(err, &pe) || !errors.Is(err, ErrRecordTooLarge) || pe.Line != 1 {
+			t.Fatalf("terminator %q: Read error = %v, want ErrRecordTooLarge on line 1", term, err)
+		}
+		if c := cap(r.rawBuffer); c > 64<<10 {
+			t.Errorf("terminator %q: line buffer grew to %d bytes", term, c)
+		}
+		if got, want := r.InputOffset(), int64(len(long+end)); got != want {
+			t.Errorf("terminator %q: InputOffset = %d, want %d", term, got, want)
+		}
+		rec, err := r.Read()
+		if err != nil || !reflect.DeepEqual(rec, []string{"b", "c"}) {
+			t.Errorf("terminator %q: Read after LimitError = %q, %v; want [b c]", term, rec, err)
+		}
+	}
+}
+
+func TestReadN(t *testing.T) {
+	const input = "# header comment\na,b\n\n# more\nc,d\n\ne,f\n"
+	tests := []struct {
+		n    int
+		want [][]string
+		rest [][]string
+	}{
+		{0, nil, [][]string{{"a", "b"}, {"c", "d"}, {"e", "f"}}},
+		{2, [][]string{{"a", "b"}, {"c", "d"}}, [][]string{{"e", "f"}}},
+		{3, [][]string{{"a", "b"}, {"c", "d"}, {"e", "f"}}, nil},
+		{10, [][]string{{"a", "b"}, {"c", "d"}, {"e", "f"}}, nil},
+	}
+	for _, tt := range tests {
+		r := NewReader(strings.NewReader(input))
+		r.Comment = '#'
+		got, err := r.ReadN(tt.n)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadN(%d) = %q, %v; want %q", tt.n, got, err, tt.want)
+			continue
+		}
+		rest, err := r.ReadAll()
+		if err != nil || !reflect.DeepEqual(rest, tt.rest) {
+			t.Errorf("ReadAll after ReadN(%d) = %q, %v; want %q", tt.n, rest, err, tt.rest)
+		}
+	}
+
+	r := NewReader(strings.NewReader("a,b\nc\n"))
+	if got, err := r.ReadN(5); got != nil || !errors.Is(err, ErrFieldCount) {
+		t.Errorf("ReadN(5) = %q, %v; want ErrFieldCount", got, err)
+	}
+}
+
+func TestStripBOM(t *testing.T) {
+	const bom = "\ufeff"
+	tests := []struct {
+		name  string
+		input string
+		want  [][]string
+		err   error
+	}{
+		{"NoBOM", "id,name\n1,a\n", [][]string{{"id", "name"}, {"1", "a"}}, nil},
+		{"BOM", bom + "id,name\n1,a\n", [][]string{{"id", "name"}, {"1", "a"}}, nil},
+		{"BOMComment", bom + "# comment\nid\n", [][]string{{"id"}}, nil},
+		{"BOMOnly", bom, nil, nil},
+		{"LaterBOM", "a\n" + bom + "b\n", [][]string{{"a"}, {bom + "b"}}, nil},
+		{"ShortInput", "\xef\xbb", [][]string{{"\xef\xbb"}}, nil},
+		{"UTF16LE", "\xff\xfei\x00d\x00", nil, ErrUTF16},
+		{"UTF16BE", "\xfe\xff\x00i\x00d", nil, ErrUTF16},
+	}
+	for _, tt := range tests {
+		r := NewReader(strings.NewReader(tt.input))
+		r.StripBOM = true
+		r.Comment = '#'
+		got, err := r.ReadAll()
+		if err != tt.err || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ReadAll() = %q, %v; want %q, %v", tt.name, got, err, tt.want, tt.err)
+		}
+		if err == nil && r.InputOffset() != int64(len(tt.input)) {
+			t.Errorf("%s: InputOffset() = %d, want %d", tt.name, r.InputOffset(), len(tt.input))
+		}
+	}
+
+	r := NewReader(strings.NewReader(bom + "a\n"))
+	if rec, err := r.Read(); err != nil || rec[0] != bom+"a" {
+		t.Errorf("Read() without StripBOM = %q, %v; want BOM kept", rec, err)
+	}
+}
+
+func TestNoQuote(t *testing.T) {
+	r := NewReader(strings.NewReader(`"a,b",c"d` + "\n"))
+	r.Quote = 0
+	out, err := r.ReadAll()
+	want := [][]string{{`"a`, `b"`, `c"d`}}
+	if err != nil || !reflect.DeepEqual(out, want) {
+		t.Errorf("got %q, %v; want %q", out, err, want)
+	}
+}
+
+type closeReader struct {
+	*strings.Reader
+	closed int
+}
+
+func (c *closeReader) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestReaderClose(t *testing.T) {
+	c := &closeReader{Reader: strings.NewReader("a,b\nc,d\n")}
+	r := NewReader(c)
+	if _, err := r.Read(); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if c.closed != 1 {
+		t.Errorf("underlying reader closed %d times, want 1", c.closed)
+	}
+	if _, err := r.Read(); err != errClosed {
+		t.Errorf("Read after Close: got %v, want %v", err, errClosed)
+	}
+	if _, err := r.ReadAll(); err != errClosed {
+		t.Errorf("ReadAll after Close: got %v, want %v", err, errClosed)
+	}
+	if err := r.Close(); err != errClosed {
+		t.Errorf("second Close: got %v, want %v", err, errClosed)
+	}
+	if c.closed != 1 {
+		t.Errorf("underlying reader closed %d times, want 1", c.closed)
+	}
+
+	// Non-closers are left alone.
+	r = NewReader(strings.NewReader("a\n"))
+	if err := r.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if _, err := r.Read(); err != errClosed {
+		t.Errorf("Read after Close: got %v, want %v", err, errClosed)
+	}
+}
+
 // firstError returns the first non-nil error in errs,
 // with the position adjusted according to the error's
 // index inside positions.
@@ -660,3 +2068,901 @@ xxxxxxxxxxxxxxxxxxxxxxxx,yyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyy,zzzzzzzzzzzzzzzzzzzzzz
 xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx,yyyyyyyyyyyyyyyyyyyyyyyyyyyyyyyy,zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz,wwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwwww,vvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvv
 `, 3))
 }
+
+// wideRow is a record of 400 columns, for BenchmarkReadSelectColumns.
+var wideRow = func() string {
+	fields := make([]string, 400)
+	for i := range fields {
+		fields[i] = fmt.Sprintf("value %d", i)
+	}
+	return strings.Join(fields, ",") + "\n"
+}()
+
+func BenchmarkReadSelectColumns(b *testing.B) {
+	b.Run("All", func(b *testing.B) {
+		benchmarkRead(b, nil, wideRow)
+	})
+	b.Run("Six", func(b *testing.B) {
+		benchmarkRead(b, func(r *Reader) { r.SelectColumns = []int{0, 7, 42, 100, 250, 399} }, wideRow)
+	})
+}
+
+func BenchmarkReadBytes(b *testing.B) {
+	b.ReportAllocs()
+	r := NewReader(&nTimes{s: benchmarkCSVData, n: b.N})
+	for {
+		_, err := r.ReadBytes()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// millionRows is a 1M-row input for comparing ReadBytes with Read.
+var millionRows = sync.OnceValue(func() []byte {
+	var b strings.Builder
+	for i := 0; i < 1_000_000; i++ {
+		fmt.Fprintf(&b, "%d,widget %d,\"say \"\"hi\"\"\",%d.%02d\n", i, i%977, i%10000, i%100)
+	}
+	return []byte(b.String())
+})
+
+func BenchmarkReadMillionRows(b *testing.B) {
+	data := millionRows()
+	b.Run("ReuseRecord", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r := NewReader(bytes.NewReader(data))
+			r.ReuseRecord = true
+			for {
+				if _, err := r.Read(); err == io.EOF {
+					break
+				} else if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("ReadBytes", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r := NewReader(bytes.NewReader(data))
+			for {
+				if _, err := r.ReadBytes(); err == io.EOF {
+					break
+				} else if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+func TestOnShortLongRow(t *testing.T) {
+	const headerless = "a,b,c\nd\ne,f,g,h\ni,j,k\n"
+	tests := []struct {
+		name       string
+		input      string
+		header     bool
+		fields     int
+		short      ShortRowMode
+		long       LongRowMode
+		reuse      bool
+		want       [][]string
+		wantCounts []int
+		wantErrs   []bool
+	}{{
+		name:       "Default",
+		input:      headerless,
+		want:       [][]string{{"a", "b", "c"}, {"d"}, {"e", "f", "g", "h"}, {"i", "j", "k"}},
+		wantCounts: []int{3, 1, 4, 3},
+		wantErrs:   []bool{false, true, true, false},
+	}, {
+		name:       "PadTruncate",
+		input:      headerless,
+		short:      ShortRowPad,
+		long:       LongRowTruncate,
+		want:       [][]string{{"a", "b", "c"}, {"d", "", ""}, {"e", "f", "g"}, {"i", "j", "k"}},
+		wantCounts: []int{3, 1, 4, 3},
+		wantErrs:   []bool{false, false, false, false},
+	}, {
+		name:       "PadTruncateReuseRecord",
+		input:      "a\nb,c,d,e\nf,g\n",
+		fields:     3,
+		short:      ShortRowPad,
+		long:       LongRowTruncate,
+		reuse:      true,
+		want:       [][]string{{"a", "", ""}, {"b", "c", "d"}, {"f", "g", ""}},
+		wantCounts: []int{1, 4, 2},
+		wantErrs:   []bool{false, false, false},
+	}, {
+		name:       "Keep",
+		input:      headerless,
+		short:      ShortRowKeep,
+		long:       LongRowKeep,
+		want:       [][]string{{"a", "b", "c"}, {"d"}, {"e", "f", "g", "h"}, {"i", "j", "k"}},
+		wantCounts: []int{3, 1, 4, 3},
+		wantErrs:   []bool{false, false, false, false},
+	}, {
+		name:       "PadKeepsLongError",
+		input:      headerless,
+		short:      ShortRowPad,
+		want:       [][]string{{"a", "b", "c"}, {"d", "", ""}, {"e", "f", "g", "h"}, {"i", "j", "k"}},
+		wantCounts: []int{3, 1, 4, 3},
+		wantErrs:   []bool{false, false, true, false},
+	}, {
+		name:       "Header",
+		input:      "id,name\n1\n2,b,extra\n",
+		header:     true,
+		short:      ShortRowPad,
+		long:       LongRowTruncate,
+		want:       [][]string{{"1", ""}, {"2", "b"}},
+		wantCounts: []int{1, 3},
+		wantErrs:   []bool{false, false},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(tt.input))
+			r.FieldsPerRecord = tt.fields
+			r.OnShortRow = tt.short
+			r.OnLongRow = tt.long
+			r.ReuseRecord = tt.reuse
+			if tt.header {
+				if _, err := r.ReadHeader(); err != nil {
+					t.Fatalf("ReadHeader: %v", err)
+				}
+			}
+			for i, want := range tt.want {
+				rec, err := r.Read()
+				if !reflect.DeepEqual(rec, want) {
+					t.Errorf("record %d = %q, want %q", i, rec, want)
+				}
+				if (err != nil) != tt.wantErrs[i] || err != nil && !errors.Is(err, ErrFieldCount) {
+					t.Errorf("record %d error = %v, want error %v", i, err, tt.wantErrs[i])
+				}
+				if got := r.FieldCount(); got != tt.wantCounts[i] {
+					t.Errorf("record %d FieldCount = %d, want %d", i, got, tt.wantCounts[i])
+				}
+				for j := range rec {
+					r.FieldPos(j) // Must not panic for padded fields
+				}
+			}
+			if _, err := r.Read(); err != io.EOF {
+				t.Errorf("Read at end = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestRepairQuotes(t *testing.T) {
+	const input = "item,material\n" +
+		"5\" pipe fitting,steel\n" +
+		"\"a \"b\" c\",d\n" +
+		"bolt,\"zinc\n" +
+		"nut,\"brass"
+	r := NewReader(strings.NewReader(input))
+	r.RepairQuotes = true
+	var repairs []ParseError
+	r.OnRepair = func(err *ParseError) { repairs = append(repairs, *err) }
+	got, err := r.ReadAll()
+	want := [][]string{
+		{"item", "material"},
+		{`5" pipe fitting`, "steel"},
+		{`a "b" c`, "d"},
+		{"bolt", "zinc"},
+		{"nut", "brass"},
+	}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadAll = %q, %v; want %q", got, err, want)
+	}
+	wantRepairs := []ParseError{
+		{StartLine: 2, Line: 2, Column: 2, Err: ErrBareQuote},
+		{StartLine: 3, Line: 3, Column: 4, Err: ErrQuote},
+		{StartLine: 3, Line: 3, Column: 6, Err: ErrQuote},
+		{StartLine: 4, Line: 4, Column: 6, Err: ErrQuote},
+		{StartLine: 5, Line: 5, Column: 5, Err: ErrQuote},
+	}
+	if !reflect.DeepEqual(repairs, wantRepairs) {
+		t.Errorf("repairs = %v, want %v", repairs, wantRepairs)
+	}
+	if r.Repairs() != len(wantRepairs) {
+		t.Errorf("Repairs() = %d, want %d", r.Repairs(), len(wantRepairs))
+	}
+}
+
+func TestSkipRows(t *testing.T) {
+	const input = "Statement for account 1234\n" +
+		"\"Note: amounts, in EUR,\n" +
+		"exclude fees\"\n" +
+		"Branch: \"Main\" st.\n" +
+		"Period,2024\n" +
+		"date,amount\n" +
+		"2024-01-02,10\n" +
+		"2024-01-03,\"5\"x\n"
+	r := NewReader(strings.NewReader(input))
+	r.SkipRows = 4
+	header, err := r.ReadHeader()
+	if want := []string{"date", "amount"}; err != nil || !reflect.DeepEqual(header, want) {
+		t.Fatalf("ReadHeader = %q, %v; want %q", header, err, want)
+	}
+	if got, want := r.InputOffset(), int64(strings.Index(input, "2024-01-02")); got != want {
+		t.Errorf("InputOffset = %d, want %d", got, want)
+	}
+	if rec, err := r.Read(); err != nil || !reflect.DeepEqual(rec, []string{"2024-01-02", "10"}) {
+		t.Errorf("Read = %q, %v", rec, err)
+	}
+	_, err = r.Read()
+	want := &ParseError{StartLine: 8, Line: 8, Column: 14, Err: ErrQuote, Leniency: "AllowTextAfterClosingQuote"}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("Read error = %v, want %v", err, want)
+	}
+}
+
+func TestMaxRecords(t *testing.T) {
+	const input = "id,name\n# comment\n1,a\n\n2,b\n3,c\n4,d\n"
+	r := NewReader(strings.NewReader(input))
+	r.Comment = '#'
+	r.MaxRecords = 2
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatalf("ReadHeader: %v", err)
+	}
+	got, err := r.ReadAll()
+	if want := [][]string{{"1", "a"}, {"2", "b"}}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadAll = %q, %v; want %q", got, err, want)
+	}
+	if got, want := r.InputOffset(), int64(strings.Index(input, "3,c")); got != want {
+		t.Errorf("InputOffset = %d, want %d", got, want)
+	}
+	if rec, err := r.Read(); rec != nil || err != io.EOF {
+		t.Errorf("Read past MaxRecords = %q, %v; want nil, io.EOF", rec, err)
+	}
+	if got, want := r.InputOffset(), int64(strings.Index(input, "3,c")); got != want {
+		t.Errorf("InputOffset after io.EOF = %d, want %d", got, want)
+	}
+	r.MaxRecords = 0
+	if rec, err := r.Read(); err != nil || !reflect.DeepEqual(rec, []string{"3", "c"}) {
+		t.Errorf("Read after raising MaxRecords = %q, %v; want [3 c]", rec, err)
+	}
+}
+
+func TestRecordOffset(t *testing.T) {
+	const input = "# comment\r\n" +
+		"  a,  b\r\n" +
+		"\r\n" +
+		"\"multi\r\nline\",c\r\n" +
+		"# another\r\n" +
+		"\r\n" +
+		"  d,e"
+	path := filepath.Join(t.TempDir(), "offsets.csv")
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	newReader := func() *Reader {
+		r := NewReader(f)
+		r.Comment = '#'
+		r.TrimLeadingSpace = true
+		return r
+	}
+
+	r := newReader()
+	var records [][]string
+	var offsets []int64
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+		offsets = append(offsets, r.RecordOffset())
+	}
+	wantOffsets := []int64{
+		int64(strings.Index(input, "  a")),
+		int64(strings.Index(input, `"multi`)),
+		int64(strings.Index(input, "  d")),
+	}
+	if !reflect.DeepEqual(offsets, wantOffsets) {
+		t.Errorf("RecordOffset = %v, want %v", offsets, wantOffsets)
+	}
+	for i, off := range offsets {
+		if _, err := f.Seek(off, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		rec, err := newReader().Read()
+		if err != nil || !reflect.DeepEqual(rec, records[i]) {
+			t.Errorf("record at offset %d = %q, %v; want %q", off, rec, err, records[i])
+		}
+	}
+}
+
+func TestErrorSnippets(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"BareQuote", "a,b\nc,\td\"e\n", "c,\td\"e\n  \t ^"},
+		{"FieldCount", "a,b\r\nc\r\n", "c\n^"},
+		{"MultiLine", "a,\"b\nc\" d\n", "c\" d\n ^"},
+		{"Long", long + "\"" + long + "\n", "..." + long[:128] + "\"" + long[:127] + "...\n" + strings.Repeat(" ", 131) + "^"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(tt.input))
+			r.ErrorSnippets = true
+			_, err := r.ReadAll()
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("ReadAll error = %v, want a ParseError", err)
+			}
+			if pe.Snippet != tt.want {
+				t.Errorf("Snippet =\n%s\nwant\n%s", pe.Snippet, tt.want)
+			}
+		})
+	}
+
+	_, err := NewReader(strings.NewReader("a,\"secret\"x\n")).Read()
+	if pe, ok := err.(*ParseError); !ok || pe.Snippet != "" {
+		t.Errorf("Read error without ErrorSnippets = %#v, want no Snippet", err)
+	}
+}
+
+func TestReadRaw(t *testing.T) {
+	const input = "# comment\r\n" +
+		"a,\"b\"\"c\"\r\n" +
+		"\n" +
+		"\"multi\r\nline\",d\r\n" +
+		"e,\"f\"g\n" +
+		"h"
+	r := NewReader(strings.NewReader(input))
+	r.Comment = '#'
+	tests := []struct {
+		record []string
+		raw    string
+		err    error
+	}{
+		{[]string{"a", `b"c`}, "a,\"b\"\"c\"\r\n", nil},
+		{[]string{"multi\nline", "d"}, "\"multi\r\nline\",d\r\n", nil},
+		{[]string{"e"}, "e,\"f\"g\n", ErrQuote},
+		{[]string{"h"}, "h", ErrFieldCount},
+	}
+	for i, tt := range tests {
+		record, raw, err := r.ReadRaw()
+		if !reflect.DeepEqual(record, tt.record) || string(raw) != tt.raw || !errors.Is(err, tt.err) {
+			t.Errorf("ReadRaw #%d = %q, %q, %v; want %q, %q, %v", i, record, raw, err, tt.record, tt.raw, tt.err)
+		}
+	}
+	if record, raw, err := r.ReadRaw(); record != nil || raw != nil || err != io.EOF {
+		t.Errorf("ReadRaw at end = %q, %q, %v; want nil, nil, io.EOF", record, raw, err)
+	}
+}
+
+func TestReaderReset(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b,c\nd,e,f\ng"))
+	r.LazyQuotes = true
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if r.FieldsPerRecord != 3 {
+		t.Fatalf("FieldsPerRecord = %d, want 3 inferred", r.FieldsPerRecord)
+	}
+
+	r.Reset(strings.NewReader("x,y\n1,2\"\n"))
+	if r.FieldsPerRecord != 0 || !r.LazyQuotes || r.Comma != ',' {
+		t.Errorf("after Reset: FieldsPerRecord = %d, LazyQuotes = %v, Comma = %q; want 0, true, ','", r.FieldsPerRecord, r.LazyQuotes, r.Comma)
+	}
+	if header, err := r.ReadHeader(); err != nil || !reflect.DeepEqual(header, []string{"x", "y"}) {
+		t.Errorf("ReadHeader after Reset = %q, %v; want [x y]", header, err)
+	}
+	rec, err := r.Read()
+	if err != nil || !reflect.DeepEqual(rec, []string{"1", `2"`}) {
+		t.Errorf("Read after Reset = %q, %v", rec, err)
+	}
+	if line, col := r.FieldPos(1); line != 2 || col != 3 {
+		t.Errorf("FieldPos(1) = %d, %d; want 2, 3", line, col)
+	}
+	if got, want := r.InputOffset(), int64(len("x,y\n1,2\"\n")); got != want {
+		t.Errorf("InputOffset = %d, want %d", got, want)
+	}
+
+	// An explicit FieldsPerRecord is kept.
+	r.FieldsPerRecord = 3
+	r.Reset(strings.NewReader("a,b\n"))
+	if _, err := r.Read(); !errors.Is(err, ErrFieldCount) {
+		t.Errorf("Read with FieldsPerRecord 3 = %v, want ErrFieldCount", err)
+	}
+}
+
+func TestInternStrings(t *testing.T) {
+	long := strings.Repeat("z", maxInternLen)
+	input := "US,open,\n\"US\",closed,\nDE,open," + long + "\n"
+	r := NewReader(strings.NewReader(input))
+	r.InternStrings = true
+	got, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll error = %v", err)
+	}
+	want, _ := NewReader(strings.NewReader(input)).ReadAll()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadAll = %q, want %q", got, want)
+	}
+	if unsafe.StringData(got[0][0]) != unsafe.StringData(got[1][0]) || unsafe.StringData(got[0][1]) != unsafe.StringData(got[2][1]) {
+		t.Errorf("repeated values do not share memory")
+	}
+	if _, ok := r.interned[long]; ok {
+		t.Errorf("field of %d bytes was interned", len(long))
+	}
+}
+
+// lowCardinalityRows is a 1M-row input holding 5 distinct values.
+var lowCardinalityRows = sync.OnceValue(func() []byte {
+	values := []string{"pending", "active", "suspended", "closed", "deleted"}
+	var b strings.Builder
+	for i := 0; i < 1_000_000; i++ {
+		fmt.Fprintf(&b, "%s,%s\n", values[i%5], values[i/5%5])
+	}
+	return []byte(b.String())
+})
+
+func BenchmarkReadInternStrings(b *testing.B) {
+	data := lowCardinalityRows()
+	for _, intern := range []bool{false, true} {
+		b.Run(fmt.Sprintf("InternStrings=%v", intern), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			var heap uint64
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				r := NewReader(bytes.NewReader(data))
+				r.InternStrings = intern
+				records, err := r.ReadAll()
+				if err != nil {
+					b.Fatal(err)
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				heap += after.HeapAlloc - before.HeapAlloc
+				runtime.KeepAlive(records)
+			}
+			b.ReportMetric(float64(heap)/float64(b.N), "heap-B/op")
+		})
+	}
+}
+
+func TestReadFunc(t *testing.T) {
+	const input = "a,\"b\nc\",d\ne,f,g\nh,i\n"
+	r := NewReader(strings.NewReader(input))
+	var got [][]string
+	collect := func(field string, col int) error {
+		if col == 0 {
+			got = append(got, nil)
+		}
+		got[len(got)-1] = append(got[len(got)-1], field)
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		if err := r.ReadFunc(collect); err != nil {
+			t.Fatalf("ReadFunc error = %v", err)
+		}
+	}
+	if err := r.ReadFunc(collect); !errors.Is(err, ErrFieldCount) {
+		t.Errorf("ReadFunc on short record = %v, want ErrFieldCount", err)
+	}
+	if err := r.ReadFunc(collect); err != io.EOF {
+		t.Errorf("ReadFunc at EOF = %v, want EOF", err)
+	}
+	want := [][]string{{"a", "b\nc", "d"}, {"e", "f", "g"}, {"h", "i"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %q, want %q", got, want)
+	}
+
+	// An error from fn stops the record; the next call reads the next one.
+	stop := errors.New("stop")
+	r = NewReader(strings.NewReader(input))
+	var cols []int
+	err := r.ReadFunc(func(field string, col int) error {
+		cols = append(cols, col)
+		if col == 1 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || !reflect.DeepEqual(cols, []int{0, 1}) {
+		t.Errorf("ReadFunc = %v after columns %v, want stop after [0 1]", err, cols)
+	}
+	if rec, err := r.Read(); err != nil || !reflect.DeepEqual(rec, want[1]) {
+		t.Errorf("Read after stop = %q, %v; want %q", rec, err, want[1])
+	}
+}
+
+func TestStrictOverrides(t *testing.T) {
+	r := NewReader(strings.NewReader("a,b\nc\rd\n"))
+	r.Strict = true
+	r.AcceptCR = true
+	r.RepairQuotes = true
+	r.OnShortRow = ShortRowPad
+	if _, err := r.Read(); err != nil {
+		t.Fatalf("Read error = %v", err)
+	}
+	if _, err := r.Read(); !errors.Is(err, ErrBareCR) {
+		t.Errorf("Read error = %v, want ErrBareCR", err)
+	}
+	if !r.AcceptCR || !r.RepairQuotes || r.OnShortRow != ShortRowPad {
+		t.Errorf("Strict changed the options: AcceptCR = %v, RepairQuotes = %v, OnShortRow = %v", r.AcceptCR, r.RepairQuotes, r.OnShortRow)
+	}
+
+	r = NewReader(strings.NewReader("a,b\nc\n"))
+	r.Strict = true
+	r.OnShortRow = ShortRowPad
+	r.Read()
+	if _, err := r.Read(); !errors.Is(err, ErrFieldCount) {
+		t.Errorf("Read of short row = %v, want ErrFieldCount", err)
+	}
+
+	src := strings.NewReader("a,b\n")
+	r = NewReader(readerFunc(func(p []byte) (int, error) {
+		if !r.LazyQuotes || !r.AllowBareQuotes || !r.AcceptCR || r.OnLongRow != LongRowTruncate {
+			t.Errorf("Strict changed the options while reading: LazyQuotes = %v, AllowBareQuotes = %v, AcceptCR = %v, OnLongRow = %v",
+				r.LazyQuotes, r.AllowBareQuotes, r.AcceptCR, r.OnLongRow)
+		}
+		return src.Read(p)
+	}))
+	r.Strict = true
+	r.LazyQuotes = true
+	r.AllowBareQuotes = true
+	r.AcceptCR = true
+	r.OnLongRow = LongRowTruncate
+	if _, err := r.ReadAll(); err != nil {
+		t.Errorf("ReadAll error = %v", err)
+	}
+}
+
+// readerFunc is an io.Reader calling itself, to look at a Reader while
+// it parses.
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+func TestBackslashEscapesOptions(t *testing.T) {
+	src := strings.NewReader(`a\,b,c\n` + "\n")
+	var r *Reader
+	r = NewReader(readerFunc(func(p []byte) (int, error) {
+		if r.Escape != 0 || r.DecodeEscapes {
+			t.Errorf("DecodeBackslashEscapes changed the options: Escape = %q, DecodeEscapes = %v", r.Escape, r.DecodeEscapes)
+		}
+		return src.Read(p)
+	}))
+	r.DecodeBackslashEscapes = true
+	if rec, err := r.Read(); err != nil || !reflect.DeepEqual(rec, []string{"a,b", "c\n"}) {
+		t.Errorf("Read = %q, %v", rec, err)
+	}
+}
+
+func TestParseErrorLeniency(t *testing.T) {
+	_, err := NewReader(strings.NewReader(`a,5" pipe`)).Read()
+	if want := `parse error on line 1, column 4: bare " in non-quoted-field (AllowBareQuotes would accept it)`; err == nil || err.Error() != want {
+		t.Errorf("Error() = %v, want %q", err, want)
+	}
+
+	r := NewReader(strings.NewReader(`a,5" pipe`))
+	r.Strict = true
+	r.AllowBareQuotes = true
+	var pe *ParseError
+	if _, err := r.Read(); !errors.As(err, &pe) || pe.Err != ErrBareQuote || pe.Leniency != "" {
+		t.Errorf("Strict Read error = %#v, want ErrBareQuote without Leniency", err)
+	}
+	if !r.AllowBareQuotes {
+		t.Error("Strict changed AllowBareQuotes")
+	}
+}
+
+func TestLastRecordQuoted(t *testing.T) {
+	r := NewReader(strings.NewReader("a,\"b\",\"\"\n\"c\"\n"))
+	r.FieldsPerRecord = 3
+	r.OnShortRow = ShortRowPad
+	for _, want := range [][]bool{{false, true, true}, {true, false, false}} {
+		if _, err := r.Read(); err != nil {
+			t.Fatalf("Read error = %v", err)
+		}
+		if got := r.LastRecordQuoted(); !reflect.DeepEqual(got, want) {
+			t.Errorf("LastRecordQuoted() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestUnterminated(t *testing.T) {
+	r := NewReader(strings.NewReader("a,\"b\"\nc,\"d\ne"))
+	r.AllowUnterminatedQuote = true
+	rec, err := r.Read()
+	if err != nil || r.Unterminated() != nil {
+		t.Fatalf("Read = %q, %v with Unterminated() = %v, want no errors", rec, err, r.Unterminated())
+	}
+	rec, err = r.Read()
+	if want := []string{"c", "d\ne"}; err != nil || !reflect.DeepEqual(rec, want) {
+		t.Fatalf("Read = %q, %v, want %q", rec, err, want)
+	}
+	want := &ParseError{StartLine: 2, Line: 3, Column: 2, Err: ErrUnterminatedField}
+	if got := r.Unterminated(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unterminated() = %#v, want %#v", got, want)
+	}
+	if _, err := r.Read(); err != io.EOF || !reflect.DeepEqual(r.Unterminated(), want) {
+		t.Errorf("Read at EOF = %v with Unterminated() = %v, want EOF and %v", err, r.Unterminated(), want)
+	}
+
+	r = NewReader(strings.NewReader("a,\"b\"\nc,\"d\ne"))
+	r.AllowUnterminatedQuote = true
+	if _, err := r.ReadAll(); err != nil || !reflect.DeepEqual(r.Unterminated(), want) {
+		t.Errorf("ReadAll error = %v with Unterminated() = %v, want nil and %v", err, r.Unterminated(), want)
+	}
+	r.Reset(strings.NewReader("a\n"))
+	if _, err := r.ReadAll(); err != nil || r.Unterminated() != nil {
+		t.Errorf("ReadAll after Reset = %v with Unterminated() = %v, want no errors", err, r.Unterminated())
+	}
+
+	r = NewReader(strings.NewReader("a,\"b"))
+	if _, err := r.Read(); !errors.Is(err, ErrQuote) {
+		t.Errorf("Read without AllowUnterminatedQuote = %v, want ErrQuote", err)
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	r := NewReader(strings.NewReader("a,\"b\",c,d\ne,f,g,h\ni,j\n"))
+	r.SelectColumns = []int{2, 1, 5}
+	r.FieldsPerRecord = 4
+	r.OnShortRow = ShortRowKeep
+	wantQuoted := []bool{false, true, false}
+	for i, want := range [][]string{{"c", "b", ""}, {"g", "f", ""}, {"", "j", ""}} {
+		record, err := r.Read()
+		if err != nil || !reflect.DeepEqual(record, want) {
+			t.Fatalf("Read = %q, %v, want %q", record, err, want)
+		}
+		if line, col := r.FieldPos(1); line != i+1 || col != 3 {
+			t.Errorf("FieldPos(1) = %d, %d, want %d, 3", line, col, i+1)
+		}
+		if i == 0 {
+			if got := r.LastRecordQuoted(); !reflect.DeepEqual(got, wantQuoted) {
+				t.Errorf("LastRecordQuoted() = %v, want %v", got, wantQuoted)
+			}
+		}
+	}
+	if r.FieldCount() != 2 {
+		t.Errorf("FieldCount() = %d, want 2", r.FieldCount())
+	}
+
+	r = NewReader(strings.NewReader("a,b\nc\n"))
+	r.SelectColumns = []int{1}
+	if _, err := r.ReadAll(); !errors.Is(err, ErrFieldCount) {
+		t.Errorf("ReadAll error = %v, want ErrFieldCount for the physical record", err)
+	}
+
+	r = NewReader(strings.NewReader("a\n"))
+	r.SelectColumns = []int{-1}
+	if _, err := r.Read(); err != errSelectColumns {
+		t.Errorf("Read error = %v, want %v", err, errSelectColumns)
+	}
+}
+
+func TestStats(t *testing.T) {
+	const input = "h1,h2\n# note\na,\"b\nc\nd\"\n\n\ne,f,g\n#\nh,i"
+	r := NewReader(strings.NewReader(input))
+	r.Comment = '#'
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		_, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil && !errors.Is(err, ErrFieldCount) {
+			t.Fatal(err)
+		}
+	}
+	want := ReaderStats{Records: 3, Lines: 10, CommentLines: 2, BlankLines: 2, BytesRead: int64(len(input))}
+	if got := r.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	r.Reset(strings.NewReader("a\n\nb\n"))
+	if _, err := r.ReadAll(); err != nil {
+		t.Fatal(err)
+	}
+	want = ReaderStats{Records: 2, Lines: 3, BlankLines: 1, BytesRead: 5}
+	if got := r.Stats(); got != want {
+		t.Errorf("Stats() after Reset = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadPtr(t *testing.T) {
+	str := func(s string) *string { return &s }
+	records := [][]*string{
+		{str("a"), nil, str(""), nil},
+		{nil, str(" b"), str(""), str("c")},
+	}
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.QuoteEmpty = true
+	for _, record := range records {
+		if err := w.WritePtr(record); err != nil {
+			t.Fatalf("WritePtr error = %v", err)
+		}
+	}
+	w.Flush()
+	if want := "a,,\"\",\n,\" b\",\"\",c\n"; b.String() != want {
+		t.Fatalf("output = %q, want %q", b.String(), want)
+	}
+
+	for _, reuse := range []bool{false, true} {
+		r := NewReader(strings.NewReader(b.String() + "  ,  \"\"\n"))
+		r.NullIfUnquotedEmpty = true
+		r.TrimLeadingSpace = true
+		r.FieldsPerRecord = -1
+		r.ReuseRecord = reuse
+		for _, want := range append(records[:1:1], []*string{nil, str("")}) {
+			got, err := r.ReadPtr()
+			if err != nil {
+				t.Fatalf("ReadPtr error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadPtr with ReuseRecord %v = %v, want %v", reuse, got, want)
+			}
+			if _, err := r.Read(); err != nil && err != io.EOF {
+				t.Fatalf("Read error = %v", err)
+			}
+		}
+	}
+
+	r := NewReader(strings.NewReader("a,,\"\"\n"))
+	if got, err := r.ReadPtr(); err != nil || len(got) != 3 || got[1] == nil || *got[1] != "" {
+		t.Errorf("ReadPtr without NullIfUnquotedEmpty = %v, %v, want no nil fields", got, err)
+	}
+}
+
+func TestNullToken(t *testing.T) {
+	str := func(s string) *string { return &s }
+	records := [][]*string{
+		{str("a"), nil, str("NA"), str("")},
+		{nil, str("NA,b"), nil, str("NAN")},
+	}
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.Null = "NA"
+	for _, record := range records {
+		if err := w.WritePtr(record); err != nil {
+			t.Fatalf("WritePtr error = %v", err)
+		}
+	}
+	w.Flush()
+	if want := "a,NA,\"NA\",\nNA,\"NA,b\",NA,NAN\n"; b.String() != want {
+		t.Fatalf("output = %q, want %q", b.String(), want)
+	}
+
+	r := NewReader(strings.NewReader(b.String()))
+	r.Null = "NA"
+	for _, want := range records {
+		got, err := r.ReadPtr()
+		if err != nil {
+			t.Fatalf("ReadPtr error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadPtr = %v, want %v", got, want)
+		}
+	}
+	if _, err := r.ReadPtr(); err != io.EOF {
+		t.Errorf("ReadPtr error = %v, want io.EOF", err)
+	}
+}
+
+func TestInvalidUTF8Header(t *testing.T) {
+	const input = "id,na\xefme\n1,x\n"
+	r := NewReader(strings.NewReader(input))
+	r.InvalidUTF8 = InvalidUTF8Error
+	_, err := r.ReadHeader()
+	want := &ParseError{StartLine: 1, Line: 1, Column: 6, Err: ErrInvalidUTF8}
+	if !reflect.DeepEqual(err, want) {
+		t.Fatalf("ReadHeader error = %v, want %v", err, want)
+	}
+
+	r = NewReader(strings.NewReader(input))
+	r.InvalidUTF8 = InvalidUTF8Replace
+	header, err := r.ReadHeader()
+	if err != nil {
+		t.Fatalf("ReadHeader error = %v", err)
+	}
+	if want := []string{"id", "na\ufffdme"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("ReadHeader = %q, want %q", header, want)
+	}
+}
+
+// BenchmarkReadShapes measures the field scanning of Read on inputs of
+// long unquoted fields, quoted fields full of doubled quotes and line
+// breaks, and many tiny fields.
+func BenchmarkReadShapes(b *testing.B) {
+	long := strings.Repeat("x", 2000)
+	shapes := []struct {
+		name string
+		rows string
+	}{
+		{"LongUnquoted", strings.Repeat(long+","+long+","+long+"\n", 4)},
+		{"HeavilyQuoted", strings.Repeat(`"a ""b"" c, d","e`+"\r\n"+`f ""g""","""h"""`+"\n", 64)},
+		{"TinyFields", strings.Repeat(strings.Repeat("a,", 99)+"b\n", 8)},
+	}
+	for _, s := range shapes {
+		b.Run(s.name, func(b *testing.B) {
+			b.SetBytes(int64(len(s.rows)))
+			benchmarkRead(b, func(r *Reader) { r.ReuseRecord = true }, s.rows)
+		})
+	}
+}
+
+func TestNewReaderSize(t *testing.T) {
+	blob := strings.Repeat("x", 100_000)
+	input := "a,\"" + blob + "\n" + blob + "\"\nb,c\n"
+	for _, size := range []int{0, 16, 1 << 20} {
+		r := NewReaderSize(strings.NewReader(input), size)
+		if size > 16 && r.r.Size() < size {
+			t.Errorf("NewReaderSize(%d) buffer size = %d", size, r.r.Size())
+		}
+		r.InitialRecordBuffer = 300_000
+		r.InitialFieldCount = 8
+		got, err := r.ReadAll()
+		want := [][]string{{"a", blob + "\n" + blob}, {"b", "c"}}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("NewReaderSize(%d): ReadAll error = %v, records equal = %v", size, err, reflect.DeepEqual(got, want))
+		}
+		if cap(r.recordBuffer) < 300_000 || cap(r.fieldIndexes) < 8 {
+			t.Errorf("NewReaderSize(%d): buffer capacities = %d, %d", size, cap(r.recordBuffer), cap(r.fieldIndexes))
+		}
+	}
+}
+
+// BenchmarkReadLargeRecords reads records holding a 5MB quoted field,
+// with and without size hints, from a new Reader each time.
+func BenchmarkReadLargeRecords(b *testing.B) {
+	blob := strings.Repeat(strings.Repeat("y", 999)+"\n", 5<<10)
+	data := []byte("1,\"" + blob + "\"\n2,\"" + blob + "\"\n")
+	read := func(b *testing.B, newReader func() *Reader) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r := newReader()
+			r.ReuseRecord = true
+			for {
+				if _, err := r.Read(); err == io.EOF {
+					break
+				} else if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+	b.Run("Default", func(b *testing.B) {
+		read(b, func() *Reader { return NewReader(bytes.NewReader(data)) })
+	})
+	b.Run("Hints", func(b *testing.B) {
+		read(b, func() *Reader {
+			r := NewReaderSize(bytes.NewReader(data), 1<<20)
+			r.InitialRecordBuffer = len(blob) + 16
+			r.InitialFieldCount = 2
+			return r
+		})
+	})
+}
@@ -0,0 +1,92 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+)
+
+// ErrNotRecordStart is returned by ReadRecordAt if the offset is not just
+// after a line terminator, so that it cannot be the start of a record.
+var ErrNotRecordStart = errors.New("csv: offset is not at the start of a record")
+
+// A RandomReader reads single records at given offsets of an input that
+// supports io.ReaderAt, such as an os.File. A RandomReader is not safe for
+// concurrent use.
+type RandomReader struct {
+	ra io.ReaderAt
+	r  *Reader
+}
+
+// NewReaderAt returns a RandomReader that reads records from ra in the
+// format described by dialect.
+func NewReaderAt(ra io.ReaderAt, dialect Dialect) *RandomReader {
+	r := NewReader(bytes.NewReader(nil))
+	dialect.configure(r)
+	return &RandomReader{ra: ra, r: r}
+}
+
+// ReadRecordAt parses the record starting at offset, which must be 0 or
+// the offset of the start of a line, such as one returned by
+// Reader.RecordOffset or by an earlier ReadRecordAt. Comment and blank
+// lines at offset are skipped as Read would skip them. ReadRecordAt
+// returns the record and the offset just past it, where the next record
+// starts, or nil, offset and io.EOF if there is no record at or after
+// offset.
+//
+// If the input before offset is not a line terminator and offset is not
+// at the end of the input, ReadRecordAt returns ErrNotRecordStart. An
+// offset just after a line break inside a quoted field cannot be told
+// apart from a record start without parsing the input from the
+// beginning; the record read from it then usually fails to parse, with a
+// bare or unterminated quote. Line numbers in errors count from the line
+// at offset. Each record is parsed on its own, so a FieldsPerRecord of 0
+// accepts any field count.
+func (rr *RandomReader) ReadRecordAt(offset int64) ([]string, int64, error) {
+	if err := rr.checkRecordStart(offset); err != nil {
+		return nil, offset, err
+	}
+	r := rr.r
+	r.Reset(io.NewSectionReader(rr.ra, offset, math.MaxInt64-offset))
+	record, err := r.Read()
+	if record == nil {
+		return nil, offset, err
+	}
+	return record, offset + r.InputOffset(), err
+}
+
+// checkRecordStart returns ErrNotRecordStart unless offset is 0 or just
+// after a line terminator.
+func (rr *RandomReader) checkRecordStart(offset int64) error {
+	if offset == 0 {
+		return nil
+	}
+	if offset < 0 {
+		return ErrNotRecordStart
+	}
+	term := rr.r.Terminator
+	if term == "" {
+		term = "\n"
+	}
+	n := int64(len(term))
+	if offset < n {
+		return ErrNotRecordStart
+	}
+	prev := make([]byte, n)
+	if m, err := rr.ra.ReadAt(prev, offset-n); m < len(prev) {
+		return err
+	}
+	if string(prev) == term || rr.r.AcceptCR && prev[n-1] == '\r' {
+		return nil
+	}
+	if m, err := rr.ra.ReadAt(prev[:1], offset); m == 0 && err == io.EOF {
+		return nil // The end of an unterminated last record
+	}
+	return ErrNotRecordStart
+}
@@ -0,0 +1,73 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRecordAt(t *testing.T) {
+	const input = "a;'b\nc';d\n# comment\n\ne;f'g;h\n'i'';j';k;l"
+	dialect := Dialect{Comma: ';', Quote: '\'', Comment: '#', LazyQuotes: true}
+	r := NewReader(strings.NewReader(input))
+	dialect.configure(r)
+	var want [][]string
+	var offsets []int64
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, rec)
+		offsets = append(offsets, r.RecordOffset())
+	}
+
+	rr := NewReaderAt(strings.NewReader(input), dialect)
+	for i, off := range offsets {
+		rec, next, err := rr.ReadRecordAt(off)
+		if err != nil || !reflect.DeepEqual(rec, want[i]) {
+			t.Errorf("ReadRecordAt(%d) = %q, %v; want %q", off, rec, err, want[i])
+		}
+		wantNext := int64(len(input))
+		if i+1 < len(offsets) {
+			wantNext = offsets[i+1]
+			if i == 0 {
+				wantNext = int64(strings.Index(input, "#")) // Comment lines are left for the next call
+			}
+		}
+		if next != wantNext {
+			t.Errorf("ReadRecordAt(%d) next offset = %d, want %d", off, next, wantNext)
+		}
+	}
+
+	// From the end of the first record, the comment and blank lines are skipped.
+	_, next, _ := rr.ReadRecordAt(0)
+	if rec, _, err := rr.ReadRecordAt(next); err != nil || !reflect.DeepEqual(rec, want[1]) {
+		t.Errorf("ReadRecordAt(%d) = %q, %v; want %q", next, rec, err, want[1])
+	}
+
+	if rec, next, err := rr.ReadRecordAt(int64(len(input))); rec != nil || next != int64(len(input)) || err != io.EOF {
+		t.Errorf("ReadRecordAt at EOF = %q, %d, %v; want nil, %d, EOF", rec, next, err, len(input))
+	}
+	if _, _, err := rr.ReadRecordAt(1); err != ErrNotRecordStart {
+		t.Errorf("ReadRecordAt inside a field = %v, want %v", err, ErrNotRecordStart)
+	}
+
+	// After a quoted line break, the quote that ends the field is bare.
+	const quoted = "a,\"b\nc\",d\n"
+	_, _, err := NewReaderAt(strings.NewReader(quoted), Dialect{}).ReadRecordAt(int64(strings.Index(quoted, "c")))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Err != ErrBareQuote || pe.Line != 1 {
+		t.Errorf("ReadRecordAt inside a quoted field = %v, want ErrBareQuote on line 1", err)
+	}
+}
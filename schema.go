@@ -0,0 +1,142 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A ColumnType is the kind of values InferSchema finds in a column.
+type ColumnType int
+
+const (
+	// TypeString is a column of arbitrary text. It is also the type of a
+	// column with no values in the sample.
+	TypeString ColumnType = iota
+
+	// TypeInt is a column of base 10 integers that fit in an int64.
+	TypeInt
+
+	// TypeFloat is a column of floating-point numbers, as accepted by
+	// strconv.ParseFloat, or a mix of them and integers.
+	TypeFloat
+
+	// TypeBool is a column of boolean values, as accepted by
+	// strconv.ParseBool.
+	TypeBool
+
+	// TypeDate is a column of dates or times in one of the layouts
+	// time.DateOnly, time.DateTime and time.RFC3339.
+	TypeDate
+)
+
+func (t ColumnType) String() string {
+	switch t {
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeBool:
+		return "bool"
+	case TypeDate:
+		return "date"
+	}
+	return "string"
+}
+
+// inferOrder lists the column types from the strictest to the loosest.
+var inferOrder = []ColumnType{TypeInt, TypeFloat, TypeBool, TypeDate}
+
+// dateLayouts are the layouts of TypeDate values.
+var dateLayouts = []string{time.DateOnly, time.DateTime, time.RFC3339}
+
+// InferSchema reads the header with ReadHeader and then up to sampleRows
+// records, or all of them if sampleRows is not positive, and returns the
+// header along with the type of each of its columns.
+//
+// A column gets the strictest type that every sampled value in it parses
+// as, trying TypeInt, TypeFloat, TypeBool and TypeDate in turn, so that a
+// column of integers with a single fraction is TypeFloat and a column
+// with any other value is TypeString. Values are trimmed of white space
+// first, and empty values are left out, so that missing data does not
+// force TypeString. Fields beyond the end of the header are ignored.
+//
+// The sampled records are consumed. InferSchema returns any error other
+// than io.EOF from reading them.
+func InferSchema(r *Reader, sampleRows int) ([]string, []ColumnType, error) {
+	header, err := r.ReadHeader()
+	if err != nil {
+		return nil, nil, err
+	}
+	// fits holds for each column a bit per type in inferOrder that all
+	// its values so far parse as, and seen whether it has had a value.
+	fits := make([]uint8, len(header))
+	seen := make([]bool, len(header))
+	for n := 0; sampleRows <= 0 || n < sampleRows; n++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, field := range record[:min(len(record), len(header))] {
+			if v := strings.TrimSpace(field); v == "" {
+				continue
+			} else if seen[i] {
+				fits[i] &= valueFits(v)
+			} else {
+				fits[i], seen[i] = valueFits(v), true
+			}
+		}
+	}
+	types := make([]ColumnType, len(header))
+	for i, f := range fits {
+		for j, t := range inferOrder {
+			if f&(1<<j) != 0 {
+				types[i] = t
+				break
+			}
+		}
+	}
+	return header, types, nil
+}
+
+// valueFits returns the set of types in inferOrder that v parses as.
+func valueFits(v string) uint8 {
+	var fits uint8
+	for j, t := range inferOrder {
+		var err error
+		switch t {
+		case TypeInt:
+			_, err = strconv.ParseInt(v, 10, 64)
+		case TypeFloat:
+			_, err = strconv.ParseFloat(v, 64)
+		case TypeBool:
+			_, err = strconv.ParseBool(v)
+		case TypeDate:
+			err = parseDate(v)
+		}
+		if err == nil {
+			fits |= 1 << j
+		}
+	}
+	return fits
+}
+
+// parseDate returns an error unless v is in one of dateLayouts.
+func parseDate(v string) error {
+	var err error
+	for _, layout := range dateLayouts {
+		if _, err = time.Parse(layout, v); err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	const input = "id,price,active,day,name,empty,mixed\n" +
+		"1,9.5,true,2024-01-02,apple,,1\n" +
+		"2,10,false,2024-01-03 10:00:00,pear,,x\n" +
+		" 3 , ,,2024-01-04T10:00:00Z,fig,,2\n" +
+		"4,1e3,,,kiwi,,3\n"
+	tests := []struct {
+		sampleRows int
+		want       []ColumnType
+	}{
+		{0, []ColumnType{TypeInt, TypeFloat, TypeBool, TypeDate, TypeString, TypeString, TypeString}},
+		{1, []ColumnType{TypeInt, TypeFloat, TypeBool, TypeDate, TypeString, TypeString, TypeInt}},
+		{2, []ColumnType{TypeInt, TypeFloat, TypeBool, TypeDate, TypeString, TypeString, TypeString}},
+	}
+	for _, tt := range tests {
+		r := NewReader(strings.NewReader(input))
+		header, types, err := InferSchema(r, tt.sampleRows)
+		if err != nil {
+			t.Fatalf("sampleRows %d: InferSchema error = %v", tt.sampleRows, err)
+		}
+		if want := strings.Split("id,price,active,day,name,empty,mixed", ","); !reflect.DeepEqual(header, want) {
+			t.Errorf("sampleRows %d: header = %q, want %q", tt.sampleRows, header, want)
+		}
+		if !reflect.DeepEqual(types, tt.want) {
+			t.Errorf("sampleRows %d: types = %v, want %v", tt.sampleRows, types, tt.want)
+		}
+	}
+}
+
+func TestInferSchemaBoolInt(t *testing.T) {
+	// 0 and 1 parse as both integers and booleans; integers win.
+	_, types, err := InferSchema(NewReader(strings.NewReader("a,b\n0,1\n1,true\n")), 0)
+	if want := []ColumnType{TypeInt, TypeBool}; err != nil || !reflect.DeepEqual(types, want) {
+		t.Errorf("InferSchema = %v, %v; want %v", types, err, want)
+	}
+}
+
+func TestInferSchemaError(t *testing.T) {
+	_, _, err := InferSchema(NewReader(strings.NewReader("a,b\n1,2\n3\n")), 0)
+	if !errors.Is(err, ErrFieldCount) {
+		t.Errorf("InferSchema error = %v, want ErrFieldCount", err)
+	}
+}
@@ -0,0 +1,259 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffSampleSize is the amount of input examined by DetectDelimiter.
+const sniffSampleSize = 64 << 10
+
+// defaultDelimiters are the candidates used by DetectDelimiter if none
+// are given.
+var defaultDelimiters = []rune{',', ';', '\t', '|'}
+
+// ErrNoDelimiter is returned by DetectDelimiter if no candidate splits
+// the sample into records of two or more fields.
+var ErrNoDelimiter = errors.New("csv: no delimiter fits the input")
+
+// An AmbiguousDelimiterError is returned by DetectDelimiter if several
+// candidates fit the sample equally well.
+type AmbiguousDelimiterError struct {
+	Candidates []rune // The tied candidates, in the order they were given
+}
+
+func (e *AmbiguousDelimiterError) Error() string {
+	quoted := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		quoted[i] = fmt.Sprintf("%q", c)
+	}
+	return "csv: ambiguous delimiter: " + strings.Join(quoted, ", ") + " fit the input equally well"
+}
+
+// DetectDelimiter examines the first 64 KiB of the input that has not been
+// read yet and sets Comma to the candidate delimiter that best fits it,
+// clearing Separator. If candidates is empty, ',', ';', '\t' and '|' are
+// tried. The sample stays buffered, so the next Read starts where it would
+// have without the call. With StripBOM, the byte order mark is removed
+// first.
+//
+// The sample is parsed with each candidate and the Reader's other options,
+// such as Quote and Comment, so delimiters inside quoted fields are not
+// counted. The candidate giving the most records with the same number of
+// fields, two or more, wins. A candidate for which the sample does not
+// parse is rejected. DetectDelimiter returns ErrNoDelimiter if no
+// candidate fits, and an AmbiguousDelimiterError if several fit equally
+// well; in both cases the Reader is left unchanged.
+func (r *Reader) DetectDelimiter(candidates []rune) (rune, error) {
+	if len(candidates) == 0 {
+		candidates = defaultDelimiters
+	}
+	for _, c := range candidates {
+		if !validDelim(c) {
+			return 0, errInvalidDelim
+		}
+	}
+	r.useDecoder()
+	if err := r.stripBOM(); err != nil {
+		return 0, err
+	}
+	if r.r.Size() < sniffSampleSize {
+		r.r = bufio.NewReaderSize(r.r, sniffSampleSize)
+	}
+	sample, err := r.r.Peek(sniffSampleSize)
+	truncated := err == nil
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	if truncated {
+		// Leave out the last line, which is likely cut short.
+		if i := bytes.LastIndexByte(sample, '\n'); i >= 0 {
+			sample = sample[:i+1]
+		}
+	}
+
+	var best []rune
+	bestScore := 0
+	for _, c := range candidates {
+		score := r.delimiterScore(sample, c, truncated)
+		switch {
+		case score > bestScore:
+			best, bestScore = append(best[:0], c), score
+		case score == bestScore && score > 0:
+			best = append(best, c)
+		}
+	}
+	switch len(best) {
+	case 0:
+		return 0, ErrNoDelimiter
+	case 1:
+		r.Comma = best[0]
+		r.Separator = ""
+		return best[0], nil
+	}
+	return 0, &AmbiguousDelimiterError{Candidates: best}
+}
+
+// delimiterScore returns the number of records in sample that have the
+// most common field count when split on comma, or 0 if that count is
+// below two or the sample does not parse. If truncated is true, the
+// sample may end inside a record and parsing stops at the first error.
+func (r *Reader) delimiterScore(sample []byte, comma rune, truncated bool) int {
+	cr := NewReader(bytes.NewReader(sample))
+	cr.Comma = comma
+	cr.Quote = r.Quote
+	cr.QuoteOpen = r.QuoteOpen
+	cr.QuoteClose = r.QuoteClose
+	cr.Comment = r.Comment
+	cr.InlineComments = r.InlineComments
+	cr.LazyQuotes = r.LazyQuotes
+	cr.AllowBareQuotes = r.AllowBareQuotes
+	cr.AllowTextAfterClosingQuote = r.AllowTextAfterClosingQuote
+	cr.AllowUnterminatedQuote = r.AllowUnterminatedQuote
+	cr.Escape = r.Escape
+	cr.DecodeBackslashEscapes = r.DecodeBackslashEscapes
+	cr.QuoteEscape = r.QuoteEscape
+	cr.StrictQuoteEscape = r.StrictQuoteEscape
+	cr.AcceptCR = r.AcceptCR
+	cr.Terminator = r.Terminator
+	cr.TrimLeadingSpace = r.TrimLeadingSpace
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	counts := make(map[int]int)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if truncated {
+				break
+			}
+			return 0
+		}
+		counts[len(record)]++
+	}
+	mode, score := 0, 0
+	for n, count := range counts {
+		if count > score || (count == score && n > mode) {
+			mode, score = n, count
+		}
+	}
+	if mode < 2 {
+		return 0
+	}
+	return score
+}
+
+// A HeaderVote is the verdict of one column of a sample on whether the
+// first record is a header.
+type HeaderVote int
+
+const (
+	// VoteAbstain means the column gives no evidence either way, because
+	// its values are neither all numbers nor all of the same length, or
+	// because no record after the first has the column.
+	VoteAbstain HeaderVote = iota
+
+	// VoteHeader means the first value differs in kind from the rest:
+	// text above numbers, or a different length above values of equal
+	// length.
+	VoteHeader
+
+	// VoteData means the first value is of the same kind as the rest.
+	VoteData
+)
+
+func (v HeaderVote) String() string {
+	switch v {
+	case VoteHeader:
+		return "header"
+	case VoteData:
+		return "data"
+	}
+	return "abstain"
+}
+
+// HasHeader reports whether the first record of sample looks like a
+// header naming the columns, using the votes of HeaderVotes.
+func HasHeader(sample [][]string) bool {
+	has, _ := HeaderVotes(sample)
+	return has
+}
+
+// HeaderVotes guesses whether the first record of sample is a header.
+// Each column of the first record casts a vote: if the values below it are
+// all numbers, or all text of the same length in runes, the column votes
+// for a header if the first value is not of that kind, and for data
+// otherwise. Other columns abstain. The first record is taken to be a
+// header if more columns vote for a header than for data, and the votes
+// are returned in column order so that callers can log the reasoning.
+//
+// A few records after the first are enough. Records may be ragged; a
+// column only counts the records that have it. In a sample of text only,
+// such as a list of names, the columns usually abstain and the first
+// record is not taken to be a header: the heuristic cannot tell labels
+// from data that looks like them.
+func HeaderVotes(sample [][]string) (bool, []HeaderVote) {
+	if len(sample) < 2 {
+		return false, nil
+	}
+	first := sample[0]
+	votes := make([]HeaderVote, len(first))
+	balance := 0
+	for col, name := range first {
+		numeric, length, n := true, -1, 0
+		for _, record := range sample[1:] {
+			if col >= len(record) {
+				continue
+			}
+			v := strings.TrimSpace(record[col])
+			if n == 0 {
+				length = utf8.RuneCountInString(v)
+			} else if length != utf8.RuneCountInString(v) {
+				length = -1
+			}
+			numeric = numeric && isNumber(v)
+			n++
+		}
+		switch {
+		case n == 0:
+			continue
+		case numeric:
+			votes[col] = VoteData
+			if !isNumber(strings.TrimSpace(name)) {
+				votes[col] = VoteHeader
+			}
+		case length >= 0:
+			votes[col] = VoteData
+			if utf8.RuneCountInString(strings.TrimSpace(name)) != length {
+				votes[col] = VoteHeader
+			}
+		}
+		switch votes[col] {
+		case VoteHeader:
+			balance++
+		case VoteData:
+			balance--
+		}
+	}
+	return balance > 0, votes
+}
+
+// isNumber reports whether s is an integer or floating-point number.
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
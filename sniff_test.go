@@ -0,0 +1,183 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var detectDelimiterTests = []struct {
+	Name       string
+	Input      string
+	Candidates []rune
+	Comment    rune
+	Want       rune
+	Error      error
+}{{
+	Name:  "Comma",
+	Input: "a,b,c\n1,2,3\n4,5,6\n",
+	Want:  ',',
+}, {
+	Name:  "Semicolon",
+	Input: "name;price\nwidget;1,50\ngadget;2,75\nthing;3\n",
+	Want:  ';',
+}, {
+	Name:  "SemicolonQuotedCommas",
+	Input: "id;text\n1;\"a, b, c\"\n2;\"d,e\"\n3;f\n",
+	Want:  ';',
+}, {
+	Name:  "CommaQuotedSemicolons",
+	Input: "id,text\n1,\"a;b\"\n2,\"c;d;e\"\n",
+	Want:  ',',
+}, {
+	Name:  "Tab",
+	Input: "a\tb, c\n1\t2\n3\t4\n",
+	Want:  '\t',
+}, {
+	Name:    "Comment",
+	Input:   "# a;b;c;d\n# e;f;g;h\n# i;j\nx|y\nz|w\n",
+	Comment: '#',
+	Want:    '|',
+}, {
+	Name:       "Candidates",
+	Input:      "a:b\nc:d\n",
+	Candidates: []rune{':'},
+	Want:       ':',
+}, {
+	Name:  "Ambiguous",
+	Input: "a;b,c\nd;e,f\n",
+	Error: &AmbiguousDelimiterError{Candidates: []rune{',', ';'}},
+}, {
+	Name:  "SingleColumn",
+	Input: "a\nb\nc\n",
+	Error: ErrNoDelimiter,
+}, {
+	Name:  "Empty",
+	Input: "",
+	Error: ErrNoDelimiter,
+}, {
+	Name:       "InvalidCandidate",
+	Input:      "a,b\n",
+	Candidates: []rune{',', '\n'},
+	Error:      errInvalidDelim,
+}}
+
+func TestDetectDelimiter(t *testing.T) {
+	for _, tt := range detectDelimiterTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(tt.Input))
+			r.Comment = tt.Comment
+			got, err := r.DetectDelimiter(tt.Candidates)
+			if !reflect.DeepEqual(err, tt.Error) {
+				t.Fatalf("DetectDelimiter() error:\ngot  %v\nwant %v", err, tt.Error)
+			}
+			if err != nil {
+				if r.Comma != ',' {
+					t.Errorf("Comma changed to %q on error", r.Comma)
+				}
+				return
+			}
+			if got != tt.Want || r.Comma != tt.Want {
+				t.Errorf("DetectDelimiter() = %q, Comma = %q; want %q", got, r.Comma, tt.Want)
+			}
+		})
+	}
+}
+
+func TestDetectDelimiterKeepsInput(t *testing.T) {
+	// The sample exceeds both the default buffer and the sample size.
+	input := "a;b;c\n" + strings.Repeat("1;\"x,y\";3\n", 10000)
+	r := NewReader(strings.NewReader(input))
+	if c, err := r.DetectDelimiter(nil); err != nil || c != ';' {
+		t.Fatalf("DetectDelimiter() = %q, %v; want ';'", c, err)
+	}
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 10001 || !reflect.DeepEqual(records[0], []string{"a", "b", "c"}) ||
+		!reflect.DeepEqual(records[10000], []string{"1", "x,y", "3"}) {
+		t.Errorf("ReadAll after DetectDelimiter read %d records, first %q", len(records), records[0])
+	}
+	if got, want := r.InputOffset(), int64(len(input)); got != want {
+		t.Errorf("InputOffset() = %d, want %d", got, want)
+	}
+}
+
+func TestAmbiguousDelimiterError(t *testing.T) {
+	err := &AmbiguousDelimiterError{Candidates: []rune{',', '\t'}}
+	want := `csv: ambiguous delimiter: ',', '\t' fit the input equally well`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+var headerVotesTests = []struct {
+	Name   string
+	Sample [][]string
+	Want   bool
+	Votes  []HeaderVote
+}{{
+	Name:   "Numbers",
+	Sample: [][]string{{"id", "price"}, {"1", "9.99"}, {"2", "19.5"}, {"3", "7"}},
+	Want:   true,
+	Votes:  []HeaderVote{VoteHeader, VoteHeader},
+}, {
+	Name:   "NoHeader",
+	Sample: [][]string{{"0", "1.5"}, {"1", "9.99"}, {"2", "19.5"}, {"3", "7"}},
+	Want:   false,
+	Votes:  []HeaderVote{VoteData, VoteData},
+}, {
+	Name:   "FixedLength",
+	Sample: [][]string{{"code", "name"}, {"DE", "Germany"}, {"FR", "France"}, {"IT", "Italy"}},
+	Want:   true,
+	Votes:  []HeaderVote{VoteHeader, VoteAbstain},
+}, {
+	// All text of varying length: nothing to go on, so no header.
+	Name:   "AllText",
+	Sample: [][]string{{"name", "city"}, {"Ann", "Paris"}, {"Bartholomew", "Rome"}, {"Cy", "Lisbon"}},
+	Want:   false,
+	Votes:  []HeaderVote{VoteAbstain, VoteAbstain},
+}, {
+	Name:   "Mixed",
+	Sample: [][]string{{"id", "note", "qty"}, {"1", "a", "5"}, {"2", "bb", "x"}, {"3", "ccc", "10"}},
+	Want:   true,
+	Votes:  []HeaderVote{VoteHeader, VoteAbstain, VoteAbstain},
+}, {
+	Name:   "Ragged",
+	Sample: [][]string{{"a", "b", "c"}, {"1"}, {}, {"2", "3"}, {"4", "5", "6", "7"}},
+	Want:   true,
+	Votes:  []HeaderVote{VoteHeader, VoteHeader, VoteHeader},
+}, {
+	Name:   "ShortFirst",
+	Sample: [][]string{{"x"}, {"1", "2"}, {"3", "4"}},
+	Want:   true,
+	Votes:  []HeaderVote{VoteHeader},
+}, {
+	Name:   "OnlyFirst",
+	Sample: [][]string{{"a", "b"}},
+	Want:   false,
+}, {
+	Name:   "Empty",
+	Sample: nil,
+	Want:   false,
+}}
+
+func TestHeaderVotes(t *testing.T) {
+	for _, tt := range headerVotesTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			got, votes := HeaderVotes(tt.Sample)
+			if got != tt.Want || !reflect.DeepEqual(votes, tt.Votes) {
+				t.Errorf("HeaderVotes() = %v, %v; want %v, %v", got, votes, tt.Want, tt.Votes)
+			}
+			if HasHeader(tt.Sample) != tt.Want {
+				t.Errorf("HasHeader() = %v, want %v", !tt.Want, tt.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var errNotStruct = errors.New("csv: value is not a struct or pointer to struct")
+
+// structField is a column of a flattened struct type.
+type structField struct {
+	name   string
+	index  []int // As for reflect.Value.FieldByIndex
+	depth  int   // Number of embedded structs the field is in
+	tagged bool  // The name comes from a csv tag
+}
+
+// structFields caches the columns of each struct type.
+var structFields sync.Map // map[reflect.Type][]structField
+
+// fieldsOf returns the columns of the struct type t, flattened as
+// described by WriteStruct.
+func fieldsOf(t reflect.Type) []structField {
+	if f, ok := structFields.Load(t); ok {
+		return f.([]structField)
+	}
+	var all []structField
+	appendFields(&all, t, nil, map[reflect.Type]bool{})
+
+	// Resolve name collisions, keeping the columns in order.
+	best := make(map[string]int, len(all))
+	for i, f := range all {
+		j, ok := best[f.name]
+		if !ok || f.depth < all[j].depth || f.depth == all[j].depth && f.tagged && !all[j].tagged {
+			best[f.name] = i
+		}
+	}
+	fields := make([]structField, 0, len(best))
+	for i, f := range all {
+		if best[f.name] == i {
+			fields = append(fields, f)
+		}
+	}
+	f, _ := structFields.LoadOrStore(t, fields)
+	return f.([]structField)
+}
+
+// appendFields appends the columns of the struct type t, found at index,
+// to fields. visiting guards against embedding cycles through pointers.
+func appendFields(fields *[]structField, t reflect.Type, index []int, visiting map[reflect.Type]bool) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("csv")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		idx := append(index[:len(index):len(index)], i)
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			appendFields(fields, ft, idx, visiting)
+			continue
+		}
+		tagged := name != ""
+		if !tagged {
+			name = sf.Name
+		}
+		*fields = append(*fields, structField{name: name, index: idx, depth: len(index), tagged: tagged})
+	}
+}
+
+// structType returns the struct type of v, a struct or pointer to struct.
+func structType(v any) (reflect.Type, bool) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, t != nil && t.Kind() == reflect.Struct
+}
+
+// Header returns the header WriteStruct writes columns in for the type of
+// v, a struct or pointer to struct, which may be nil. It returns nil for
+// any other v.
+func (w *Writer) Header(v any) []string {
+	t, ok := structType(v)
+	if !ok {
+		return nil
+	}
+	fields := fieldsOf(t)
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.name
+	}
+	return header
+}
+
+// WriteStruct writes the exported fields of v, a struct or pointer to
+// struct, as a record, formatting them as WriteTyped does; column
+// formatters apply to the columns of the record. A pointer field is
+// written as the value it points to, and a nil pointer as an empty field.
+//
+// The columns are the fields in declaration order, named by their csv
+// tag, such as `csv:"id"`, or else by the field name. A field tagged
+// `csv:"-"` is left out. The fields of an embedded exported struct, or
+// pointer to struct, without a tag name are expanded in its place,
+// recursively; a nil embedded pointer writes empty fields. A tagged
+// embedded struct is a single column. If several fields get the
+// same name, the least deeply embedded one is kept, then one with a tag
+// name, then the first in declaration order; the others are left out.
+// Header returns the resulting column names.
+func (w *Writer) WriteStruct(v any) error {
+	t, ok := structType(v)
+	if !ok {
+		return errNotStruct
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return errNotStruct
+		}
+		rv = rv.Elem()
+	}
+	fields := fieldsOf(t)
+	vals := make([]any, len(fields))
+	for i, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index)
+		if ok && fv.Kind() == reflect.Pointer {
+			ok = !fv.IsNil()
+			fv = fv.Elem()
+		}
+		if ok {
+			vals[i] = fv.Interface()
+		}
+	}
+	return w.WriteTyped(vals...)
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but reports false
+// instead of panicking on a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,61 @@
+// BSD 3-Clause License
+
+// Copyright (c) 2024, Steve Li
+// All rights reserved.
+
+package flexcsv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Audit struct {
+	Created string `csv:"created"`
+	ID      int    `csv:"id"` // Collides with Item.ID, which is less deeply embedded
+}
+
+type Owner struct {
+	Name string `csv:"owner"`
+}
+
+type Item struct {
+	ID int `csv:"id"`
+	Audit
+	*Owner
+	Name    string
+	Price   *float64 `csv:"price"`
+	secret  string
+	Skipped string `csv:"-"`
+	Note    string `csv:"Name"` // Tagged, so it wins over Name
+}
+
+func TestWriteStruct(t *testing.T) {
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	wantHeader := []string{"id", "created", "owner", "price", "Name"}
+	if got := w.Header((*Item)(nil)); !reflect.DeepEqual(got, wantHeader) {
+		t.Errorf("Header = %q, want %q", got, wantHeader)
+	}
+	price := 9.5
+	items := []any{
+		Item{ID: 1, Audit: Audit{Created: "2024-01-02", ID: 7}, Owner: &Owner{"ann"}, Name: "x", Price: &price, secret: "s", Note: "n"},
+		&Item{ID: 2},
+	}
+	for _, it := range items {
+		if err := w.WriteStruct(it); err != nil {
+			t.Fatalf("WriteStruct(%v): %v", it, err)
+		}
+	}
+	w.Flush()
+	if got, want := b.String(), "1,2024-01-02,ann,9.5,n\n2,,,,\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if err := w.WriteStruct(42); err != errNotStruct {
+		t.Errorf("WriteStruct(42) = %v, want %v", err, errNotStruct)
+	}
+	if got := w.Header(42); got != nil {
+		t.Errorf("Header(42) = %q, want nil", got)
+	}
+}
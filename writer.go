@@ -11,12 +11,23 @@ package flexcsv
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 	"unicode/utf8"
 )
 
+var errClosed = errors.New("csv: use of closed Reader or Writer")
+
+var errStrictWriter = errors.New("csv: option not allowed by Writer.Strict")
+
 // A Writer writes records using CSV encoding.
 //
 // As returned by NewWriter, a Writer writes records terminated by a
@@ -27,37 +38,542 @@ import (
 //
 // If UseCRLF is true, the Writer ends each output line with \r\n instead of \n.
 //
+// If Escape is set, quote and escape characters inside quoted fields are
+// preceded by Escape instead of doubling the quote, and fields containing
+// Escape are always quoted, unless EscapeDelimiter is set, so that a
+// Reader with the same Escape or QuoteEscape reads the records back
+// unchanged.
+//
 // The writes of individual records are buffered.
 // After all data has been written, the client should call the
 // Flush method to guarantee all data has been forwarded to
 // the underlying io.Writer.  Any errors that occurred should
-// be checked by calling the Error method.
+// be checked by calling the Error method. Alternatively, Close
+// flushes, closes the underlying io.Writer if it is an io.Closer,
+// and reports any error, so that defer w.Close() is sufficient.
 type Writer struct {
 	Comma      rune // Field delimiter (set to ',' by NewWriter)
 	Quote      rune // Quote character to use (set to '"' by NewWriter)
 	QuoteEmpty bool // True to quote empty fields. This is false by default after Go 1.4
 	QuoteAll   bool // True to quote each csv field
 	UseCRLF    bool // True to use \r\n as the line terminator
-	w          *bufio.Writer
+	Escape     rune // Escape character for quotes in quoted fields (0 doubles quotes instead)
+
+	// QuoteOpen and QuoteClose, if not 0, replace Quote as the characters
+	// that open and close a quoted field, for a Reader with the same
+	// options. Each defaults to Quote. A QuoteClose in a quoted field is
+	// doubled, or preceded by Escape, and a field holding either
+	// character is quoted.
+	QuoteOpen  rune
+	QuoteClose rune
+
+	// If EscapeNewlines is true, newlines, carriage returns and
+	// backslashes in fields are written as \n, \r and \\, so that each
+	// record takes exactly one line and no field needs quoting for a line
+	// break. A Reader with UnescapeNewlines reads the fields back. UseCRLF
+	// still only selects the record terminator; it does not change how
+	// line breaks in fields are written.
+	EscapeNewlines bool
+
+	// If EncodeBackslashEscapes is true, fields are written as in the text
+	// formats of database dumps, for a Reader with DecodeBackslashEscapes:
+	// a newline, a tab, a carriage return, NUL and a backslash are written
+	// as \n, \t, \r, \0 and \\, and the Comma, Quote and Comment
+	// characters are preceded by a backslash, so that no field is quoted.
+	// A nil field given to WritePtr is written as \N. EscapeNewlines,
+	// QuoteAll and QuoteEmpty are then ignored. It cannot be combined
+	// with DoubledDelimiterEscape or with a Terminator holding anything
+	// but line breaks.
+	EncodeBackslashEscapes bool
+
+	// If DoubledDelimiterEscape is true, a Comma in a field is written
+	// doubled instead of making the field quoted, for a Reader with the
+	// same option. A field that would then be misread, one starting
+	// with Comma or an empty field between two others, is quoted. With
+	// Quote set to 0, such fields cannot be written unambiguously.
+	DoubledDelimiterEscape bool
+
+	// If EscapeDelimiter is true and Escape is set, a Comma or Escape in a
+	// field is written preceded by Escape instead of making the field
+	// quoted, as in a\,b, for a Reader with the same Escape; QuoteEscape
+	// does not apply outside quoted fields. A field holding anything else
+	// that needs quoting, such as a line break or a quote, is still
+	// quoted, with Comma then left as it is. EscapeDelimiter is ignored
+	// with DoubledDelimiterEscape.
+	EscapeDelimiter bool
+
+	// FieldsPerRecord, if positive, is the number of fields each record
+	// must have. A record with a different number of fields is not written
+	// and the write returns a WriteError with Err set to ErrFieldCount and
+	// Column set to the first missing or extra field. By default records
+	// are not checked.
+	FieldsPerRecord int
+
+	// If StableQuote is true, a column that has been quoted in one record
+	// is quoted in every later record as well, so that regenerating a file
+	// does not change the quoting of unchanged fields. By default fields
+	// are quoted only when needed. Reset forgets the quoted columns.
+	StableQuote bool
+
+	// Validate, if set, is called with each record before it is written,
+	// with its fields as they will be written but before quoting: after
+	// the formatting of WriteTyped and the escaping of EscapeNewlines. If
+	// it returns an error, the record is not written and the error is
+	// returned by the write and reported by Error. The record slice is
+	// reused, so Validate must not retain it.
+	Validate func(record []string) error
+
+	// If Dedup is true, a record equal to one written before is skipped:
+	// the write returns nil without writing it, and Duplicates counts it.
+	// Records are compared by the key DedupKey returns for them, if set,
+	// or else by all their fields. The keys of every distinct record
+	// written are kept, so memory grows with the number of distinct
+	// records until DedupReset or Reset forgets them. Records are
+	// compared as they will be written, after SkipColumns, and records
+	// rejected by FieldsPerRecord or Validate are not remembered. The
+	// record slice given to DedupKey is reused, so DedupKey must not
+	// retain it.
+	Dedup    bool
+	DedupKey func(record []string) string
+
+	// Comment, if not 0, is the comment character of the Reader that will
+	// read the output. A record whose first field begins with Comment has
+	// that field quoted, so that it is not read back as a comment line.
+	// Comment must be a valid rune other than Comma and Quote.
+	Comment rune
+
+	// Null, if not empty, is written unquoted for a nil field given to
+	// WritePtr, and a field equal to Null is quoted, so that a Reader with
+	// the same Null reads both back unchanged. Null should not need
+	// quoting itself, and with Quote set to 0 a field equal to it cannot
+	// be told apart from NULL. It is ignored with EncodeBackslashEscapes.
+	Null string
+
+	// Terminator, if not empty, ends each record instead of \n or \r\n,
+	// and UseCRLF is ignored. Fields are quoted where the terminator could
+	// otherwise be found in the record, and line breaks inside quoted
+	// fields are written unchanged, so that a Reader with the same
+	// Terminator reads the records back. Terminator must be valid UTF-8
+	// and must not contain Quote, Escape or Comma.
+	Terminator string
+
+	// OmitFinalNewline suppresses the line terminator after the last
+	// record. Each terminator is then written just before the next
+	// record, so that Flush and Close leave the output unterminated.
+	OmitFinalNewline bool
+
+	// If Strict is true, the output follows RFC 4180 exactly: records end
+	// in \r\n whatever UseCRLF says, a field is quoted if and only if it
+	// holds a comma, a double quote, \r or \n, and field data, line
+	// breaks included, is written unchanged. The quoted flags given to
+	// WriteWithQuoting are ignored. Writes fail unless Comma is ',' and
+	// Quote is '"', and options that change the format, such as Escape,
+	// Terminator, QuoteAll, QuoteEmpty, StableQuote, Comment and Null,
+	// are unset.
+	Strict bool
+
+	// TimeLayout, FloatPrecision, TrueText and FalseText control how
+	// WriteTyped formats time.Time, floating-point and bool values by
+	// default. NewWriter sets them to time.RFC3339, -1 (the fewest digits
+	// that represent the value exactly), "true" and "false".
+	TimeLayout     string
+	FloatPrecision int
+	TrueText       string
+	FalseText      string
+
+	// DecimalSeparator, if not 0, replaces the '.' of the floating-point
+	// values formatted by WriteTyped and WriteNumber, as in 1234,5 for
+	// locales that use a decimal comma. Digits are never grouped. A
+	// value whose separator is Comma is quoted like any field holding
+	// the delimiter.
+	DecimalSeparator rune
+
+	// Encoder, if set, converts the output from UTF-8 to a legacy
+	// character encoding, such as Latin-1. It must be set before the
+	// first Write. BytesWritten counts the bytes before encoding, and a
+	// record containing a character the encoding cannot represent makes
+	// the write, or the Flush or Close that writes it out, fail.
+	Encoder Transformer
+
+	// OnFlush, if set, is called after each flush of the output buffer,
+	// whether by Flush, WriteAll, AutoFlush or Close, with the number of
+	// bytes that flush wrote, counted as BytesWritten counts them, and
+	// its error. Calls are never concurrent, even with a timer-driven
+	// AutoFlush, but they hold the Writer's lock, so OnFlush must not
+	// call methods of the Writer. Bytes the buffer writes out on its own
+	// when it fills are counted by the next call.
+	OnFlush func(bytes int64, err error)
+
+	// Preamble and Epilogue, if set, write text that is not CSV, such as
+	// a title line or a summary line, around the records: Preamble just
+	// before the first record is written, or by Close if no record was,
+	// and Epilogue by Close, after the terminator of the last record,
+	// even one deferred by OmitFinalNewline. The io.Writer they are given
+	// writes to the output as it is, without any quoting, and what they
+	// write is buffered, encoded and counted by BytesWritten and OnFlush
+	// like the records. An error they return is returned by the write or
+	// Close that called them and reported by Error. They hold the
+	// Writer's lock, so they must not call methods of the Writer. Reset
+	// makes Preamble run again for the new output.
+	Preamble func(w io.Writer) error
+	Epilogue func(w io.Writer) error
+
+	w   *bufio.Writer
+	cw  *countWriter
+	enc *encodeWriter
+
+	// pendingEOL records that the terminator of the last record has been
+	// deferred because of OmitFinalNewline.
+	pendingEOL bool
+
+	// numRecords counts the records written.
+	numRecords int
+
+	// preambled records that Preamble has been called for the output.
+	preambled bool
+
+	// flushed is the output offset at the end of the last flush, for
+	// OnFlush.
+	flushed int64
+
+	// quotedCols records the columns quoted so far, for StableQuote.
+	quotedCols []bool
+
+	// seen holds the keys of the records written with Dedup, duplicates
+	// counts the records skipped and dedupFields and dedupKey are the
+	// buffers that build the keys.
+	seen        map[string]struct{}
+	duplicates  int
+	dedupFields []string
+	dedupKey    []byte
+
+	// validated is the record buffer for Validate, and err is the first
+	// error it returned.
+	validated []string
+	err       error
+
+	// fields is the record built by WriteField.
+	fields []string
+
+	// skipCols holds the columns set by SkipColumns in increasing order,
+	// and keptStrings, keptBytes, keptQuoted and keptNull are the buffers
+	// of the fields and flags left.
+	skipCols    []int
+	keptStrings []string
+	keptBytes   [][]byte
+	keptQuoted  []bool
+	keptNull    []bool
+
+	// formatters are the column formatters set by SetFormatter, and
+	// typedRecord is the record buffer of WriteTyped.
+	formatters  []func(any) (string, error)
+	typedRecord []string
+
+	// closer is the underlying io.Writer, if it is also an io.Closer.
+	closer io.Closer
+	closed bool
+
+	// mu guards w and the auto-flush state, since a timer-driven
+	// flush runs on its own goroutine.
+	mu sync.Mutex
+
+	// flushRecords is the record threshold set by AutoFlush and
+	// numPending counts the records written since the last flush.
+	flushRecords int
+	numPending   int
+
+	// flushInterval, ticker and stop drive the timer-based flush, and
+	// done is closed when its goroutine returns.
+	flushInterval time.Duration
+	ticker        *time.Ticker
+	stop          chan struct{}
+	done          chan struct{}
 }
 
 // NewWriter returns a new Writer that writes to w.
 func NewWriter(w io.Writer) *Writer {
-	return &Writer{
-		Comma: ',',
-		Quote: '"',
-		w:     bufio.NewWriter(w),
+	cw := new(Writer)
+	cw.init(w, nil)
+	return cw
+}
+
+// init makes w the Writer NewWriter(out) returns, reusing buf, if not nil,
+// as its output buffer. The auto-flush goroutine must not be running.
+func (w *Writer) init(out io.Writer, buf *bufio.Writer) {
+	c, _ := out.(io.Closer)
+	cw := &countWriter{w: out}
+	if buf == nil {
+		buf = bufio.NewWriter(cw)
+	} else {
+		buf.Reset(cw)
+	}
+	*w = Writer{
+		Comma:          ',',
+		Quote:          '"',
+		TimeLayout:     time.RFC3339,
+		FloatPrecision: -1,
+		TrueText:       "true",
+		FalseText:      "false",
+		w:              buf,
+		cw:             cw,
+		closer:         c,
 	}
 }
 
+// Reset discards any unflushed output and error, forgets the state kept
+// between records, such as the quoted columns of StableQuote, the records
+// seen by Dedup, a terminator deferred by OmitFinalNewline and the fields
+// given to WriteField for a record not yet ended, and makes the Writer
+// write to out. The options, formatters and auto-flush settings are kept.
+// Reset also reopens a closed Writer, but the timer-based auto-flush
+// stopped by Close must be restarted with AutoFlush.
+func (w *Writer) Reset(out io.Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closer, _ = out.(io.Closer)
+	w.cw = &countWriter{w: out}
+	w.enc = nil
+	w.w.Reset(w.cw)
+	w.closed = false
+	w.pendingEOL = false
+	w.numRecords = 0
+	w.preambled = false
+	w.flushed = 0
+	w.numPending = 0
+	w.quotedCols = w.quotedCols[:0]
+	w.seen, w.duplicates = nil, 0
+	clear(w.fields)
+	w.fields = w.fields[:0]
+	w.err = nil
+}
+
+// countWriter counts the bytes written to w.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// BytesWritten returns the number of bytes of CSV output produced so far,
+// including any that are still buffered. A terminator deferred because of
+// OmitFinalNewline is not counted until it is written.
+func (w *Writer) BytesWritten() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cw.n + int64(w.w.Buffered())
+}
+
 // Write writes a single CSV record to w along with any necessary quoting.
 // A record is a slice of strings with each string being one field.
 // Writes are buffered, so Flush must eventually be called to ensure
 // that the record is written to the underlying io.Writer.
+// Write returns an error if the Writer has been closed.
 func (w *Writer) Write(record []string) error {
-	if !validDelim(w.Comma) {
+	return write(w, record, nil, nil)
+}
+
+// WritePtr writes a single CSV record like Write, with a nil field
+// standing for NULL: it is written as \N with EncodeBackslashEscapes, as
+// Null if that is set and as an unquoted empty field otherwise, even with
+// QuoteEmpty or QuoteAll, so that a Reader with NullIfUnquotedEmpty, or
+// the same Null, reads it back as nil.
+func (w *Writer) WritePtr(record []*string) error {
+	fields := make([]string, len(record))
+	null := make([]bool, len(record))
+	for i, p := range record {
+		if p == nil {
+			null[i] = true
+		} else {
+			fields[i] = *p
+		}
+	}
+	return write(w, fields, nil, null)
+}
+
+// WriteBytes is like Write but takes the fields as byte slices, so that
+// data which is already held as []byte need not be converted to strings.
+// The fields are quoted exactly as Write would quote them. WriteBytes
+// does not retain the record once it returns.
+func (w *Writer) WriteBytes(record [][]byte) error {
+	return write(w, record, nil, nil)
+}
+
+// WriteWithQuoting writes a single CSV record like Write, but quotes each
+// field whose entry in quoted is true even if it does not need quotes, so
+// that a record read with Reader.LastRecordQuoted can be written back
+// quoted as it was in the input. A field whose entry is false or missing
+// is quoted only if Write would quote it. quoted has no effect if Quote
+// is 0.
+func (w *Writer) WriteWithQuoting(record []string, quoted []bool) error {
+	return write(w, record, quoted, nil)
+}
+
+// WriteField adds field to the record being built for EndRecord, so that
+// a record can be written one field at a time instead of as a slice. The
+// fields are held until EndRecord writes them, since FieldsPerRecord,
+// Validate and StableQuote apply to the whole record; Flush and Close do
+// not write a record that has not been ended. WriteField returns an error
+// if the Writer has been closed.
+func (w *Writer) WriteField(field string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errClosed
+	}
+	w.fields = append(w.fields, field)
+	return nil
+}
+
+// EndRecord writes the fields added by WriteField since the last
+// EndRecord as a record, exactly as Write would, and starts a new record.
+// The fields are discarded even if the record is not written.
+func (w *Writer) EndRecord() error {
+	err := write(w, w.fields, nil, nil)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	clear(w.fields) // Let the fields be collected
+	w.fields = w.fields[:0]
+	return err
+}
+
+// SkipColumns makes the writes leave out the fields in the given 0-based
+// columns of each record, replacing the columns given to an earlier call;
+// with no columns, every field is written again. FieldsPerRecord,
+// Validate, StableQuote and the other options see only the fields left,
+// while the column formatters of WriteTyped apply to the columns of the
+// record before any are skipped. Negative columns and columns a record
+// does not have are ignored, not reported as errors.
+func (w *Writer) SkipColumns(indexes ...int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.skipCols = w.skipCols[:0]
+	for _, i := range indexes {
+		if i >= 0 {
+			w.skipCols = append(w.skipCols, i)
+		}
+	}
+	slices.Sort(w.skipCols)
+	w.skipCols = slices.Compact(w.skipCols)
+}
+
+// keepColumns appends to dst the elements of src whose index is not in
+// skip, which is sorted.
+func keepColumns[E any](skip []int, dst, src []E) []E {
+	for i, e := range src {
+		for len(skip) > 0 && skip[0] < i {
+			skip = skip[1:]
+		}
+		if len(skip) == 0 || skip[0] != i {
+			dst = append(dst, e)
+		}
+	}
+	return dst
+}
+
+// text is the type of a record field accepted by the Writer.
+type text interface {
+	string | []byte
+}
+
+func write[T text](w *Writer, record []T, quoted, null []bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errClosed
+	}
+	w.startEncoder()
+	if len(w.skipCols) > 0 {
+		switch rec := any(record).(type) {
+		case []string:
+			w.keptStrings = keepColumns(w.skipCols, w.keptStrings[:0], rec)
+			record = any(w.keptStrings).([]T)
+		case [][]byte:
+			w.keptBytes = keepColumns(w.skipCols, w.keptBytes[:0], rec)
+			record = any(w.keptBytes).([]T)
+		}
+		if quoted != nil {
+			w.keptQuoted = keepColumns(w.skipCols, w.keptQuoted[:0], quoted)
+			quoted = w.keptQuoted
+		}
+		if null != nil {
+			w.keptNull = keepColumns(w.skipCols, w.keptNull[:0], null)
+			null = w.keptNull
+		}
+		defer func() {
+			clear(w.keptStrings) // Let the fields be collected
+			clear(w.keptBytes)
+		}()
+	}
+	if w.FieldsPerRecord > 0 && len(record) != w.FieldsPerRecord {
+		return &WriteError{Record: w.numRecords + 1, Column: min(len(record), w.FieldsPerRecord), Err: ErrFieldCount}
+	}
+	if w.Validate != nil {
+		if err := validate(w, record); err != nil {
+			if w.err == nil {
+				w.err = err
+			}
+			return err
+		}
+	}
+	var key []byte
+	if w.Dedup {
+		key = recordKey(w, record)
+		if _, ok := w.seen[string(key)]; ok {
+			w.duplicates++
+			return nil
+		}
+	}
+	if err := w.preamble(); err != nil {
+		return err
+	}
+	if err := writeRecord(w, record, quoted, null); err != nil {
+		return err
+	}
+	if w.Dedup {
+		if w.seen == nil {
+			w.seen = make(map[string]struct{})
+		}
+		w.seen[string(key)] = struct{}{}
+	}
+	w.numRecords++
+	w.numPending++
+	if w.flushRecords > 0 && w.numPending >= w.flushRecords {
+		return w.flush()
+	}
+	return nil
+}
+
+// writeRecord writes record, quoting the fields marked in quoted as well
+// as those that need it. The fields marked in null are NULL.
+func writeRecord[T text](w *Writer, record []T, quoted, null []bool) error {
+	if w.Strict && !w.strictOptions() {
+		return errStrictWriter
+	}
+	qopen, qclose := w.quoteRunes()
+	if (qopen == 0) != (qclose == 0) {
+		return errInvalidDelim
+	}
+	if !validDelim(w.Comma) || w.Comment != 0 && (!validDelim(w.Comment) || w.Comment == w.Comma || w.Comment == qopen || w.Comment == qclose) {
+		return errInvalidDelim
+	}
+	if t := w.Terminator; t != "" && (!utf8.ValidString(t) || strings.ContainsRune(t, w.Comma) ||
+		qopen != 0 && strings.ContainsAny(t, string([]rune{qopen, qclose})) || w.Escape != 0 && strings.ContainsRune(t, w.Escape)) {
+		return errInvalidDelim
+	}
+	if w.EncodeBackslashEscapes && (w.DoubledDelimiterEscape || strings.Trim(w.Terminator, "\r\n") != "") {
 		return errInvalidDelim
 	}
+	if w.pendingEOL {
+		if err := w.writeEOL(); err != nil {
+			return err
+		}
+		w.pendingEOL = false
+	}
 
 	for n, field := range record {
 		if n > 0 {
@@ -65,28 +581,76 @@ func (w *Writer) Write(record []string) error {
 				return err
 			}
 		}
+		if w.EncodeBackslashEscapes {
+			var err error
+			if n < len(null) && null[n] {
+				_, err = w.w.WriteString(`\N`)
+			} else {
+				err = writeBackslashEscaped(w, field)
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if n < len(null) && null[n] && w.Null != "" {
+			if _, err := w.w.WriteString(w.Null); err != nil {
+				return err
+			}
+			continue
+		}
+		if n < len(null) && null[n] && !misreadAsDoubled(w, field, n, len(record)) {
+			continue // An unquoted empty field
+		}
+		if w.EscapeNewlines && indexAny(field, "\\\r\n") >= 0 {
+			field = T(newlineEscaper.Replace(string(field)))
+		}
 
 		// If we don't have to have a quoted field then just
 		// write out the field and continue to the next field.
-		if !w.fieldNeedsQuotes(field) {
-			if _, err := w.w.WriteString(field); err != nil {
+		quote := fieldNeedsQuotes(w, field) || n == 0 && startsWithComment(w, field) ||
+			n < len(quoted) && quoted[n] && qopen != 0 && !w.Strict ||
+			misreadAsDoubled(w, field, n, len(record))
+		if w.StableQuote && qopen != 0 {
+			if n < len(w.quotedCols) && w.quotedCols[n] {
+				quote = true
+			} else if quote {
+				for len(w.quotedCols) <= n {
+					w.quotedCols = append(w.quotedCols, false)
+				}
+				w.quotedCols[n] = true
+			}
+		}
+		if !quote {
+			if w.DoubledDelimiterEscape && indexRune(field, w.Comma) >= 0 {
+				c := string(w.Comma)
+				field = T(strings.ReplaceAll(string(field), c, c+c))
+			}
+			if w.escapesDelimiter() && (indexRune(field, w.Comma) >= 0 || indexRune(field, w.Escape) >= 0) {
+				field = T(escapeDelimiter(string(field), w.Comma, w.Escape))
+			}
+			if err := writeText(w.w, field); err != nil {
 				return err
 			}
 			continue
 		}
 
-		if _, err := w.w.WriteRune(w.Quote); err != nil {
+		if _, err := w.w.WriteRune(qopen); err != nil {
 			return err
 		}
+		special := "\r\n" + string(qclose)
+		if w.Escape != 0 {
+			special += string(w.Escape)
+		}
 		for len(field) > 0 {
 			// Search for special characters.
-			i := strings.IndexAny(field, "\r\n"+string(w.Quote))
+			i := indexAny(field, special)
 			if i < 0 {
 				i = len(field)
 			}
 
 			// Copy verbatim everything before the special character.
-			if _, err := w.w.WriteString(field[:i]); err != nil {
+			if err := writeText(w.w, field[:i]); err != nil {
 				return err
 			}
 			field = field[i:]
@@ -94,32 +658,48 @@ func (w *Writer) Write(record []string) error {
 			// Encode the special character.
 			if len(field) > 0 {
 				var err error
-				switch field[0] {
-				case byte(w.Quote):
-					_, err = w.w.WriteString(string([]rune{w.Quote, w.Quote}))
-				case '\r':
-					if !w.UseCRLF {
+				c, size := decodeRune(field)
+				switch {
+				case w.Escape != 0 && c == w.Escape:
+					_, err = w.w.WriteString(string([]rune{w.Escape, w.Escape}))
+				case c == qclose && w.Escape != 0:
+					_, err = w.w.WriteString(string([]rune{w.Escape, qclose}))
+				case c == qclose:
+					_, err = w.w.WriteString(string([]rune{qclose, qclose}))
+				case c == '\r':
+					if !w.UseCRLF || w.Terminator != "" || w.Strict {
 						err = w.w.WriteByte('\r')
 					}
-				case '\n':
-					if w.UseCRLF {
+				case c == '\n':
+					if w.UseCRLF && w.Terminator == "" && !w.Strict {
 						_, err = w.w.WriteString("\r\n")
 					} else {
 						err = w.w.WriteByte('\n')
 					}
 				}
-				field = field[1:]
+				field = field[size:]
 				if err != nil {
 					return err
 				}
 			}
 		}
-		if _, err := w.w.WriteRune(w.Quote); err != nil {
+		if _, err := w.w.WriteRune(qclose); err != nil {
 			return err
 		}
 	}
+	if w.OmitFinalNewline {
+		w.pendingEOL = true
+		return nil
+	}
+	return w.writeEOL()
+}
+
+// writeEOL writes the line terminator.
+func (w *Writer) writeEOL() error {
 	var err error
-	if w.UseCRLF {
+	if w.Terminator != "" {
+		_, err = w.w.WriteString(w.Terminator)
+	} else if w.UseCRLF || w.Strict {
 		_, err = w.w.WriteString("\r\n")
 	} else {
 		err = w.w.WriteByte('\n')
@@ -129,13 +709,36 @@ func (w *Writer) Write(record []string) error {
 
 // Flush writes any buffered data to the underlying io.Writer.
 // To check if an error occurred during the Flush, call Error.
+// If AutoFlush is active, Flush also restarts its record count and timer.
 func (w *Writer) Flush() {
-	w.w.Flush()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flush()
+}
+
+// flush flushes the buffer and restarts the auto-flush thresholds.
+// w.mu must be held.
+func (w *Writer) flush() error {
+	w.numPending = 0
+	if w.ticker != nil {
+		w.ticker.Reset(w.flushInterval)
+	}
+	err := w.w.Flush()
+	if w.OnFlush != nil {
+		w.OnFlush(w.cw.n-w.flushed, err)
+	}
+	w.flushed = w.cw.n
+	return err
 }
 
 // Error reports any error that has occurred during a previous Write or Flush.
 func (w *Writer) Error() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	_, err := w.w.Write(nil)
+	if err == nil {
+		err = w.err
+	}
 	return err
 }
 
@@ -148,11 +751,288 @@ func (w *Writer) WriteAll(records [][]string) error {
 			return err
 		}
 	}
-	return w.w.Flush()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+// WriteAllN is like WriteAll but also returns the number of records that
+// reached the underlying io.Writer, so that an interrupted export can be
+// resumed with records[n:]. If Write rejects a record, the records before
+// it are flushed all the same, so that n is its index unless the Flush
+// fails too. A record counts once all its bytes are written, not counting
+// a terminator deferred by OmitFinalNewline; with an Encoder, once the
+// Encoder has accepted them.
+func (w *Writer) WriteAllN(records [][]string) (n int, err error) {
+	var ends []int64 // Output offsets of the ends of the records after n
+	written := func() {
+		for len(ends) > 0 && ends[0] <= w.cw.n {
+			ends, n = ends[1:], n+1
+		}
+	}
+	for _, record := range records {
+		if err = w.Write(record); err != nil {
+			break
+		}
+		w.mu.Lock()
+		ends = append(ends, w.cw.n+int64(w.w.Buffered()))
+		written()
+		w.mu.Unlock()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ferr := w.flush(); err == nil {
+		err = ferr
+	}
+	written()
+	return n, err
+}
+
+// CopyFrom writes the records read from r, until io.EOF, to w and then
+// calls Flush, returning the number of records written and any error
+// from the Flush. Each record is read with ReadBytes and written with
+// WriteBytes, so that no strings are allocated, and is checked and
+// counted exactly as by Write; it is quoted for the options of w, not as
+// it was in the input. CopyFrom stops at the first error from r, even
+// one like ErrFieldCount that comes with a record, or from w; the records
+// before it are flushed all the same.
+func (w *Writer) CopyFrom(r *Reader) (n int64, err error) {
+	for {
+		var record [][]byte
+		if record, err = r.ReadBytes(); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			break
+		}
+		if err = w.WriteBytes(record); err != nil {
+			break
+		}
+		n++
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ferr := w.flush(); err == nil {
+		err = ferr
+	}
+	return n, err
+}
+
+// A TableError is returned by WriteTable for a row that Write rejects.
+type TableError struct {
+	Row int   // 0-based index of the row in rows
+	Err error // The error from Write
+}
+
+func (e *TableError) Error() string {
+	var we *WriteError
+	if errors.As(e.Err, &we) {
+		return fmt.Sprintf("csv: table row %d, column %d: %v", e.Row, we.Column, we.Err)
+	}
+	return fmt.Sprintf("csv: table row %d: %v", e.Row, e.Err)
+}
+
+func (e *TableError) Unwrap() error { return e.Err }
+
+// WriteTable writes header and then rows using Write, requiring every
+// row to have as many fields as header as if FieldsPerRecord were set to
+// its length, and then calls Flush, returning any error from the Flush.
+// FieldsPerRecord itself is left unchanged. WriteTable stops at the first
+// row Write rejects and returns a TableError wrapping the error from
+// Write.
+func (w *Writer) WriteTable(header []string, rows [][]string) error {
+	fields := w.FieldsPerRecord
+	w.FieldsPerRecord = len(header)
+	defer func() { w.FieldsPerRecord = fields }()
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		if err := w.Write(row); err != nil {
+			return &TableError{Row: i, Err: err}
+		}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+// AutoFlush makes the Writer flush on its own once everyRecords records
+// have been written or everyDuration has elapsed since the last flush,
+// whichever comes first. A threshold of zero disables that trigger, so
+// AutoFlush(0, 0) turns automatic flushing off again.
+//
+// The duration-based flush runs on a background goroutine that lives
+// until Close or a later call to AutoFlush. The Writer serializes it with
+// Write and Flush, but Write itself must still be called from a single
+// goroutine at a time.
+func (w *Writer) AutoFlush(everyRecords int, everyDuration time.Duration) {
+	w.mu.Lock()
+	done := w.stopTicker()
+	w.flushRecords = everyRecords
+	w.flushInterval = everyDuration
+	if everyDuration > 0 {
+		w.ticker = time.NewTicker(everyDuration)
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.flushOnTick(w.ticker, w.stop, w.done)
+	}
+	w.mu.Unlock()
+	waitDone(done)
+}
+
+func (w *Writer) flushOnTick(ticker *time.Ticker, stop, done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			select {
+			case <-stop:
+				// Stopped while waiting for the lock, perhaps by Close.
+				w.mu.Unlock()
+				return
+			default:
+			}
+			w.flush()
+			w.mu.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// stopTicker stops the auto-flush goroutine, if any, and returns the
+// channel closed when it has returned, or nil. w.mu must be held, and
+// must be released before waiting on the channel, since the goroutine may
+// be waiting for it.
+func (w *Writer) stopTicker() chan struct{} {
+	if w.ticker == nil {
+		return nil
+	}
+	done := w.done
+	w.ticker.Stop()
+	close(w.stop)
+	w.ticker = nil
+	w.stop = nil
+	w.done = nil
+	return done
+}
+
+// waitDone waits for the auto-flush goroutine that closes done to return,
+// if done is not nil.
+func waitDone(done chan struct{}) {
+	if done != nil {
+		<-done
+	}
+}
+
+// Close flushes any buffered data, stops automatic flushing and, if the
+// underlying io.Writer implements io.Closer, closes it. It returns the
+// first error encountered, including any error from a previous Write or
+// Flush. Writes after Close fail, as does a second call to Close. The
+// auto-flush goroutine has returned by the time Close does, so that the
+// flush of Close is the last one.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return errClosed
+	}
+	w.closed = true
+	done := w.stopTicker()
+	err := w.closeOutput()
+	w.mu.Unlock()
+	waitDone(done)
+	return err
+}
+
+// closeOutput writes the preamble and epilogue, flushes the output and
+// closes it, for Close. w.mu must be held.
+func (w *Writer) closeOutput() error {
+	if w.Preamble != nil || w.Epilogue != nil {
+		w.startEncoder()
+	}
+	err := w.preamble()
+	if err == nil && w.Epilogue != nil {
+		err = w.epilogue()
+	}
+	if ferr := w.flush(); err == nil {
+		err = ferr
+	}
+	if w.enc != nil && err == nil {
+		err = w.enc.close()
+	}
+	if w.closer != nil {
+		if cerr := w.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// startEncoder starts encoding the output with Encoder, if set, when the
+// first output is written.
+func (w *Writer) startEncoder() {
+	if w.Encoder != nil && w.enc == nil {
+		w.enc = newEncodeWriter(w.cw.w, w.Encoder)
+		w.cw.w = w.enc
+	}
+}
+
+// preamble calls Preamble, if set, unless it has already been called for
+// the output.
+func (w *Writer) preamble() error {
+	if w.Preamble == nil || w.preambled {
+		return nil
+	}
+	w.preambled = true
+	return w.frameErr(w.Preamble(w.w))
+}
+
+// epilogue writes a terminator deferred by OmitFinalNewline and calls
+// Epilogue.
+func (w *Writer) epilogue() error {
+	if w.pendingEOL {
+		if err := w.writeEOL(); err != nil {
+			return err
+		}
+		w.pendingEOL = false
+	}
+	return w.frameErr(w.Epilogue(w.w))
+}
+
+// frameErr records err, returned by Preamble or Epilogue, as the error of
+// w if there is none yet, and returns it.
+func (w *Writer) frameErr(err error) error {
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return err
+}
+
+// strictOptions reports whether the options of w are allowed by Strict.
+func (w *Writer) strictOptions() bool {
+	return w.Comma == ',' && w.Quote == '"' && (w.QuoteOpen == 0 || w.QuoteOpen == '"') && (w.QuoteClose == 0 || w.QuoteClose == '"') &&
+		w.Escape == 0 && !w.EscapeNewlines && !w.EncodeBackslashEscapes && !w.DoubledDelimiterEscape &&
+		!w.QuoteAll && !w.QuoteEmpty && !w.StableQuote && w.Comment == 0 && w.Null == "" && w.Terminator == ""
+}
+
+// quoteRunes returns the opening and closing quote characters, which
+// are 0 if quoting is disabled.
+func (w *Writer) quoteRunes() (open, close rune) {
+	open, close = w.Quote, w.Quote
+	if w.QuoteOpen != 0 {
+		open = w.QuoteOpen
+	}
+	if w.QuoteClose != 0 {
+		close = w.QuoteClose
+	}
+	return
 }
 
 // fieldNeedsQuotes reports whether our field must be enclosed in quotes.
-// Fields with a Comma, fields with a quote or newline, and
+// Fields with a Comma, fields with a quote, newline or NUL byte, and
 // fields which start with a space must be enclosed in quotes.
 // We used to quote empty strings, but we do not anymore (as of Go 1.4).
 // The two representations should be equivalent, but Postgres distinguishes
@@ -163,13 +1043,18 @@ func (w *Writer) WriteAll(records [][]string) error {
 // Not quoting the empty string also makes this package match the behavior
 // of Microsoft Excel and Google Drive.
 // For Postgres, quote the data terminating string `\.`.
-func (w *Writer) fieldNeedsQuotes(field string) bool {
+func fieldNeedsQuotes[T text](w *Writer, field T) bool {
 	// If quotes are enforced by configuration, always return true
 	if w.QuoteAll {
 		return true
 	}
 
-	if w.Quote == 0 {
+	if w.Strict {
+		return indexAny(field, ",\"\r\n") >= 0
+	}
+
+	qopen, qclose := w.quoteRunes()
+	if qopen == 0 {
 		return false
 	}
 
@@ -177,23 +1062,250 @@ func (w *Writer) fieldNeedsQuotes(field string) bool {
 		return w.QuoteEmpty
 	}
 
-	if field == `\.` {
+	if w.Null != "" && string(field) == w.Null {
+		return true
+	}
+
+	if len(field) == 2 && field[0] == '\\' && field[1] == '.' {
 		return true
 	}
 
-	if w.Comma < utf8.RuneSelf {
+	escaped := w.DoubledDelimiterEscape || w.escapesDelimiter()
+	if w.Escape != 0 && !w.escapesDelimiter() && indexRune(field, w.Escape) >= 0 {
+		return true
+	}
+
+	if w.Comma < utf8.RuneSelf && qopen < utf8.RuneSelf && qclose < utf8.RuneSelf {
 		for i := 0; i < len(field); i++ {
 			c := field[i]
-			if c == '\n' || c == '\r' || c == byte(w.Quote) || c == byte(w.Comma) {
+			if c == '\n' || c == '\r' || c == 0 || c == byte(qopen) || c == byte(qclose) || c == byte(w.Comma) && !escaped {
 				return true
 			}
 		}
 	} else {
-		if strings.ContainsRune(field, w.Comma) || strings.ContainsRune(field, w.Quote) || strings.ContainsAny(field, "\"\r\n") {
+		if indexRune(field, w.Comma) >= 0 && !escaped || indexRune(field, qopen) >= 0 || indexRune(field, qclose) >= 0 || indexAny(field, "\"\r\n\x00") >= 0 {
 			return true
 		}
 	}
 
-	r1, _ := utf8.DecodeRuneInString(field)
+	if w.Terminator != "" && overlapsTerminator(field, w.Terminator) {
+		return true
+	}
+
+	r1, _ := decodeRune(field)
 	return unicode.IsSpace(r1)
 }
+
+// overlapsTerminator reports whether an occurrence of term could start
+// inside field when it is written unquoted: either field contains term or
+// it ends with the start of term, which what follows could complete.
+func overlapsTerminator[T text](field T, term string) bool {
+	if indexString(field, term) >= 0 {
+		return true
+	}
+	for k := 1; k < len(term); k++ {
+		if len(field) >= k && string(field[len(field)-k:]) == term[:k] {
+			return true
+		}
+	}
+	return false
+}
+
+// recordKey returns the key of record for Dedup, in the dedupKey buffer:
+// the result of DedupKey or else the fields, each preceded by its length.
+func recordKey[T text](w *Writer, record []T) []byte {
+	if w.DedupKey != nil {
+		fields, ok := any(record).([]string)
+		if !ok {
+			w.dedupFields = w.dedupFields[:0]
+			for _, field := range record {
+				w.dedupFields = append(w.dedupFields, string(field))
+			}
+			fields = w.dedupFields
+			defer clear(w.dedupFields) // Let the fields be collected
+		}
+		w.dedupKey = append(w.dedupKey[:0], w.DedupKey(fields)...)
+		return w.dedupKey
+	}
+	key := w.dedupKey[:0]
+	for _, field := range record {
+		key = binary.AppendUvarint(key, uint64(len(field)))
+		key = append(key, field...)
+	}
+	w.dedupKey = key
+	return key
+}
+
+// DedupReset forgets the records written so far with Dedup, releasing
+// the memory of their keys, so that a record written again afterwards
+// is not a duplicate. The count of Duplicates is kept.
+func (w *Writer) DedupReset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.seen = nil
+}
+
+// Duplicates returns the number of records skipped by Dedup since the
+// Writer was created or Reset.
+func (w *Writer) Duplicates() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.duplicates
+}
+
+// validate passes record to Validate as it will be written.
+func validate[T text](w *Writer, record []T) error {
+	w.validated = w.validated[:0]
+	for _, field := range record {
+		s := string(field)
+		if w.EscapeNewlines && strings.ContainsAny(s, "\\\r\n") {
+			s = newlineEscaper.Replace(s)
+		}
+		w.validated = append(w.validated, s)
+	}
+	return w.Validate(w.validated)
+}
+
+// writeBackslashEscaped writes field escaped for EncodeBackslashEscapes.
+func writeBackslashEscaped[T text](w *Writer, field T) error {
+	special := "\\\n\r\t\x00" + string(w.Comma)
+	if qopen, qclose := w.quoteRunes(); qopen != 0 {
+		special += string([]rune{qopen, qclose})
+	}
+	if w.Comment != 0 {
+		special += string(w.Comment)
+	}
+	for len(field) > 0 {
+		i := indexAny(field, special)
+		if i < 0 {
+			return writeText(w.w, field)
+		}
+		if err := writeText(w.w, field[:i]); err != nil {
+			return err
+		}
+		c, size := decodeRune(field[i:])
+		var err error
+		switch c {
+		case '\n':
+			_, err = w.w.WriteString(`\n`)
+		case '\r':
+			_, err = w.w.WriteString(`\r`)
+		case '\t':
+			_, err = w.w.WriteString(`\t`)
+		case 0:
+			_, err = w.w.WriteString(`\0`)
+		default:
+			if err = w.w.WriteByte('\\'); err == nil {
+				_, err = w.w.WriteRune(c)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		field = field[i+size:]
+	}
+	return nil
+}
+
+// newlineEscaper escapes fields for EscapeNewlines.
+var newlineEscaper = strings.NewReplacer("\\", `\\`, "\n", `\n`, "\r", `\r`)
+
+// startsWithComment reports whether field begins with the Comment rune.
+func startsWithComment[T text](w *Writer, field T) bool {
+	if qopen, _ := w.quoteRunes(); w.Comment == 0 || qopen == 0 || len(field) == 0 {
+		return false
+	}
+	r1, _ := decodeRune(field)
+	return r1 == w.Comment
+}
+
+// misreadAsDoubled reports whether field, the nth of count fields, has to
+// be quoted for DoubledDelimiterEscape because a Reader would otherwise
+// take the delimiter before it and its first character for a doubled one.
+func misreadAsDoubled[T text](w *Writer, field T, n, count int) bool {
+	if qopen, _ := w.quoteRunes(); !w.DoubledDelimiterEscape || qopen == 0 || n == 0 {
+		return false
+	}
+	if len(field) == 0 {
+		return n < count-1
+	}
+	r1, _ := decodeRune(field)
+	return r1 == w.Comma
+}
+
+// escapesDelimiter reports whether unquoted fields are written with
+// EscapeDelimiter.
+func (w *Writer) escapesDelimiter() bool {
+	return w.EscapeDelimiter && w.Escape != 0 && !w.DoubledDelimiterEscape
+}
+
+// escapeDelimiter returns field with each comma and esc preceded by esc.
+// Invalid UTF-8 is copied unchanged.
+func escapeDelimiter(field string, comma, esc rune) string {
+	var b strings.Builder
+	b.Grow(len(field) + 4)
+	for len(field) > 0 {
+		c, size := utf8.DecodeRuneInString(field)
+		if c == comma || c == esc {
+			b.WriteRune(esc)
+		}
+		b.WriteString(field[:size])
+		field = field[size:]
+	}
+	return b.String()
+}
+
+// The helpers below dispatch to the strings or bytes package, so that
+// Write and WriteBytes share one implementation without converting
+// between string and []byte.
+
+func indexAny[T text](s T, chars string) int {
+	switch s := any(s).(type) {
+	case string:
+		return strings.IndexAny(s, chars)
+	case []byte:
+		return bytes.IndexAny(s, chars)
+	}
+	panic("unreachable")
+}
+
+func indexString[T text](s T, substr string) int {
+	switch s := any(s).(type) {
+	case string:
+		return strings.Index(s, substr)
+	case []byte:
+		return bytes.Index(s, []byte(substr))
+	}
+	panic("unreachable")
+}
+
+func indexRune[T text](s T, r rune) int {
+	switch s := any(s).(type) {
+	case string:
+		return strings.IndexRune(s, r)
+	case []byte:
+		return bytes.IndexRune(s, r)
+	}
+	panic("unreachable")
+}
+
+func decodeRune[T text](s T) (rune, int) {
+	switch s := any(s).(type) {
+	case string:
+		return utf8.DecodeRuneInString(s)
+	case []byte:
+		return utf8.DecodeRune(s)
+	}
+	panic("unreachable")
+}
+
+func writeText[T text](w *bufio.Writer, s T) error {
+	var err error
+	switch s := any(s).(type) {
+	case string:
+		_, err = w.WriteString(s)
+	case []byte:
+		_, err = w.Write(s)
+	}
+	return err
+}
@@ -12,8 +12,14 @@ package flexcsv
 import (
 	"bytes"
 	"errors"
+	"io"
+	"math/rand"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 var writeTests = []struct {
@@ -25,6 +31,11 @@ var writeTests = []struct {
 	Quote      rune
 	QuoteEmpty bool
 	QuoteAll   bool
+	Escape     rune
+	OmitEOL    bool // OmitFinalNewline
+	Comment    rune
+	Stable     bool // StableQuote
+	Terminator string
 }{
 	{Input: [][]string{{"abc"}}, Output: "abc\n"},
 	{Input: [][]string{{"abc"}}, Output: "abc\r\n", UseCRLF: true},
@@ -67,6 +78,33 @@ var writeTests = []struct {
 	// Test QuoteEmpty.
 	{Input: [][]string{{"", "abc"}}, Output: `"",abc` + "\n", QuoteEmpty: true},
 	{Input: [][]string{{"", "abc"}}, Output: `,abc` + "\n", QuoteEmpty: false},
+	// Test Escape.
+	{Input: [][]string{{`a"b`, "c"}}, Output: `"a\"b",c` + "\n", Escape: '\\'},
+	{Input: [][]string{{`a\b`, `c\"`}}, Output: `"a\\b","c\\\""` + "\n", Escape: '\\'},
+	{Input: [][]string{{"a,b", "c\nd"}}, Output: "\"a,b\",\"c\nd\"\n", Escape: '\\'},
+	{Input: [][]string{{`a|b`}}, Output: `|a\|b|` + "\n", Quote: '|', Escape: '\\'},
+	// Test OmitFinalNewline.
+	{Input: [][]string{{"abc", "def"}}, Output: "abc,def", OmitEOL: true},
+	{Input: [][]string{{"abc"}, {"def"}}, Output: "abc\r\ndef", UseCRLF: true, OmitEOL: true},
+	{Input: [][]string{{"a\nb"}, {""}}, Output: "\"a\nb\"\n", OmitEOL: true},
+	{Input: [][]string{{"foo"}}, Comma: '"', Error: errInvalidDelim, OmitEOL: true},
+	{Input: [][]string{{"#a", "#b"}, {"b", "#c"}}, Output: "\"#a\",#b\nb,#c\n", Comment: '#'},
+	{Input: [][]string{{"#a", "#b"}}, Output: "\"#a\",\"#b\"\n", Comment: '#', QuoteAll: true},
+	{Input: [][]string{{"#a\"", "b"}}, Output: "\"#a\"\"\",b\n", Comment: '#'},
+	{Input: [][]string{{"§a"}, {"a§"}}, Output: "\"§a\"\na§\n", Comment: '§'},
+	{Input: [][]string{{"#a"}}, Output: "|#a|\n", Comment: '#', Quote: '|'},
+	{Input: [][]string{{"a"}}, Comment: ',', Error: errInvalidDelim},
+	{Input: [][]string{{"a"}}, Comment: '"', Error: errInvalidDelim},
+	{Input: [][]string{{"a", "b c"}, {"x,y", "d"}, {"e", "f"}}, Output: "a,b c\n\"x,y\",d\n\"e\",f\n", Stable: true},
+	{Input: [][]string{{"a", "b\nc"}, {"d", "e", "f"}, {"g"}, {"h", "i"}}, Output: "a,\"b\nc\"\nd,\"e\",f\ng\nh,\"i\"\n", Stable: true},
+	{Input: [][]string{{"x,y"}, {"e"}}, Output: "\"x,y\"\ne\n"},
+	{Input: [][]string{{"a", "b"}, {"c"}}, Output: "a,b;;c;;", Terminator: ";;"},
+	{Input: [][]string{{"a;;b", "c;"}, {"d"}}, Output: "\"a;;b\",\"c;\";;d;;", Terminator: ";;"},
+	{Input: [][]string{{"a\r\nb", "c;d"}}, Output: "\"a\r\nb\",c;d;;", Terminator: ";;", UseCRLF: true},
+	{Input: [][]string{{"a"}, {"b"}}, Output: "a\x00b", Terminator: "\x00", OmitEOL: true},
+	{Input: [][]string{{"a"}}, Terminator: ",;", Error: errInvalidDelim},
+	{Input: [][]string{{"a"}}, Terminator: "x,", Error: errInvalidDelim},
+	{Input: [][]string{{"a"}}, Terminator: "\"", Error: errInvalidDelim},
 }
 
 func TestWrite(t *testing.T) {
@@ -76,6 +114,11 @@ func TestWrite(t *testing.T) {
 		f.UseCRLF = tt.UseCRLF
 		f.QuoteAll = tt.QuoteAll
 		f.QuoteEmpty = tt.QuoteEmpty
+		f.Escape = tt.Escape
+		f.OmitFinalNewline = tt.OmitEOL
+		f.Comment = tt.Comment
+		f.StableQuote = tt.Stable
+		f.Terminator = tt.Terminator
 		if tt.Comma != 0 {
 			f.Comma = tt.Comma
 		}
@@ -93,6 +136,332 @@ func TestWrite(t *testing.T) {
 	}
 }
 
+func TestWriteBytes(t *testing.T) {
+	for n, tt := range writeTests {
+		b := &strings.Builder{}
+		f := NewWriter(b)
+		f.UseCRLF = tt.UseCRLF
+		f.QuoteAll = tt.QuoteAll
+		f.QuoteEmpty = tt.QuoteEmpty
+		f.Escape = tt.Escape
+		f.OmitFinalNewline = tt.OmitEOL
+		f.Comment = tt.Comment
+		f.StableQuote = tt.Stable
+		f.Terminator = tt.Terminator
+		if tt.Comma != 0 {
+			f.Comma = tt.Comma
+		}
+		if tt.Quote != 0 {
+			f.Quote = tt.Quote
+		}
+		var err error
+		for _, record := range tt.Input {
+			if err = f.WriteBytes(toBytes(record)); err != nil {
+				break
+			}
+		}
+		f.Flush()
+		if err != tt.Error {
+			t.Errorf("Unexpected error:\ngot  %v\nwant %v", err, tt.Error)
+		}
+		out := b.String()
+		if out != tt.Output {
+			t.Errorf("#%d: out=%q want %q", n, out, tt.Output)
+		}
+	}
+}
+
+func TestWriteBytesAllocs(t *testing.T) {
+	w := NewWriter(io.Discard)
+	record := toBytes([]string{"abc", "a,b", "multi\nline", " space", ""})
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := w.WriteBytes(record); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("WriteBytes allocated %v times, want 0", allocs)
+	}
+}
+
+func toBytes(record []string) [][]byte {
+	fields := make([][]byte, len(record))
+	for i, field := range record {
+		fields[i] = []byte(field)
+	}
+	return fields
+}
+
+func TestBytesWritten(t *testing.T) {
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.OmitFinalNewline = true
+	check := func(want int64) {
+		t.Helper()
+		if got := w.BytesWritten(); got != want {
+			t.Errorf("BytesWritten() = %d, want %d", got, want)
+		}
+	}
+	check(0)
+	w.Write([]string{"a", "b,c"})
+	check(7) // a,"b,c"
+	w.Flush()
+	check(7)
+	if b.String() != `a,"b,c"` {
+		t.Errorf("output after Flush = %q, want no terminator", b.String())
+	}
+	w.Write([]string{"d"})
+	check(9)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	check(9)
+	if got, want := b.String(), "a,\"b,c\"\nd"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if n := int64(len(b.String())); n != w.BytesWritten() {
+		t.Errorf("BytesWritten() = %d, but %d bytes were written", w.BytesWritten(), n)
+	}
+}
+
+func TestWriteEscapeRoundTrip(t *testing.T) {
+	records := [][]string{
+		{`a"b`, `c\d`, `\`, `"`, `\"`},
+		{"a,b", "multi\nline", "", "x", "\r"},
+		{`"quoted"`, `trailing\`, `\leading`, `mid\,dle`, `""`},
+	}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.Escape = '\\'
+	if err := w.WriteAll(records); err != nil {
+		t.Fatalf("WriteAll: %v", err)
+	}
+	r := NewReader(strings.NewReader(b.String()))
+	r.Escape = '\\'
+	got, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll(%q): %v", b.String(), err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Fatalf("round trip of %q:\ngot  %q\nwant %q", b.String(), got, records)
+	}
+}
+
+func TestWriteQuoteEscapeRoundTrip(t *testing.T) {
+	records := [][]string{
+		{`a"b`, `c^d`, `^`, `"`, `^"`},
+		{"a,b", "multi\nline", "", "x", `""`},
+		{`"quoted"`, `trailing^`, `^leading`, `^^`, `"^"`},
+	}
+	tests := []struct {
+		name        string
+		escape      rune // Writer.Escape
+		quoteEscape rune // Reader.QuoteEscape
+		strict      bool // Reader.StrictQuoteEscape
+	}{
+		{"Doubled", 0, 0, false},
+		{"Caret", '^', '^', false},
+		{"CaretStrict", '^', '^', true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &strings.Builder{}
+			w := NewWriter(b)
+			w.Escape = tt.escape
+			if err := w.WriteAll(records); err != nil {
+				t.Fatalf("WriteAll: %v", err)
+			}
+			r := NewReader(strings.NewReader(b.String()))
+			r.QuoteEscape = tt.quoteEscape
+			r.StrictQuoteEscape = tt.strict
+			got, err := r.ReadAll()
+			if err != nil {
+				t.Fatalf("ReadAll(%q): %v", b.String(), err)
+			}
+			if !reflect.DeepEqual(got, records) {
+				t.Fatalf("round trip of %q:\ngot  %q\nwant %q", b.String(), got, records)
+			}
+		})
+	}
+}
+
+func TestWriteEscapeNewlines(t *testing.T) {
+	records := [][]string{
+		{"multi\nline", "cr\r\nlf", `back\slash`, `literal \n`},
+		{"", "a,b", "\r", `\`},
+	}
+	for _, crlf := range []bool{false, true} {
+		b := &strings.Builder{}
+		w := NewWriter(b)
+		w.EscapeNewlines = true
+		w.UseCRLF = crlf
+		if err := w.WriteAll(records); err != nil {
+			t.Fatalf("WriteAll: %v", err)
+		}
+		want := `multi\nline,cr\r\nlf,back\\slash,literal \\n` + "\n" + `,"a,b",\r,\\` + "\n"
+		if crlf {
+			want = strings.ReplaceAll(want, "\n", "\r\n")
+		}
+		if b.String() != want {
+			t.Errorf("UseCRLF=%v: output = %q, want %q", crlf, b.String(), want)
+		}
+		r := NewReader(strings.NewReader(b.String()))
+		r.UnescapeNewlines = true
+		got, err := r.ReadAll()
+		if err != nil || !reflect.DeepEqual(got, records) {
+			t.Errorf("UseCRLF=%v: round trip = %q, %v; want %q", crlf, got, err, records)
+		}
+	}
+}
+
+func TestWriteDoubledDelimiterEscape(t *testing.T) {
+	records := [][]string{
+		{"a,b", "c", ",d", "e,"},
+		{"", "", ",", ""},
+		{",,", "f,,,g", "h\ni,j"},
+	}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.DoubledDelimiterEscape = true
+	if err := w.WriteAll(records); err != nil {
+		t.Fatalf("WriteAll: %v", err)
+	}
+	want := "a,,b,c,\",d\",e,,\n" + ",\"\",\",\",\n" + ",,,,,f,,,,,,g,\"h\ni,j\"\n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+	r := NewReader(strings.NewReader(b.String()))
+	r.DoubledDelimiterEscape = true
+	r.FieldsPerRecord = -1
+	got, err := r.ReadAll()
+	if err != nil || !reflect.DeepEqual(got, records) {
+		t.Errorf("round trip = %q, %v; want %q", got, err, records)
+	}
+}
+
+func TestWriteEscapeDelimiter(t *testing.T) {
+	records := [][]string{
+		{"a,b", `c\d`, ",", ""},
+		{`e\,f`, "g\nh,i", `j"k`, " l,m"},
+		{"\xff,", "n"},
+	}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.Escape = '\\'
+	w.EscapeDelimiter = true
+	if err := w.WriteAll(records); err != nil {
+		t.Fatalf("WriteAll: %v", err)
+	}
+	want := `a\,b,c\\d,\,,` + "\n" + `e\\\,f,"g` + "\n" + `h,i","j\"k"," l,m"` + "\n" + "\xff\\,,n\n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+	r := NewReader(strings.NewReader(b.String()))
+	r.Escape = '\\'
+	r.FieldsPerRecord = -1
+	got, err := r.ReadAll()
+	if err != nil || !reflect.DeepEqual(got, records) {
+		t.Errorf("round trip = %q, %v; want %q", got, err, records)
+	}
+
+	// Without Escape, fields are quoted as usual.
+	b.Reset()
+	w = NewWriter(b)
+	w.EscapeDelimiter = true
+	w.Write([]string{"a,b"})
+	w.Flush()
+	if want := "\"a,b\"\n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriteValidate(t *testing.T) {
+	errNoKey := errors.New("empty key")
+	var seen [][]string
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.EscapeNewlines = true
+	w.Validate = func(record []string) error {
+		seen = append(seen, append([]string(nil), record...))
+		if record[0] == "" {
+			return errNoKey
+		}
+		return nil
+	}
+	if err := w.Write([]string{"k1", "a,b\nc"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.WriteBytes([][]byte{nil, []byte("x")}); err != errNoKey {
+		t.Errorf("WriteBytes = %v, want %v", err, errNoKey)
+	}
+	if err := w.WriteTyped("k2", 3); err != nil {
+		t.Fatalf("WriteTyped: %v", err)
+	}
+	w.Flush()
+	if err := w.Error(); err != errNoKey {
+		t.Errorf("Error = %v, want %v", err, errNoKey)
+	}
+	if got, want := b.String(), "k1,\"a,b\\nc\"\nk2,3\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	want := [][]string{{"k1", `a,b\nc`}, {"", "x"}, {"k2", "3"}}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("Validate saw %q, want %q", seen, want)
+	}
+}
+
+func TestWriterReset(t *testing.T) {
+	var b1, b2 strings.Builder
+	w := NewWriter(&b1)
+	w.StableQuote = true
+	w.OmitFinalNewline = true
+	w.Write([]string{"a b", "c,d"})
+	w.Write([]string{"unflushed"})
+	w.Reset(&b2)
+	w.Write([]string{"e", "f"})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if b1.Len() != 0 {
+		t.Errorf("output before Reset = %q, want it discarded", b1.String())
+	}
+	if got, want := b2.String(), "e,f"; got != want {
+		t.Errorf("output after Reset = %q, want %q", got, want)
+	}
+	w.Reset(&b1)
+	if err := w.Write([]string{"g"}); err != nil {
+		t.Errorf("Write after Reset of a closed Writer: %v", err)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.QuoteAll = true
+	rows := [][]string{{"1", "a"}, {"2", "b"}}
+	if err := w.WriteTable([]string{"id", "name"}, rows); err != nil {
+		t.Fatalf("WriteTable: %v", err)
+	}
+	if got, want := b.String(), `"id","name"`+"\n"+`"1","a"`+"\n"+`"2","b"`+"\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if w.FieldsPerRecord != 0 {
+		t.Errorf("FieldsPerRecord = %d after WriteTable, want 0", w.FieldsPerRecord)
+	}
+
+	b.Reset()
+	w = NewWriter(b)
+	err := w.WriteTable([]string{"id", "name"}, [][]string{{"1", "a"}, {"2"}})
+	var te *TableError
+	var we *WriteError
+	if !errors.As(err, &te) || te.Row != 1 || !errors.As(err, &we) || we.Err != ErrFieldCount || we.Column != 1 {
+		t.Fatalf("WriteTable error = %v, want a TableError for row 1 wrapping a WriteError for ErrFieldCount", err)
+	}
+	if got, want := err.Error(), "csv: table row 1, column 1: wrong number of fields"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
 type errorWriter struct{}
 
 func (e errorWriter) Write(b []byte) (int, error) {
@@ -120,6 +489,396 @@ func TestError(t *testing.T) {
 	}
 }
 
+// limitWriter accepts n bytes and then fails.
+type limitWriter struct {
+	buf bytes.Buffer
+	n   int
+}
+
+func (l *limitWriter) Write(b []byte) (int, error) {
+	if len(b) > l.n {
+		l.buf.Write(b[:l.n])
+		n := l.n
+		l.n = 0
+		return n, errors.New("Test")
+	}
+	l.n -= len(b)
+	return l.buf.Write(b)
+}
+
+func TestWriteAllN(t *testing.T) {
+	records := make([][]string, 100)
+	for i := range records {
+		records[i] = []string{strings.Repeat("x", 49), strings.Repeat("y", 49)} // 100 bytes per record
+	}
+	lw := &limitWriter{n: 5050}
+	n, err := NewWriter(lw).WriteAllN(records)
+	if n != 50 || err == nil {
+		t.Errorf("WriteAllN = %d, %v, want 50 and an error", n, err)
+	}
+	if lw.buf.Len() != 5050 {
+		t.Errorf("wrote %d bytes, want 5050", lw.buf.Len())
+	}
+
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.Validate = func(record []string) error {
+		if record[0] == "bad" {
+			return errors.New("bad record")
+		}
+		return nil
+	}
+	n, err = w.WriteAllN([][]string{{"a"}, {"b"}, {"bad"}, {"c"}})
+	if n != 2 || err == nil || b.String() != "a\nb\n" {
+		t.Errorf("WriteAllN = %d, %v with output %q, want 2, an error and %q", n, err, b.String(), "a\nb\n")
+	}
+
+	b.Reset()
+	if n, err := NewWriter(&b).WriteAllN(records); n != len(records) || err != nil {
+		t.Errorf("WriteAllN = %d, %v, want %d, nil", n, err, len(records))
+	}
+}
+
+// syncBuffer is a bytes.Buffer that is safe to read while a Writer's
+// auto-flush goroutine writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAutoFlushRecords(t *testing.T) {
+	b := &bytes.Buffer{}
+	f := NewWriter(b)
+	f.AutoFlush(2, 0)
+	defer f.Close()
+
+	f.Write([]string{"a"})
+	if got := b.String(); got != "" {
+		t.Fatalf("flushed after one record: %q", got)
+	}
+	f.Write([]string{"b"})
+	if got, want := b.String(), "a\nb\n"; got != want {
+		t.Fatalf("after two records: got %q want %q", got, want)
+	}
+	f.Write([]string{"c"})
+	if got, want := b.String(), "a\nb\n"; got != want {
+		t.Fatalf("after three records: got %q want %q", got, want)
+	}
+
+	// An explicit Flush restarts the count.
+	f.Flush()
+	f.Write([]string{"d"})
+	if got, want := b.String(), "a\nb\nc\n"; got != want {
+		t.Fatalf("after Flush: got %q want %q", got, want)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got, want := b.String(), "a\nb\nc\nd\n"; got != want {
+		t.Fatalf("after Close: got %q want %q", got, want)
+	}
+}
+
+func TestAutoFlushDuration(t *testing.T) {
+	b := &syncBuffer{}
+	f := NewWriter(b)
+	f.AutoFlush(0, 5*time.Millisecond)
+
+	f.Write([]string{"abc"})
+	deadline := time.Now().Add(5 * time.Second)
+	for b.String() == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("record was not flushed by the timer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := b.String(), "abc\n"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if f.ticker != nil {
+		t.Fatal("Close did not stop the auto-flush ticker")
+	}
+}
+
+func TestAutoFlushDisable(t *testing.T) {
+	b := &bytes.Buffer{}
+	f := NewWriter(b)
+	f.AutoFlush(1, 0)
+	f.AutoFlush(0, 0)
+	f.Write([]string{"abc"})
+	if got := b.String(); got != "" {
+		t.Fatalf("flushed with auto-flush disabled: %q", got)
+	}
+	f.Close()
+	if got, want := b.String(), "abc\n"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+// closedWriter fails writes once closed is set, to catch flushes that happen
+// after Close has returned.
+type closedWriter struct {
+	closed atomic.Bool
+	t      *testing.T
+}
+
+func (w *closedWriter) Write(p []byte) (int, error) {
+	if w.closed.Load() {
+		w.t.Errorf("Write of %q after Close", p)
+	}
+	return len(p), nil
+}
+
+func TestAutoFlushClose(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		cw := &closedWriter{t: t}
+		f := NewWriter(cw)
+		f.AutoFlush(0, time.Microsecond)
+		f.Write([]string{"abc"})
+		f.AutoFlush(0, time.Microsecond)
+		f.Write([]string{"def"})
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		cw.closed.Store(true)
+		time.Sleep(10 * time.Microsecond)
+	}
+}
+
+func TestCopyFrom(t *testing.T) {
+	r := NewReader(strings.NewReader("a;\"b;c\"\nd;\"e\"\"f\"\n"))
+	r.Comma = ';'
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.Comma = '\t'
+	n, err := w.CopyFrom(r)
+	if want := "a\tb;c\nd\t\"e\"\"f\"\n"; n != 2 || err != nil || b.String() != want {
+		t.Errorf("CopyFrom() = %d, %v, output %q; want 2, nil, %q", n, err, b.String(), want)
+	}
+
+	r = NewReader(strings.NewReader("a,b\nc,d\ne\n"))
+	b.Reset()
+	w = NewWriter(&b)
+	n, err = w.CopyFrom(r)
+	if !errors.Is(err, ErrFieldCount) || n != 2 || b.String() != "a,b\nc,d\n" {
+		t.Errorf("CopyFrom() with a short record = %d, %v, output %q", n, err, b.String())
+	}
+
+	r = NewReader(strings.NewReader("a,b\nc\n"))
+	r.FieldsPerRecord = -1
+	b.Reset()
+	w = NewWriter(&b)
+	w.FieldsPerRecord = 2
+	n, err = w.CopyFrom(r)
+	if !errors.Is(err, ErrFieldCount) || n != 1 || b.String() != "a,b\n" {
+		t.Errorf("CopyFrom() with FieldsPerRecord = %d, %v, output %q", n, err, b.String())
+	}
+}
+
+func TestDedup(t *testing.T) {
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.Dedup = true
+	w.Write([]string{"a,b", "c"})
+	w.Write([]string{"a", "b,c"})
+	w.WriteBytes([][]byte{[]byte("a,b"), []byte("c")})
+	w.Write([]string{"a", "b,c"})
+	w.DedupReset()
+	w.Write([]string{"a", "b,c"})
+	w.DedupKey = func(record []string) string { return strings.ToLower(record[0]) }
+	w.Write([]string{"X", "1"})
+	w.WriteBytes([][]byte{[]byte("x"), []byte("2")})
+	w.Flush()
+	want := "\"a,b\",c\na,\"b,c\"\na,\"b,c\"\nX,1\n"
+	if b.String() != want || w.Duplicates() != 3 {
+		t.Errorf("output = %q, %d duplicates; want %q, 3", b.String(), w.Duplicates(), want)
+	}
+
+	w.Reset(&b)
+	w.FieldsPerRecord = 2
+	if err := w.Write([]string{"y"}); !errors.Is(err, ErrFieldCount) {
+		t.Fatalf("Write() error = %v, want ErrFieldCount", err)
+	}
+	if err := w.Write([]string{"y"}); !errors.Is(err, ErrFieldCount) || w.Duplicates() != 0 {
+		t.Errorf("rejected record deduplicated: error = %v, %d duplicates", err, w.Duplicates())
+	}
+}
+
+func TestOnFlush(t *testing.T) {
+	type flush struct {
+		bytes int64
+		err   bool
+	}
+	var got []flush
+	lw := &limitWriter{n: 8}
+	f := NewWriter(lw)
+	f.OnFlush = func(bytes int64, err error) {
+		got = append(got, flush{bytes, err != nil})
+	}
+	f.Write([]string{"abc"})
+	f.Flush()
+	f.Flush()
+	f.AutoFlush(1, 0)
+	f.Write([]string{"de"})
+	f.Write([]string{"fg"})
+	f.Close()
+	want := []flush{{4, false}, {0, false}, {3, false}, {1, true}, {0, true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OnFlush calls = %v, want %v", got, want)
+	}
+}
+
+func TestOnFlushAfterClose(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		var closed atomic.Bool
+		f := NewWriter(io.Discard)
+		f.OnFlush = func(int64, error) {
+			if closed.Load() {
+				t.Error("OnFlush called after Close")
+			}
+		}
+		f.AutoFlush(0, time.Microsecond)
+		f.Write([]string{"abc"})
+		time.Sleep(time.Duration(i%5) * time.Microsecond)
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		closed.Store(true)
+		time.Sleep(10 * time.Microsecond)
+	}
+}
+
+func TestPreambleEpilogue(t *testing.T) {
+	var b strings.Builder
+	f := NewWriter(&b)
+	f.OmitFinalNewline = true
+	f.Preamble = func(w io.Writer) error {
+		_, err := io.WriteString(w, "Sales report\n\n")
+		return err
+	}
+	f.Epilogue = func(w io.Writer) error {
+		_, err := io.WriteString(w, "Total: 2\n")
+		return err
+	}
+	f.Write([]string{"a", "b,c"})
+	if n := f.BytesWritten(); n != 21 {
+		t.Errorf("BytesWritten = %d, want 21", n)
+	}
+	f.Write([]string{"d"})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close error = %v", err)
+	}
+	if want := "Sales report\n\na,\"b,c\"\nd\nTotal: 2\n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+	if n := f.BytesWritten(); n != int64(b.Len()) {
+		t.Errorf("BytesWritten = %d, want %d", n, b.Len())
+	}
+
+	// Without records, Close still writes the preamble.
+	b.Reset()
+	f.Reset(&b)
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close error = %v", err)
+	}
+	if want := "Sales report\n\nTotal: 2\n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+
+	errPreamble := errors.New("preamble failed")
+	f.Reset(&b)
+	f.Preamble = func(io.Writer) error { return errPreamble }
+	if err := f.Write([]string{"x"}); err != errPreamble {
+		t.Errorf("Write error = %v, want %v", err, errPreamble)
+	}
+	if err := f.Error(); err != errPreamble {
+		t.Errorf("Error = %v, want %v", err, errPreamble)
+	}
+}
+
+type closeWriter struct {
+	bytes.Buffer
+	closed int
+	err    error
+}
+
+func (c *closeWriter) Close() error {
+	c.closed++
+	return c.err
+}
+
+func TestClose(t *testing.T) {
+	c := &closeWriter{}
+	f := NewWriter(c)
+	f.Write([]string{"abc"})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got, want := c.String(), "abc\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if c.closed != 1 {
+		t.Errorf("underlying writer closed %d times, want 1", c.closed)
+	}
+	if err := f.Write([]string{"def"}); err != errClosed {
+		t.Errorf("Write after Close: got %v, want %v", err, errClosed)
+	}
+	if err := f.Close(); err != errClosed {
+		t.Errorf("second Close: got %v, want %v", err, errClosed)
+	}
+	if c.closed != 1 {
+		t.Errorf("underlying writer closed %d times, want 1", c.closed)
+	}
+
+	// The error from the underlying Close is reported.
+	c = &closeWriter{err: errors.New("close failed")}
+	f = NewWriter(c)
+	if err := f.Close(); err != c.err {
+		t.Errorf("got %v, want %v", err, c.err)
+	}
+
+	// A flush error takes precedence, but the writer is still closed.
+	c = &closeWriter{err: errors.New("close failed")}
+	f = NewWriter(struct {
+		errorWriter
+		*closeWriter
+	}{closeWriter: c})
+	f.Write([]string{"abc"})
+	if err := f.Close(); err == nil || err == c.err {
+		t.Errorf("got %v, want the flush error", err)
+	}
+	if c.closed != 1 {
+		t.Errorf("underlying writer closed %d times, want 1", c.closed)
+	}
+
+	// Non-closers are only flushed.
+	b := &bytes.Buffer{}
+	f = NewWriter(b)
+	f.Write([]string{"abc"})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got, want := b.String(), "abc\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
 var benchmarkWriteData = [][]string{
 	{"abc", "def", "12356", "1234567890987654311234432141542132"},
 	{"abc", "def", "12356", "1234567890987654311234432141542132"},
@@ -136,3 +895,342 @@ func BenchmarkWrite(b *testing.B) {
 		w.Flush()
 	}
 }
+
+// benchmarkWriteBytesData holds benchmarkWriteData as byte slices, the
+// way it arrives from a memory-mapped file.
+var benchmarkWriteBytesData = func() [][][]byte {
+	records := make([][][]byte, len(benchmarkWriteData))
+	for i, record := range benchmarkWriteData {
+		records[i] = toBytes(record)
+	}
+	return records
+}()
+
+// BenchmarkWriteFromBytes converts byte-sourced fields to strings for Write.
+func BenchmarkWriteFromBytes(b *testing.B) {
+	record := make([]string, 0, 4)
+	for i := 0; i < b.N; i++ {
+		w := NewWriter(&bytes.Buffer{})
+		for _, fields := range benchmarkWriteBytesData {
+			record = record[:0]
+			for _, field := range fields {
+				record = append(record, string(field))
+			}
+			if err := w.Write(record); err != nil {
+				b.Fatal(err)
+			}
+		}
+		w.Flush()
+	}
+}
+
+func BenchmarkWriteBytes(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		w := NewWriter(&bytes.Buffer{})
+		for _, record := range benchmarkWriteBytesData {
+			if err := w.WriteBytes(record); err != nil {
+				b.Fatal(err)
+			}
+		}
+		w.Flush()
+	}
+}
+
+func TestWriteTerminatorRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	pieces := []string{"a", "|", "||", "|x|", ",", "\n", "\r\n", `"`, " "}
+	for _, term := range []string{"||", "|x|", "\x1e", "\r\n"} {
+		var records [][]string
+		for i := 0; i < 200; i++ {
+			rec := make([]string, 3)
+			for j := range rec {
+				for k := rnd.Intn(4); k > 0; k-- {
+					rec[j] += pieces[rnd.Intn(len(pieces))]
+				}
+			}
+			records = append(records, rec)
+		}
+		var b strings.Builder
+		w := NewWriter(&b)
+		w.Terminator = term
+		if err := w.WriteAll(records); err != nil {
+			t.Fatalf("terminator %q: WriteAll error = %v", term, err)
+		}
+		r := NewReader(strings.NewReader(b.String()))
+		r.Terminator = term
+		got, err := r.ReadAll()
+		if err != nil || !reflect.DeepEqual(got, records) {
+			t.Fatalf("terminator %q: read back %q, %v\nwant %q", term, got, err, records)
+		}
+	}
+}
+
+func TestWriteField(t *testing.T) {
+	records := [][]string{{"a", "b,c"}, {}, {"d\ne", "", `"f"`}}
+	var want, got strings.Builder
+	NewWriter(&want).WriteAll(records)
+	w := NewWriter(&got)
+	for _, rec := range records {
+		for _, field := range rec {
+			if err := w.WriteField(field); err != nil {
+				t.Fatalf("WriteField(%q) error = %v", field, err)
+			}
+		}
+		if err := w.EndRecord(); err != nil {
+			t.Fatalf("EndRecord error = %v", err)
+		}
+	}
+	w.Flush()
+	if got.String() != want.String() {
+		t.Errorf("output = %q, want %q", got.String(), want.String())
+	}
+
+	// FieldsPerRecord applies at EndRecord, and a rejected record is dropped.
+	got.Reset()
+	w = NewWriter(&got)
+	w.FieldsPerRecord = 2
+	w.WriteField("x")
+	var we *WriteError
+	if err := w.EndRecord(); !errors.As(err, &we) || we.Err != ErrFieldCount || we.Column != 1 {
+		t.Errorf("EndRecord error = %v, want ErrFieldCount at column 1", err)
+	}
+	w.WriteField("y")
+	w.WriteField("z")
+	if err := w.EndRecord(); err != nil {
+		t.Errorf("EndRecord error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close error = %v", err)
+	}
+	if got.String() != "y,z\n" {
+		t.Errorf("output = %q, want %q", got.String(), "y,z\n")
+	}
+	if err := w.WriteField("a"); err != errClosed {
+		t.Errorf("WriteField after Close = %v, want %v", err, errClosed)
+	}
+}
+
+func TestWriteWithQuoting(t *testing.T) {
+	const input = "a,\"b\",\"\",\"c\"\"d\"\n\"e\",,\"f\ng\",h i\n"
+	r := NewReader(strings.NewReader(input))
+	var b strings.Builder
+	w := NewWriter(&b)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read error = %v", err)
+		}
+		if err := w.WriteWithQuoting(record, r.LastRecordQuoted()); err != nil {
+			t.Fatalf("WriteWithQuoting error = %v", err)
+		}
+	}
+	w.Flush()
+	if b.String() != input {
+		t.Errorf("output = %q, want %q", b.String(), input)
+	}
+
+	b.Reset()
+	w = NewWriter(&b)
+	w.WriteWithQuoting([]string{"a", "b,c", "d"}, []bool{false, false})
+	w.Flush()
+	if want := "a,\"b,c\",d\n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
+
+func TestSkipColumns(t *testing.T) {
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.SkipColumns(3, 1, -1, 9, 1)
+	w.FieldsPerRecord = 2
+	if err := w.Write([]string{"a", "b", "c,d", "e"}); err != nil {
+		t.Fatalf("Write error = %v", err)
+	}
+	if err := w.WriteBytes([][]byte{[]byte("f"), []byte("g"), []byte("h")}); err != nil {
+		t.Fatalf("WriteBytes error = %v", err)
+	}
+	if err := w.WriteWithQuoting([]string{"i", "j", "k"}, []bool{false, false, true}); err != nil {
+		t.Fatalf("WriteWithQuoting error = %v", err)
+	}
+	var werr *WriteError
+	if err := w.Write([]string{"l", "m", "n", "o", "p"}); !errors.As(err, &werr) || werr.Column != 2 {
+		t.Errorf("Write of 4 fields left = %v, want a field count error at column 2", err)
+	}
+	w.SkipColumns()
+	w.FieldsPerRecord = 0
+	w.Write([]string{"q", "r"})
+	w.Flush()
+	if want := "a,\"c,d\"\nf,h\ni,\"k\"\nq,r\n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriteStrict(t *testing.T) {
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.Strict = true
+	w.WriteWithQuoting([]string{" lead", `\.`, "a,b", `c"d`, "e\nf", "g\r\nh", ""}, []bool{true})
+	w.Flush()
+	if want := " lead,\\.,\"a,b\",\"c\"\"d\",\"e\nf\",\"g\r\nh\",\r\n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+	r := NewReader(strings.NewReader(b.String()))
+	r.Strict, r.RequireCRLF = true, true
+	if _, err := r.Read(); err != nil {
+		t.Errorf("strict Read error = %v", err)
+	}
+
+	for i, set := range []func(w *Writer){
+		func(w *Writer) { w.Comma = ';' },
+		func(w *Writer) { w.Quote = '\'' },
+		func(w *Writer) { w.Escape = '\\' },
+		func(w *Writer) { w.QuoteAll = true },
+		func(w *Writer) { w.QuoteEmpty = true },
+		func(w *Writer) { w.Comment = '#' },
+		func(w *Writer) { w.Terminator = "|" },
+	} {
+		w := NewWriter(io.Discard)
+		w.Strict = true
+		set(w)
+		if err := w.Write([]string{"a"}); err != errStrictWriter {
+			t.Errorf("Write with option %d = %v, want %v", i, err, errStrictWriter)
+		}
+	}
+}
+
+func TestWriteAsymmetricQuotes(t *testing.T) {
+	records := [][]string{{"a", "b,c", "d»e", "«f"}, {"g\nh", `"i"`, "j»", ""}}
+	for _, escape := range []rune{0, '^'} {
+		var b strings.Builder
+		w := NewWriter(&b)
+		w.QuoteOpen, w.QuoteClose, w.Escape = '«', '»', escape
+		if err := w.WriteAll(records); err != nil {
+			t.Fatalf("WriteAll error = %v", err)
+		}
+		want := "a,«b,c»,«d»»e»,««f»\n«g\nh»,«\"i\"»,«j»»»,\n"
+		if escape != 0 {
+			want = "a,«b,c»,«d^»e»,««f»\n«g\nh»,«\"i\"»,«j^»»,\n"
+		}
+		if b.String() != want {
+			t.Errorf("output with Escape %q = %q, want %q", escape, b.String(), want)
+		}
+		r := NewReader(strings.NewReader(b.String()))
+		r.QuoteOpen, r.QuoteClose, r.QuoteEscape = '«', '»', escape
+		if got, err := r.ReadAll(); err != nil || !reflect.DeepEqual(got, records) {
+			t.Errorf("ReadAll = %q, %v, want %q", got, err, records)
+		}
+	}
+
+	w := NewWriter(io.Discard)
+	w.Quote, w.QuoteOpen = 0, '«'
+	if err := w.Write([]string{"a"}); err != errInvalidDelim {
+		t.Errorf("Write with only QuoteOpen = %v, want %v", err, errInvalidDelim)
+	}
+}
+
+func TestWriteBackslashEscapes(t *testing.T) {
+	str := func(s string) *string { return &s }
+	records := [][]*string{
+		{str("line\nbreak"), str("tab\there"), str("cr\r"), str("nul\x00"), str(`back\slash`)},
+		{str("a,b"), nil, str(`\N`), str(""), str(`"quoted"`)},
+		{str("#not a comment"), str(`\.`), nil, str(" space"), str("é\t, \\")},
+	}
+	for _, comma := range []rune{',', '\t'} {
+		var b strings.Builder
+		w := NewWriter(&b)
+		w.Comma = comma
+		w.Comment = '#'
+		w.EncodeBackslashEscapes = true
+		for _, record := range records {
+			if err := w.WritePtr(record); err != nil {
+				t.Fatalf("WritePtr error = %v", err)
+			}
+		}
+		w.Flush()
+		if comma == ',' {
+			want := `line\nbreak,tab\there,cr\r,nul\0,back\\slash` + "\n" +
+				`a\,b,\N,\\N,,\"quoted\"` + "\n" +
+				`\#not a comment,\\.,\N, space,é\t\, \\` + "\n"
+			if b.String() != want {
+				t.Errorf("output = %q, want %q", b.String(), want)
+			}
+		}
+
+		r := NewReader(strings.NewReader(b.String()))
+		r.Comma = comma
+		r.Comment = '#'
+		r.DecodeBackslashEscapes = true
+		r.RejectUnknownEscapes = true
+		for i, want := range records {
+			record, err := r.Read()
+			if err != nil {
+				t.Fatalf("Comma %q: Read error = %v", comma, err)
+			}
+			null := r.LastRecordNull()
+			for j, p := range want {
+				if p == nil && (!null[j] || record[j] != "") || p != nil && (null[j] || record[j] != *p) {
+					t.Errorf("Comma %q: record %d field %d = %q (null %v), want %v", comma, i, j, record[j], null[j], p)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteBackslashEscapesInvalid(t *testing.T) {
+	tests := []struct {
+		Terminator string
+		Doubled    bool // DoubledDelimiterEscape
+		Error      error
+	}{
+		{Terminator: "\n"},
+		{Terminator: "\r\n"},
+		{Terminator: ";;", Error: errInvalidDelim},
+		{Terminator: "\n;", Error: errInvalidDelim},
+		{Doubled: true, Error: errInvalidDelim},
+	}
+	for _, tt := range tests {
+		w := NewWriter(io.Discard)
+		w.EncodeBackslashEscapes = true
+		w.Terminator = tt.Terminator
+		w.DoubledDelimiterEscape = tt.Doubled
+		if err := w.Write([]string{"x", ";"}); err != tt.Error {
+			t.Errorf("Terminator %q, DoubledDelimiterEscape %v: Write error = %v, want %v", tt.Terminator, tt.Doubled, err, tt.Error)
+		}
+	}
+}
+
+func TestWriteNUL(t *testing.T) {
+	records := [][]string{{"\x00a", "b\x00c", "d\x00"}, {"\x00", "", "é\x00"}}
+	for _, escapes := range []bool{false, true} {
+		var b strings.Builder
+		w := NewWriter(&b)
+		w.EncodeBackslashEscapes = escapes
+		w.WriteAll(records)
+		want := "\"\x00a\",\"b\x00c\",\"d\x00\"\n\"\x00\",,\"é\x00\"\n"
+		if escapes {
+			want = `\0a,b\0c,d\0` + "\n" + `\0,,é\0` + "\n"
+		}
+		if b.String() != want {
+			t.Errorf("output = %q, want %q", b.String(), want)
+		}
+		r := NewReader(strings.NewReader(b.String()))
+		r.DecodeBackslashEscapes = escapes
+		if got, err := r.ReadAll(); err != nil || !reflect.DeepEqual(got, records) {
+			t.Errorf("ReadAll = %q, %v, want %q", got, err, records)
+		}
+	}
+}
+
+func TestWritePtr(t *testing.T) {
+	a := "a"
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.WritePtr([]*string{&a, nil, &a})
+	w.Flush()
+	if want := "a,,a\n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}